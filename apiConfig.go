@@ -1,24 +1,36 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 	"sync/atomic"
 
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
+	"github.com/niccolot/Chirpy/internal/auth"
+	"github.com/niccolot/Chirpy/internal/blobstore"
 	"github.com/niccolot/Chirpy/internal/customErrors"
 	"github.com/niccolot/Chirpy/internal/database"
+	"github.com/niccolot/Chirpy/internal/moderation"
+	"github.com/niccolot/Chirpy/internal/spam"
 )
 
 
 type apiConfig struct {
 	DB *database.Queries
+	Conn *sql.DB
 	FileserverHits atomic.Int32
 	Platform string
 	JWTSecret string
 	PolkaKey string
+	Moderation *moderation.Filter
+	Spam *spam.Scorer
+	FanOutLimit int64
+	Blobs blobstore.BlobStore
 }
 
 func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
@@ -30,6 +42,204 @@ func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
 	return handler
 }
 
+type contextKey string
+
+const (
+	userIdContextKey contextKey = "userId"
+	requestIdContextKey contextKey = "requestId"
+)
+
+const maxRequestIdLength = 128
+
+// middlewareRequestId tags every request with an id, reusing the X-Request-Id
+// header sent by a proxy in front of the server when there is one
+func (cfg *apiConfig) middlewareRequestId(next http.Handler) http.Handler {
+	handler := http.HandlerFunc(func (w http.ResponseWriter, r *http.Request) {
+		requestId := r.Header.Get("X-Request-Id")
+		if requestId == "" || len(requestId) > maxRequestIdLength {
+			requestId = uuid.New().String()
+		}
+
+		w.Header().Set("X-Request-Id", requestId)
+		ctx := context.WithValue(r.Context(), requestIdContextKey, requestId)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+
+	return handler
+}
+
+func requestIdFromContext(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdContextKey).(string)
+
+	return requestId
+}
+
+// middlewareRequirePermission lets the request through only if the
+// JWT in the header belongs to a role holding perm, the user id is
+// then available to the handler through userIdFromContext
+func (cfg *apiConfig) middlewareRequirePermission(perm auth.Permission, next http.HandlerFunc) http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		// the role claim of the JWT can be stale after a demotion, the
+		// role is read again on every request
+		user, errUser := cfg.DB.FindUserById(r.Context(), userId)
+		if errUser == sql.ErrNoRows {
+			e := customErrors.CodedError{
+				Message: "user not found",
+				StatusCode: http.StatusUnauthorized,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if errUser != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get user role: %w, function: %s",
+					errUser,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		errPerm := auth.CheckPermission(auth.Role(user.Role), perm)
+		if errPerm != nil {
+			respondWithError(&w, errPerm)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userIdContextKey, userId)
+		next(w, r.WithContext(ctx))
+	}
+
+	return handler
+}
+
+// authenticate returns the user owning the JWT in the request header,
+// the JWT of a suspended user is refused even if it has not expired yet
+func (cfg *apiConfig) authenticate(r *http.Request) (uuid.UUID, *customErrors.CodedError) {
+	token, errTokenHeader := auth.GetBearerToken(r.Header)
+	if errTokenHeader != nil {
+		return uuid.UUID{}, errTokenHeader
+	}
+
+	userId, _, errJWT := auth.ParseJWT(token, cfg.JWTSecret)
+	if errJWT != nil {
+		return uuid.UUID{}, errJWT
+	}
+
+	errSuspended := cfg.checkSuspension(r.Context(), userId)
+	if errSuspended != nil {
+		return uuid.UUID{}, errSuspended
+	}
+
+	return userId, nil
+}
+
+// viewer returns the user making a request to a public endpoint, or
+// uuid.Nil when the request is anonymous or its JWT is not valid
+func (cfg *apiConfig) viewer(r *http.Request) uuid.UUID {
+	if r.Header.Get("Authorization") == "" {
+		return uuid.Nil
+	}
+
+	userId, errAuth := cfg.authenticate(r)
+	if errAuth != nil {
+		return uuid.Nil
+	}
+
+	return userId
+}
+
+func userIdFromContext(ctx context.Context) uuid.UUID {
+	userId, _ := ctx.Value(userIdContextKey).(uuid.UUID)
+
+	return userId
+}
+
+// withTx runs fn inside a transaction, committing only if fn succeeds
+func (cfg *apiConfig) withTx(ctx context.Context, fn func(q *database.Queries) *customErrors.CodedError) *customErrors.CodedError {
+	tx, errTx := cfg.Conn.BeginTx(ctx, nil)
+	if errTx != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to start transaction: %w, function: %s",
+				errTx,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+	defer tx.Rollback()
+
+	errFn := fn(cfg.DB.WithTx(tx))
+	if errFn != nil {
+		return errFn
+	}
+
+	errCommit := tx.Commit()
+	if errCommit != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to commit transaction: %w, function: %s",
+				errCommit,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	return nil
+}
+
+// reloadWordLists reads the word lists from the database and swaps
+// them into the profanity filter, it is called after every edit
+func (cfg *apiConfig) reloadWordLists(ctx context.Context) *customErrors.CodedError {
+	lists, errLists := cfg.DB.GetWordLists(ctx)
+	if errLists != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to load word lists: %w, function: %s",
+				errLists,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	entries, errEntries := cfg.DB.GetWordListEntries(ctx)
+	if errEntries != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to load word list entries: %w, function: %s",
+				errEntries,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	words := map[uuid.UUID][]string{}
+	for _, entry := range entries {
+		words[entry.ListID] = append(words[entry.ListID], entry.Word)
+	}
+
+	wordLists := make([]moderation.WordList, len(lists))
+	for i, l := range lists {
+		wordLists[i] = moderation.WordList{
+			Name: l.Name,
+			Action: moderation.Action(l.Action),
+			Words: words[l.ID],
+		}
+	}
+
+	cfg.Moderation.SetLists(wordLists)
+
+	return nil
+}
+
 func NewAPIConfig(db *sql.DB) (*apiConfig, *customErrors.CodedError) {
 	errEnv := godotenv.Load()
 	if errEnv != nil {
@@ -46,12 +256,74 @@ func NewAPIConfig(db *sql.DB) (*apiConfig, *customErrors.CodedError) {
 	cfg.FileserverHits.Store(0)
 	dbQueries := database.New(db)
 	cfg.DB = dbQueries
+	cfg.Conn = db
 	platform := os.Getenv("PLATFORM")
 	cfg.Platform = platform
 	secret := os.Getenv("JWT_SECRET")
 	cfg.JWTSecret = secret
 	polkaKey := os.Getenv("POLKA_API_KEY")
 	cfg.PolkaKey = polkaKey
+	cfg.Moderation = moderation.NewFilter(nil)
+	cfg.Spam = spam.NewScorer(spam.DefaultConfig())
+	cfg.FanOutLimit = defaultFanOutLimit
+	if limit := os.Getenv("FANOUT_FOLLOWER_LIMIT"); limit != "" {
+		parsed, errLimit := strconv.ParseInt(limit, 10, 64)
+		if errLimit != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("invalid FANOUT_FOLLOWER_LIMIT: %w, function: %s",
+					errLimit,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &apiConfig{}, &e
+		}
+		cfg.FanOutLimit = parsed
+	}
+
+	blobs, errBlobs := newBlobStore()
+	if errBlobs != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to set up media storage: %w, function: %s",
+				errBlobs,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &apiConfig{}, &e
+	}
+	cfg.Blobs = blobs
+
+	errLists := cfg.reloadWordLists(context.Background())
+	if errLists != nil {
+		return &apiConfig{}, errLists
+	}
+
+	errBlocklist := cfg.reloadBlocklist(context.Background())
+	if errBlocklist != nil {
+		return &apiConfig{}, errBlocklist
+	}
 
 	return cfg, nil
-}
\ No newline at end of file
+}
+
+// newBlobStore picks the storage of the uploaded files from MEDIA_STORE,
+// local (default) keeps them in MEDIA_DIR while s3 uses the S3_* variables
+func newBlobStore() (blobstore.BlobStore, error) {
+	switch store := os.Getenv("MEDIA_STORE"); store {
+	case "", "local":
+		dir := os.Getenv("MEDIA_DIR")
+		if dir == "" {
+			dir = "media"
+		}
+		return blobstore.NewLocal(dir)
+	case "s3":
+		return blobstore.NewS3(blobstore.S3Config{
+			Endpoint: os.Getenv("S3_ENDPOINT"),
+			Region: os.Getenv("S3_REGION"),
+			Bucket: os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown MEDIA_STORE %q", store)
+	}
+}
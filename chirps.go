@@ -0,0 +1,613 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+	"github.com/niccolot/Chirpy/internal/moderation"
+	"github.com/niccolot/Chirpy/internal/spam"
+)
+
+
+// decorateChirps maps chirps to their responses adding the counters that
+// live in other tables and the state of the chirp for the viewer, uuid.Nil
+// for anonymous requests. Every handler returning chirps goes through here
+// so that the responses are the same whatever the endpoint. Chirps of users
+// blocking or blocked by the viewer and chirps the viewer can not read are
+// left out, so the result can be shorter than chirps, and rechirps of such
+// chirps are left out too. The muted words of the viewer valid everywhere
+// are applied last
+func (cfg *apiConfig) decorateChirps(ctx context.Context, viewerId uuid.UUID, chirps []database.Chirp) ([]Chirp, *customErrors.CodedError) {
+	return cfg.decorateChirpsFor(ctx, viewerId, chirps, mutedWordEverywhere)
+}
+
+// decorateChirpsFor is decorateChirps applying the muted words of the viewer
+// for scope too, it is used by the timelines
+func (cfg *apiConfig) decorateChirpsFor(ctx context.Context, viewerId uuid.UUID, chirps []database.Chirp, scope string) ([]Chirp, *customErrors.CodedError) {
+	return cfg.decorateChirpsAs(ctx, viewerId, chirps, scope, false)
+}
+
+// decorateChirpsAs is decorateChirpsFor where a moderator reads every chirp
+// whatever its visibility
+func (cfg *apiConfig) decorateChirpsAs(ctx context.Context, viewerId uuid.UUID, chirps []database.Chirp, scope string, moderator bool) ([]Chirp, *customErrors.CodedError) {
+	blocked, errBlocked := blockedAmong(ctx, cfg.DB, viewerId, chirpAuthors(chirps))
+	if errBlocked != nil {
+		return nil, errBlocked
+	}
+	chirps = withoutAuthors(chirps, blocked)
+
+	if !moderator {
+		hidden, errHidden := hiddenAmong(ctx, cfg.DB, viewerId, chirps)
+		if errHidden != nil {
+			return nil, errHidden
+		}
+		chirps = withoutChirps(chirps, hidden)
+	}
+
+	cArr, errCount := cfg.countChirps(ctx, viewerId, chirps)
+	if errCount != nil {
+		return nil, errCount
+	}
+
+	refIds := []uuid.UUID{}
+	for _, c := range chirps {
+		if c.RechirpOf.Valid {
+			refIds = append(refIds, c.RechirpOf.UUID)
+		}
+		if c.QuoteOf.Valid {
+			refIds = append(refIds, c.QuoteOf.UUID)
+		}
+	}
+
+	if len(refIds) == 0 {
+		return cfg.presentChirps(ctx, viewerId, cArr, scope)
+	}
+
+	refs, errRefs := cfg.DB.GetVisibleChirpsByIds(ctx, refIds)
+	if errRefs != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to get shared chirps: %w, function: %s",
+				errRefs,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	blockedRefs, errBlockedRefs := blockedAmong(ctx, cfg.DB, viewerId, chirpAuthors(refs))
+	if errBlockedRefs != nil {
+		return nil, errBlockedRefs
+	}
+	hiddenRefs := map[uuid.UUID]bool{}
+	if !moderator {
+		var errHiddenRefs *customErrors.CodedError
+		hiddenRefs, errHiddenRefs = hiddenAmong(ctx, cfg.DB, viewerId, refs)
+		if errHiddenRefs != nil {
+			return nil, errHiddenRefs
+		}
+	}
+	for _, ref := range refs {
+		if blockedRefs[ref.UserID] {
+			hiddenRefs[ref.ID] = true
+		}
+	}
+	refs = withoutChirps(refs, hiddenRefs)
+
+	// the shared chirps are embedded one level deep, a quote of a
+	// quote only carries the id of the innermost chirp
+	embedded, errEmbed := cfg.countChirps(ctx, viewerId, refs)
+	if errEmbed != nil {
+		return nil, errEmbed
+	}
+
+	byId := make(map[uuid.UUID]Chirp, len(embedded))
+	for _, c := range embedded {
+		byId[c.Id] = c
+	}
+
+	kept := make([]Chirp, 0, len(cArr))
+	for i := range cArr {
+		for _, ref := range []*ChirpRef{cArr[i].RechirpOf, cArr[i].QuoteOf} {
+			if ref == nil {
+				continue
+			}
+
+			c, ok := byId[ref.Id]
+			if !ok {
+				ref.Unavailable = true
+				continue
+			}
+			ref.Chirp = &c
+		}
+
+		if cArr[i].RechirpOf != nil && hiddenRefs[cArr[i].RechirpOf.Id] {
+			continue
+		}
+		kept = append(kept, cArr[i])
+	}
+
+	return cfg.presentChirps(ctx, viewerId, kept, scope)
+}
+
+// presentChirps applies the muted words and the content preferences of the
+// viewer to decorated chirps
+func (cfg *apiConfig) presentChirps(ctx context.Context, viewerId uuid.UUID, chirps []Chirp, scope string) ([]Chirp, *customErrors.CodedError) {
+	filtered, errFilter := cfg.filterChirps(ctx, viewerId, chirps, scope)
+	if errFilter != nil {
+		return nil, errFilter
+	}
+
+	return cfg.expandFlagged(ctx, viewerId, filtered)
+}
+
+// countChirps maps chirps adding the counters and the state for the viewer,
+// without loading the chirps they share
+func (cfg *apiConfig) countChirps(ctx context.Context, viewerId uuid.UUID, chirps []database.Chirp) ([]Chirp, *customErrors.CodedError) {
+	cArr := make([]Chirp, len(chirps))
+	if len(chirps) == 0 {
+		return cArr, nil
+	}
+
+	ids := make([]uuid.UUID, len(chirps))
+	for i, c := range chirps {
+		ids[i] = c.ID
+		cArr[i].mapChirp(&c)
+	}
+
+	replyCounts, errReplies := cfg.DB.GetReplyCounts(ctx, ids)
+	if errReplies != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to count replies: %w, function: %s",
+				errReplies,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	replies := make(map[uuid.UUID]int64, len(replyCounts))
+	for _, rc := range replyCounts {
+		replies[rc.ChirpID.UUID] = rc.ReplyCount
+	}
+
+	likeCounts, errLikes := cfg.DB.GetLikeCounts(ctx, ids)
+	if errLikes != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to count likes: %w, function: %s",
+				errLikes,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	likes := make(map[uuid.UUID]int64, len(likeCounts))
+	for _, lc := range likeCounts {
+		likes[lc.ChirpID] = lc.LikeCount
+	}
+
+	likedByViewer := map[uuid.UUID]bool{}
+	if viewerId != uuid.Nil {
+		likedPars := database.GetLikedAmongParams{
+			UserID: viewerId,
+			ChirpIds: ids,
+		}
+
+		liked, errLiked := cfg.DB.GetLikedAmong(ctx, likedPars)
+		if errLiked != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get liked chirps: %w, function: %s",
+					errLiked,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return nil, &e
+		}
+
+		for _, id := range liked {
+			likedByViewer[id] = true
+		}
+	}
+
+	shareCounts, errShares := cfg.DB.GetShareCounts(ctx, ids)
+	if errShares != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to count rechirps and quotes: %w, function: %s",
+				errShares,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	shares := make(map[uuid.UUID]database.GetShareCountsRow, len(shareCounts))
+	for _, sc := range shareCounts {
+		shares[sc.ChirpID] = sc
+	}
+
+	polls, errPolls := cfg.pollsFor(ctx, viewerId, ids)
+	if errPolls != nil {
+		return nil, errPolls
+	}
+
+	mediaByChirp, errMedia := cfg.mediaFor(ctx, ids)
+	if errMedia != nil {
+		return nil, errMedia
+	}
+
+	for i := range cArr {
+		cArr[i].Poll = polls[cArr[i].Id]
+		cArr[i].Media = []Media{}
+		if m, ok := mediaByChirp[cArr[i].Id]; ok {
+			cArr[i].Media = m
+		}
+		cArr[i].ReplyCount = replies[cArr[i].Id]
+		cArr[i].RechirpCount = shares[cArr[i].Id].RechirpCount
+		cArr[i].QuoteCount = shares[cArr[i].Id].QuoteCount
+		cArr[i].LikeCount = likes[cArr[i].Id]
+		cArr[i].LikedByMe = likedByViewer[cArr[i].Id]
+	}
+
+	return cArr, nil
+}
+
+func (cfg *apiConfig) decorateChirp(ctx context.Context, viewerId uuid.UUID, chirp *database.Chirp) (*Chirp, *customErrors.CodedError) {
+	cArr, errDecorate := cfg.decorateChirps(ctx, viewerId, []database.Chirp{*chirp})
+	if errDecorate != nil {
+		return nil, errDecorate
+	}
+
+	if len(cArr) == 0 {
+		e := customErrors.CodedError{
+			Message: "chirp not found",
+			StatusCode: http.StatusNotFound,
+		}
+		return nil, &e
+	}
+
+	return &cArr[0], nil
+}
+
+// visibleChirpsInOrder loads the decorated chirps with the given ids keeping
+// the order of ids, deleted chirps and chirps of suspended users are skipped
+func (cfg *apiConfig) visibleChirpsInOrder(r *http.Request, ids []uuid.UUID) ([]Chirp, *customErrors.CodedError) {
+	chirps, errChirps := cfg.DB.GetVisibleChirpsByIds(r.Context(), ids)
+	if errChirps != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to get chirps: %w, function: %s",
+				errChirps,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	byId := make(map[uuid.UUID]database.Chirp, len(chirps))
+	for _, c := range chirps {
+		byId[c.ID] = c
+	}
+
+	ordered := make([]database.Chirp, 0, len(chirps))
+	for _, id := range ids {
+		if c, ok := byId[id]; ok {
+			ordered = append(ordered, c)
+		}
+	}
+
+	return cfg.decorateChirps(r.Context(), cfg.viewer(r), ordered)
+}
+
+// deleteChirp removes a chirp, unless somebody replied to it: in that case
+// the chirp is emptied and kept as a tombstone so the thread still renders
+func deleteChirp(ctx context.Context, q *database.Queries, chirp *database.Chirp) *customErrors.CodedError {
+	hasReplies, errReplies := q.ChirpHasReplies(ctx, uuid.NullUUID{UUID: chirp.ID, Valid: true})
+	if errReplies != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to check chirp replies: %w, function: %s",
+				errReplies,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	// rechirps and tags of a hard deleted chirp go away with the foreign
+	// keys, a tombstone has to drop them explicitly
+	var errDelete error
+	if hasReplies {
+		errDelete = q.DeleteRechirpsOf(ctx, uuid.NullUUID{UUID: chirp.ID, Valid: true})
+		if errDelete == nil {
+			errDelete = q.DeleteChirpTags(ctx, chirp.ID)
+		}
+		if errDelete == nil {
+			errDelete = q.SoftDeleteChirp(ctx, chirp.ID)
+		}
+	} else {
+		errDelete = q.DeleteChirpById(ctx, chirp.ID)
+	}
+
+	if errDelete != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to delete chirp %s: %w, function: %s",
+				chirp.ID,
+				errDelete,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	return nil
+}
+
+// deletedUserId is the account created by the migrations that owns the
+// tombstones of the users who deleted their account. It is not a user,
+// the profile and the user endpoints answer 404 for it
+var deletedUserId = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+// tombstoneUserChirps keeps the chirps of userId that somebody else replied
+// to, and the chirps of userId above them in the thread, as tombstones of
+// the deleted user account, the others go away with the account
+func tombstoneUserChirps(ctx context.Context, q *database.Queries, userId uuid.UUID) *customErrors.CodedError {
+	tombstonePars := database.TombstoneUserChirpsParams{
+		UserID: userId,
+		DeletedUserID: deletedUserId,
+	}
+
+	ids, errTombstone := q.TombstoneUserChirps(ctx, tombstonePars)
+	if errTombstone != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to keep chirps as tombstones: %w, function: %s",
+				errTombstone,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// as in deleteChirp the tombstones drop their rechirps and tags
+	errDelete := q.DeleteRechirpsOfChirps(ctx, ids)
+	if errDelete == nil {
+		errDelete = q.DeleteTagsOfChirps(ctx, ids)
+	}
+
+	if errDelete != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to clean tombstones: %w, function: %s",
+				errDelete,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	return nil
+}
+
+// replyTarget resolves the chirp a new chirp of userId replies to, returning
+// the in_reply_to and root_id to store with it
+func (cfg *apiConfig) replyTarget(ctx context.Context, userId uuid.UUID, inReplyTo *uuid.UUID) (uuid.NullUUID, uuid.NullUUID, *customErrors.CodedError) {
+	if inReplyTo == nil {
+		return uuid.NullUUID{}, uuid.NullUUID{}, nil
+	}
+
+	parent, errParent := cfg.sharedChirp(ctx, userId, *inReplyTo)
+	if errParent == sql.ErrNoRows {
+		e := customErrors.CodedError{
+			Message: "chirp to reply to not found",
+			StatusCode: http.StatusNotFound,
+		}
+		return uuid.NullUUID{}, uuid.NullUUID{}, &e
+	}
+
+	if errParent != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to get chirp to reply to: %w, function: %s",
+				errParent,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return uuid.NullUUID{}, uuid.NullUUID{}, &e
+	}
+
+	rootId := parent.RootID
+	if !rootId.Valid {
+		rootId = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+
+	return uuid.NullUUID{UUID: parent.ID, Valid: true}, rootId, nil
+}
+
+// quoteTarget resolves the chirp quoted by a new chirp of userId
+func (cfg *apiConfig) quoteTarget(ctx context.Context, userId uuid.UUID, quoteOf *uuid.UUID) (uuid.NullUUID, *customErrors.CodedError) {
+	if quoteOf == nil {
+		return uuid.NullUUID{}, nil
+	}
+
+	quoted, errQuoted := cfg.sharedChirp(ctx, userId, *quoteOf)
+	if errQuoted == sql.ErrNoRows {
+		e := customErrors.CodedError{
+			Message: "chirp to quote not found",
+			StatusCode: http.StatusNotFound,
+		}
+		return uuid.NullUUID{}, &e
+	}
+
+	if errQuoted != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to get chirp to quote: %w, function: %s",
+				errQuoted,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return uuid.NullUUID{}, &e
+	}
+
+	return uuid.NullUUID{UUID: quoted.ID, Valid: true}, nil
+}
+
+// sharedChirp returns the visible chirp with the given id that userId can
+// reply to, quote or rechirp. A rechirp is replaced by the chirp it reposts,
+// since replies and shares always go to the original. Chirps of users with a
+// block in place and chirps userId can not read are reported as missing
+func (cfg *apiConfig) sharedChirp(ctx context.Context, userId uuid.UUID, id uuid.UUID) (database.Chirp, error) {
+	chirp, errChirp := cfg.DB.GetVisibleChirp(ctx, id)
+	if errChirp == nil && chirp.RechirpOf.Valid {
+		chirp, errChirp = cfg.DB.GetVisibleChirp(ctx, chirp.RechirpOf.UUID)
+	}
+	if errChirp != nil {
+		return chirp, errChirp
+	}
+
+	blockedPars := database.GetBlockedAmongParams{
+		UserID: userId,
+		UserIds: []uuid.UUID{chirp.UserID},
+	}
+
+	blocked, errBlocked := cfg.DB.GetBlockedAmong(ctx, blockedPars)
+	if errBlocked != nil {
+		return database.Chirp{}, errBlocked
+	}
+
+	if len(blocked) > 0 {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+
+	readable, errRead := canRead(ctx, cfg.DB, userId, &chirp)
+	if errRead != nil {
+		return database.Chirp{}, errors.New(errRead.Message)
+	}
+
+	if !readable {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+
+	return chirp, nil
+}
+
+// prepareChirp runs the checks on a new chirp of userId and resolves what
+// it replies to and quotes, the body in req is censored in place. A chirp
+// refused for posting too fast comes back with the spam result holding
+// the time to wait
+func (cfg *apiConfig) prepareChirp(ctx context.Context, userId uuid.UUID, req *chirpPostRequest) (database.CreateChirpParams, spam.Result, *customErrors.CodedError) {
+	action, errChirpValidation := ValidateChirp(&req.Body, cfg.Moderation)
+	if errChirpValidation != nil {
+		return database.CreateChirpParams{}, spam.Result{}, errChirpValidation
+	}
+
+	if req.Poll != nil {
+		errPoll := validatePoll(req.Poll, cfg)
+		if errPoll != nil {
+			return database.CreateChirpParams{}, spam.Result{}, errPoll
+		}
+	}
+
+	errMedia := checkChirpMedia(req.MediaIds)
+	if errMedia != nil {
+		return database.CreateChirpParams{}, spam.Result{}, errMedia
+	}
+
+	errVisibility := validateVisibility(&req.Visibility)
+	if errVisibility != nil {
+		return database.CreateChirpParams{}, spam.Result{}, errVisibility
+	}
+
+	errWarning := validateContentWarning(&req.ContentWarning, cfg)
+	if errWarning != nil {
+		return database.CreateChirpParams{}, spam.Result{}, errWarning
+	}
+
+	inReplyTo, rootId, errReply := cfg.replyTarget(ctx, userId, req.InReplyTo)
+	if errReply != nil {
+		return database.CreateChirpParams{}, spam.Result{}, errReply
+	}
+
+	quoteOf, errQuote := cfg.quoteTarget(ctx, userId, req.QuoteOf)
+	if errQuote != nil {
+		return database.CreateChirpParams{}, spam.Result{}, errQuote
+	}
+
+	spamResult, errSpam := cfg.checkSpam(ctx, userId, req.Body)
+	if errSpam != nil {
+		return database.CreateChirpParams{}, spamResult, errSpam
+	}
+
+	chirpPars := database.CreateChirpParams{
+		Body: req.Body,
+		UserID: userId,
+		NeedsReview: action == moderation.ActionFlag || spamResult.Verdict == spam.VerdictReview,
+		BodyHash: spamResult.Hash,
+		Simhash: int64(spamResult.Simhash),
+		InReplyTo: inReplyTo,
+		RootID: rootId,
+		QuoteOf: quoteOf,
+		Visibility: req.Visibility,
+		ContentWarning: req.ContentWarning,
+		Sensitive: req.Sensitive,
+	}
+
+	return chirpPars, spamResult, nil
+}
+
+// insertChirp stores a prepared chirp with its tags, poll, media and
+// mentions, returning the users it newly mentions
+func insertChirp(ctx context.Context, q *database.Queries, chirpPars *database.CreateChirpParams, req *chirpPostRequest) (database.Chirp, []uuid.UUID, *customErrors.CodedError) {
+	chirp, errChirp := q.CreateChirp(ctx, *chirpPars)
+	if errChirp != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to create chirp: %w, function: %s",
+				errChirp,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return database.Chirp{}, nil, &e
+	}
+
+	errTags := tagChirp(ctx, q, &chirp, chirp.Body)
+	if errTags != nil {
+		return database.Chirp{}, nil, errTags
+	}
+
+	if req.Poll != nil {
+		errPoll := createPoll(ctx, q, chirp.ID, req.Poll)
+		if errPoll != nil {
+			return database.Chirp{}, nil, errPoll
+		}
+	}
+
+	errMedia := attachMedia(ctx, q, &chirp, req.MediaIds)
+	if errMedia != nil {
+		return database.Chirp{}, nil, errMedia
+	}
+
+	mentioned, errMentions := mentionChirp(ctx, q, &chirp, chirp.Body)
+	if errMentions != nil {
+		return database.Chirp{}, nil, errMentions
+	}
+
+	return chirp, mentioned, nil
+}
+
+// announceChirp notifies the users concerned by a stored chirp and copies
+// it in the home timelines of the followers of its author
+func (cfg *apiConfig) announceChirp(ctx context.Context, chirp *database.Chirp, mentioned []uuid.UUID) {
+	cfg.notifyChirp(ctx, chirp, mentioned)
+
+	// a chirp that is not fanned out is still read from the chirps table,
+	// so a failure here only makes the timelines of the followers slower
+	errFanOut := cfg.fanOutChirp(ctx, chirp)
+	if errFanOut != nil {
+		log.Printf("fan out of chirp %s: %s", chirp.ID, errFanOut.Message)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/niccolot/Chirpy/internal/auth"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+)
+
+
+// createAdminCommand bootstraps the first admin account, run as
+//
+//	chirpy create-admin -email admin@chirpy.com -password <password>
+//
+// the password can also be given with the CHIRPY_ADMIN_PASSWORD environment
+// variable, if the user already exists it is promoted to admin
+func createAdminCommand(db *sql.DB, args []string) *customErrors.CodedError {
+	flags := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	email := flags.String("email", "", "email of the admin account")
+	password := flags.String("password", os.Getenv("CHIRPY_ADMIN_PASSWORD"), "password of the admin account")
+
+	errParse := flags.Parse(args)
+	if errParse != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to parse arguments: %w, function: %s",
+				errParse,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+		return &e
+	}
+
+	if *email == "" {
+		e := customErrors.CodedError{
+			Message: "usage: chirpy create-admin -email <email> [-password <password>]",
+			StatusCode: http.StatusBadRequest,
+		}
+		return &e
+	}
+
+	ctx := context.Background()
+	dbQueries := database.New(db)
+
+	user, errUser := dbQueries.FindUserByEmail(ctx, *email)
+	if errUser == sql.ErrNoRows {
+		if *password == "" {
+			e := customErrors.CodedError{
+				Message: "a password is needed to create a new admin account",
+				StatusCode: http.StatusBadRequest,
+			}
+			return &e
+		}
+
+		hashedPassword, errHashing := auth.HashPassword(*password)
+		if errHashing != nil {
+			return errHashing
+		}
+
+		userPars := database.CreateUserParams{
+			Email: *email,
+			HashedPassword: hashedPassword,
+		}
+
+		user, errUser = dbQueries.CreateUser(ctx, userPars)
+	}
+
+	if errUser != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to find or create user: %w, function: %s",
+				errUser,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	rolePars := database.SetUserRoleParams{
+		ID: user.ID,
+		Role: string(auth.RoleAdmin),
+	}
+
+	errRole := dbQueries.SetUserRole(ctx, rolePars)
+	if errRole != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to set admin role: %w, function: %s",
+				errRole,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	fmt.Printf("user %s (%s) is now an admin\n", user.Email, user.ID)
+
+	return nil
+}
@@ -8,4 +8,6 @@ require (
 	github.com/joho/godotenv v1.5.1
 	github.com/lib/pq v1.10.9
 	golang.org/x/crypto v0.27.0
+	golang.org/x/image v0.18.0
+	golang.org/x/text v0.18.0
 )
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"text/template"
 	"time"
 
@@ -11,6 +16,8 @@ import (
 	"github.com/niccolot/Chirpy/internal/auth"
 	"github.com/niccolot/Chirpy/internal/customErrors"
 	"github.com/niccolot/Chirpy/internal/database"
+	"github.com/niccolot/Chirpy/internal/moderation"
+	"github.com/niccolot/Chirpy/internal/spam"
 )
 
 
@@ -57,6 +64,7 @@ func metricshandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.R
 
 func resetMetricshandlerWrapperd(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
 	resetMetricsHandler := func(w http.ResponseWriter, r *http.Request) {
+		// wiping the database stays a dev only operation, even for admins
 		if cfg.Platform != "dev" {
 			e := customErrors.CodedError{
 				Message: "forbidden request",
@@ -66,7 +74,7 @@ func resetMetricshandlerWrapperd(cfg *apiConfig) func(w http.ResponseWriter, r *
 			return
 		}
 
-		errDelete := cfg.DB.Reset(r.Context())
+		errDelete := cfg.DB.Reset(r.Context(), deletedUserId)
 		if errDelete != nil {
 			e := customErrors.CodedError{
 				Message: fmt.Errorf("error executing reset request: %w, function: %s", 
@@ -77,6 +85,11 @@ func resetMetricshandlerWrapperd(cfg *apiConfig) func(w http.ResponseWriter, r *
 			respondWithError(&w, &e)
 			return
 		}
+
+		cfg.recordAudit(r, auditEvent{
+			Action: auditReset,
+			ActorId: userIdFromContext(r.Context()),
+		})
 	}
 
 	return resetMetricsHandler
@@ -96,47 +109,45 @@ func postChirphandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http
 				StatusCode: http.StatusInternalServerError,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
 		}
 
-		token, errGetToken := auth.GetBearerToken(r.Header)
-		if errGetToken != nil {
-			respondWithError(&w, errGetToken)
-			return 
+		id, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
 		}
 
-		id, errValidateAuthor := auth.ValidateJWT(token, cfg.JWTSecret)
-		if errValidateAuthor != nil {
-			respondWithError(&w, errValidateAuthor)
+		chirpPars, spamResult, errPrepare := cfg.prepareChirp(r.Context(), id, &req)
+		if errPrepare != nil {
+			if errPrepare.StatusCode == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(spamResult.RetryAfter.Seconds()))))
+			}
+			respondWithError(&w, errPrepare)
+			return
 		}
 
-		errChirpValidation := ValidateChirp(&req.Body)
-		if errChirpValidation != nil {
-			respondWithError(&w, errChirpValidation)
-			return 
+		var chirp database.Chirp
+		var mentioned []uuid.UUID
+		errCreate := cfg.withTx(r.Context(), func(q *database.Queries) *customErrors.CodedError {
+			var errInsert *customErrors.CodedError
+			chirp, mentioned, errInsert = insertChirp(r.Context(), q, &chirpPars, &req)
+			return errInsert
+		})
+		if errCreate != nil {
+			respondWithError(&w, errCreate)
+			return
 		}
 
-		chirpPars := database.CreateChirpParams{
-			Body: req.Body,
-			UserID: id,
-		}
+		cfg.announceChirp(context.WithoutCancel(r.Context()), &chirp, mentioned)
 
-		chirp, errChirp := cfg.DB.CreateChirp(r.Context(), chirpPars)
-		if errChirp != nil {
-			e := customErrors.CodedError{
-				Message: fmt.Errorf("failed to create chirp: %w, function: %s", 
-					errChirp, 
-					customErrors.GetFunctionName()).Error(),
-				StatusCode: http.StatusInternalServerError,
-			}
-			respondWithError(&w, &e)
-			return 
+		c, errDecorate := cfg.decorateChirp(r.Context(), id, &chirp)
+		if errDecorate != nil {
+			respondWithError(&w, errDecorate)
+			return
 		}
 
-		c := Chirp{}
-		c.mapChirp(&chirp)
-
-		respSuccesfullChirpPost(&w, &c)
+		respSuccesfullChirpPost(&w, c)
 	}
 
 	return postChirpHandler
@@ -161,7 +172,7 @@ func getAllChirpsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *h
 					StatusCode: http.StatusInternalServerError,
 				}
 				respondWithError(&w, &e)
-				return 
+				return
 			}
 		}
 		
@@ -192,12 +203,13 @@ func getAllChirpsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *h
 				StatusCode: http.StatusInternalServerError,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
 		}
 
-		cArr := make([]Chirp, len(chirpsArr))
-		for i, c := range chirpsArr {
-			cArr[i].mapChirp(&c)
+		cArr, errDecorate := cfg.decorateChirps(r.Context(), cfg.viewer(r), chirpsArr)
+		if errDecorate != nil {
+			respondWithError(&w, errDecorate)
+			return
 		}
 
 		respSuccesfullChirpsAllGet(&w, cArr)
@@ -219,10 +231,10 @@ func getChirspHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http
 				StatusCode: http.StatusInternalServerError,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
 		}
 
-		chirp, errChirp := cfg.DB.GetChirp(r.Context(), uuid)
+		chirp, errChirp := cfg.DB.GetVisibleChirp(r.Context(), uuid)
 		if errChirp != nil {
 			e := customErrors.CodedError{
 				Message: fmt.Errorf("failed to get chirp: %w, function: %s", 
@@ -231,13 +243,16 @@ func getChirspHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http
 				StatusCode: http.StatusNotFound,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
 		}
 
-		c := Chirp{}
-		c.mapChirp(&chirp)
+		c, errDecorate := cfg.decorateChirp(r.Context(), cfg.viewer(r), &chirp)
+		if errDecorate != nil {
+			respondWithError(&w, errDecorate)
+			return
+		}
 
-		respSuccesfullChirpsGet(&w, &c)
+		respSuccesfullChirpsGet(&w, c)
 	}
 
 	return getChirpsHandler
@@ -246,16 +261,10 @@ func getChirspHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http
 func deleteChirpsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
 	deleteChirpsHandler := func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type: application/json", "charset=utf-8")
-		token, errTokenHeader := auth.GetBearerToken(r.Header)
-		if errTokenHeader != nil {
-			respondWithError(&w, errTokenHeader)
-			return 
-		}
-
-		userId, errJWT := auth.ValidateJWT(token, cfg.JWTSecret)
-		if errJWT != nil {
-			respondWithError(&w, errJWT)
-			return 
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
 		}
 
 		chirpId := r.PathValue("id")
@@ -268,44 +277,38 @@ func deleteChirpsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *h
 				StatusCode: http.StatusInternalServerError,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
 		}
 
 		chirp, errFindChirp := cfg.DB.GetChirp(r.Context(), chirpUUID)
-		if errFindChirp != nil {
+		if errFindChirp != nil || chirp.DeletedAt.Valid {
 			e := customErrors.CodedError{
 				Message: "chirp not found",
 				StatusCode: http.StatusNotFound,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
 		}
 
 		errCompare := auth.CompareUUIDs(&userId, &chirp.UserID)
 		if errCompare != nil {
 			respondWithError(&w, errCompare)
-			return 
+			return
 		}	
 		
-		delChirpParams := &database.DeleteChirpParams{
-			ID: chirp.ID,
-			UserID: userId,
-		}
-
-		errDelete := cfg.DB.DeleteChirp(r.Context(), *delChirpParams)
+		errDelete := deleteChirp(r.Context(), cfg.DB, &chirp)
 		if errDelete != nil {
-			e := customErrors.CodedError{
-				Message: fmt.Errorf("failed to delete chirp %s: %w, fucntion: %s",
-					string(chirpId),
-					errDelete,
-					customErrors.GetFunctionName()).Error(),
-				StatusCode: http.StatusInternalServerError,
-			}
-
-			respondWithError(&w, &e)
-			return 
+			respondWithError(&w, errDelete)
+			return
 		}
 
+		cfg.recordAudit(r, auditEvent{
+			Action: auditChirpDeleted,
+			ActorId: userId,
+			TargetType: "chirp",
+			TargetId: chirp.ID.String(),
+		})
+
 		respNoContent(&w)
 	}
 
@@ -315,16 +318,10 @@ func deleteChirpsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *h
 func putChirpsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
 	putChirpsHandler := func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type: application/json", "charset=utf-8")
-		token, errTokenHeader := auth.GetBearerToken(r.Header)
-		if errTokenHeader != nil {
-			respondWithError(&w, errTokenHeader)
-			return 
-		}
-
-		userId, errJWT := auth.ValidateJWT(token, cfg.JWTSecret)
-		if errJWT != nil {
-			respondWithError(&w, errJWT)
-			return 
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
 		}
 
 		decoder := json.NewDecoder(r.Body)
@@ -338,7 +335,7 @@ func putChirpsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http
 				StatusCode: http.StatusInternalServerError,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
 		}
 
 		chirp, errChirp := cfg.DB.GetChirp(r.Context(), req.ChirpId)
@@ -350,29 +347,71 @@ func putChirpsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http
 				StatusCode: http.StatusInternalServerError,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
+		}
+
+		if chirp.DeletedAt.Valid {
+			e := customErrors.CodedError{
+				Message: "chirp not found",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
 		}
 
 		errCompare := auth.CompareUUIDs(&userId, &chirp.UserID)
 		if errCompare != nil {
 			respondWithError(&w, errCompare)
-			return 
-		}
-
-		updateChirpParams := &database.UpdateChirpParams{
-			ID: req.ChirpId,
-			Body: req.Body,
+			return
 		}
 
-		errUpdate := cfg.DB.UpdateChirp(r.Context(), *updateChirpParams)
-		if errUpdate != nil {
+		if chirp.RechirpOf.Valid {
 			e := customErrors.CodedError{
-				Message: fmt.Errorf("failed to update chirp: %w, function: %s", 
-					errUpdate, 
-					customErrors.GetFunctionName()).Error(),
-				StatusCode: http.StatusInternalServerError,
+				Message: "rechirps can not be edited",
+				StatusCode: http.StatusBadRequest,
 			}
 			respondWithError(&w, &e)
+			return
+		}
+
+		updateChirpParams, visibility, errEdit := chirpEdit(cfg, &chirp, &req)
+		if errEdit != nil {
+			respondWithError(&w, errEdit)
+			return
+		}
+
+		var mentioned []uuid.UUID
+		errUpdate := cfg.withTx(r.Context(), func(q *database.Queries) *customErrors.CodedError {
+			if visibility != chirp.Visibility {
+				errVisibility := setChirpVisibility(r.Context(), q, &chirp, visibility)
+				if errVisibility != nil {
+					return errVisibility
+				}
+			}
+
+			errChirp := q.UpdateChirp(r.Context(), updateChirpParams)
+			if errChirp != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to update chirp: %w, function: %s", 
+						errChirp, 
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				return &e
+			}
+
+			errTags := tagChirp(r.Context(), q, &chirp, req.Body)
+			if errTags != nil {
+				return errTags
+			}
+
+			var errMentions *customErrors.CodedError
+			mentioned, errMentions = mentionChirp(r.Context(), q, &chirp, req.Body)
+			return errMentions
+		})
+		if errUpdate != nil {
+			respondWithError(&w, errUpdate)
+			return
 		}
 
 		chirp, errFind := cfg.DB.GetChirp(r.Context(), chirp.ID)
@@ -384,18 +423,71 @@ func putChirpsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http
 				StatusCode: http.StatusInternalServerError,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
 		}
 
-		c := Chirp{}
-		c.mapChirp(&chirp)
+		// only the mentions added by the edit are notified, the replied
+		// author already was when the chirp was posted
+		cfg.notify(r, mentionEvents(&chirp, mentioned)...)
 
-		respSuccesfullChirpPut(&w, &c)
+		c, errDecorate := cfg.decorateChirp(r.Context(), userId, &chirp)
+		if errDecorate != nil {
+			respondWithError(&w, errDecorate)
+			return
+		}
+
+		respSuccesfullChirpPut(&w, c)
 	}
 
 	return putChirpsHandler
 }
 
+// chirpEdit validates an edit of chirp and returns its update along with the
+// visibility asked for, the fields left out of the request keep their value
+func chirpEdit(cfg *apiConfig, chirp *database.Chirp, req *chirpPutRequest) (database.UpdateChirpParams, string, *customErrors.CodedError) {
+	action, errChirpValidation := ValidateChirp(&req.Body, cfg.Moderation)
+	if errChirpValidation != nil {
+		return database.UpdateChirpParams{}, "", errChirpValidation
+	}
+
+	contentWarning := chirp.ContentWarning
+	if req.ContentWarning != nil {
+		contentWarning = *req.ContentWarning
+		errWarning := validateContentWarning(&contentWarning, cfg)
+		if errWarning != nil {
+			return database.UpdateChirpParams{}, "", errWarning
+		}
+	}
+
+	sensitive := chirp.Sensitive
+	if req.Sensitive != nil {
+		sensitive = *req.Sensitive
+	}
+
+	visibility := chirp.Visibility
+	if req.Visibility != nil {
+		visibility = *req.Visibility
+		errVisibility := validateVisibility(&visibility)
+		if errVisibility != nil {
+			return database.UpdateChirpParams{}, "", errVisibility
+		}
+	}
+
+	updateChirpParams := database.UpdateChirpParams{
+		ID: chirp.ID,
+		Body: req.Body,
+		FlagForReview: action == moderation.ActionFlag,
+		BodyHash: spam.Hash(req.Body),
+		Simhash: int64(spam.Simhash(req.Body)),
+		// the forced warnings of the moderators are separate columns
+		// that an edit never touches
+		ContentWarning: contentWarning,
+		Sensitive: sensitive,
+	}
+
+	return updateChirpParams, visibility, nil
+}
+
 func postUsersHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
 	postUsersHandler := func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type: application/json", "charset=utf-8")
@@ -410,7 +502,7 @@ func postUsersHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http
 				StatusCode: http.StatusInternalServerError,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
 		}
 
 		hashed_password, errHashing := auth.HashPassword(req.Password)
@@ -433,7 +525,7 @@ func postUsersHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http
 				StatusCode: http.StatusInternalServerError,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
 		}
 
 		u := User{}
@@ -448,16 +540,10 @@ func postUsersHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http
 func putUsersHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
 	putUsersHandlerWrapped := func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type: application/json", "charset=utf-8")
-		token, errTokenHeader := auth.GetBearerToken(r.Header)
-		if errTokenHeader != nil {
-			respondWithError(&w, errTokenHeader)
-			return 
-		}
-
-		userId, errJWT := auth.ValidateJWT(token, cfg.JWTSecret)
-		if errJWT != nil {
-			respondWithError(&w, errJWT)
-			return 
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
 		}
 
 		decoder := json.NewDecoder(r.Body)
@@ -471,7 +557,17 @@ func putUsersHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.
 				StatusCode: http.StatusInternalServerError,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
+		}
+
+		oldUser, errOldUser := cfg.DB.FindUserById(r.Context(), userId)
+		if errOldUser != nil {
+			e := customErrors.CodedError{
+				Message: "user not found",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
 		}
 
 		hashedPassword, errHash := auth.HashPassword(req.Password)
@@ -511,12 +607,37 @@ func putUsersHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.
 				StatusCode: http.StatusInternalServerError,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
+		}
+
+		if oldUser.Email != user.Email {
+			cfg.recordAudit(r, auditEvent{
+				Action: auditEmailChanged,
+				ActorId: userId,
+				TargetType: "user",
+				TargetId: userId.String(),
+				Metadata: map[string]any{"old_email": oldUser.Email, "new_email": user.Email},
+			})
+		}
+
+		if auth.CheckPasswordHash(req.Password, oldUser.HashedPassword) != nil {
+			cfg.recordAudit(r, auditEvent{
+				Action: auditPasswordChanged,
+				ActorId: userId,
+				TargetType: "user",
+				TargetId: userId.String(),
+			})
 		}
 
 		u := User{}
 		u.mapUser(&user)
 
+		errCounts := cfg.setFollowCounts(r.Context(), &u)
+		if errCounts != nil {
+			respondWithError(&w, errCounts)
+			return
+		}
+
 		respSuccesfullUserPut(&w, &u)
 	}
 
@@ -526,16 +647,10 @@ func putUsersHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.
 func deleteUsersHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
 	deleteUsersHandler := func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type: application/json", "charset=utf-8")
-		token, errTokenHeader := auth.GetBearerToken(r.Header)
-		if errTokenHeader != nil {
-			respondWithError(&w, errTokenHeader)
-			return 
-		}
-
-		userId, errJWT := auth.ValidateJWT(token, cfg.JWTSecret)
-		if errJWT != nil {
-			respondWithError(&w, errJWT)
-			return 
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
 		}
 
 		userIdHeader := r.PathValue("id")
@@ -548,26 +663,54 @@ func deleteUsersHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *ht
 				StatusCode: http.StatusInternalServerError,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
 		}
 
 		errCompare := auth.CompareUUIDs(&userId, &userUUIDHeader)
 		if errCompare != nil {
 			respondWithError(&w, errCompare)
-			return 
+			return
 		}	
 
-		errDelete := cfg.DB.DeleteUser(r.Context(), userId)
-		if errDelete != nil {
-			e := customErrors.CodedError{
-				Message: fmt.Errorf("failed to delete user: %w, fucntion: %s",
-					errDelete,
-					customErrors.GetFunctionName()).Error(),
-				StatusCode: http.StatusInternalServerError,
+		var deleted database.DeleteUserRow
+		errDelete := cfg.withTx(r.Context(), func(q *database.Queries) *customErrors.CodedError {
+			errTombstones := tombstoneUserChirps(r.Context(), q, userId)
+			if errTombstones != nil {
+				return errTombstones
 			}
 
-			respondWithError(&w, &e)
-			return 
+			var errUser error
+			deleted, errUser = q.DeleteUser(r.Context(), userId)
+			if errUser != nil && !errors.Is(errUser, sql.ErrNoRows) {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to delete user: %w, fucntion: %s",
+						errUser,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				return &e
+			}
+
+			return nil
+		})
+		if errDelete != nil {
+			respondWithError(&w, errDelete)
+			return
+		}
+
+		cfg.recordAudit(r, auditEvent{
+			Action: auditUserDeleted,
+			ActorId: userId,
+			TargetType: "user",
+			TargetId: userId.String(),
+		})
+
+		// the uploaded media are collected by the media worker
+		if deleted.AvatarKey.Valid {
+			cfg.deleteProfileImage(r.Context(), &avatarImage, deleted.AvatarKey.String)
+		}
+		if deleted.BannerKey.Valid {
+			cfg.deleteProfileImage(r.Context(), &bannerImage, deleted.BannerKey.String)
 		}
 
 		respNoContent(&w)
@@ -590,11 +733,16 @@ func postLoginHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http
 				StatusCode: http.StatusInternalServerError,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
 		}
 
 		user, errUser := cfg.DB.FindUserByEmail(r.Context(), req.Email)
 		if errUser != nil {
+			cfg.recordAudit(r, auditEvent{
+				Action: auditLoginFailed,
+				Metadata: map[string]any{"email": req.Email, "reason": "unknown email"},
+			})
+
 			e := customErrors.CodedError{
 				Message: fmt.Errorf("failed to find user: %w, function: %s", 
 					errUser, 
@@ -602,16 +750,36 @@ func postLoginHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http
 				StatusCode: http.StatusInternalServerError,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
 		}
 
 		check := auth.CheckPasswordHash(req.Password, user.HashedPassword)
 		if check != nil {
+			cfg.recordAudit(r, auditEvent{
+				Action: auditLoginFailed,
+				TargetType: "user",
+				TargetId: user.ID.String(),
+				Metadata: map[string]any{"email": req.Email, "reason": "wrong password"},
+			})
+
 			respondWithError(&w, check)
-			return 
+			return
 		}
 
-		token, refreshToken, errToken := auth.MakeJWT(user.ID, cfg.JWTSecret)
+		errSuspended := cfg.checkSuspension(r.Context(), user.ID)
+		if errSuspended != nil {
+			cfg.recordAudit(r, auditEvent{
+				Action: auditLoginFailed,
+				TargetType: "user",
+				TargetId: user.ID.String(),
+				Metadata: map[string]any{"email": req.Email, "reason": "suspended"},
+			})
+
+			respondWithError(&w, errSuspended)
+			return
+		}
+
+		token, refreshToken, errToken := auth.MakeJWT(user.ID, auth.Role(user.Role), cfg.JWTSecret)
 		if errToken != nil {
 			e := customErrors.CodedError{
 				Message: fmt.Errorf("failed to generate jwt: %w, function: %s", 
@@ -620,7 +788,7 @@ func postLoginHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http
 				StatusCode: http.StatusInternalServerError,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
 		}
 
 		expiresAt := time.Now().Add(60 * 24 * time.Hour)
@@ -640,12 +808,25 @@ func postLoginHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http
 				StatusCode: http.StatusInternalServerError,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
 		}
 
+		cfg.recordAudit(r, auditEvent{
+			Action: auditLogin,
+			ActorId: user.ID,
+			TargetType: "user",
+			TargetId: user.ID.String(),
+		})
+
 		u := User{}
 		u.mapUser(&user)
 
+		errCounts := cfg.setFollowCounts(r.Context(), &u)
+		if errCounts != nil {
+			respondWithError(&w, errCounts)
+			return
+		}
+
 		respSuccesfullLoginPost(&w, &u, &token, &refreshToken)
 	}
 
@@ -667,13 +848,13 @@ func postRefreshHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *ht
 				StatusCode: http.StatusNotFound,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
 		}
 
 		errValid := auth.CheckValidityRefreshToken(&tokenObj)
 		if errValid != nil {
 			respondWithError(&w, errValid)
-			return 
+			return
 		}
 
 		userId, errSearch := cfg.DB.GetUserFromRefreshToken(r.Context(), token)
@@ -683,10 +864,26 @@ func postRefreshHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *ht
 				StatusCode: http.StatusUnauthorized,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
+		}
+
+		errSuspended := cfg.checkSuspension(r.Context(), userId)
+		if errSuspended != nil {
+			respondWithError(&w, errSuspended)
+			return
 		}
 
-		newToken, refreshToken, errToken := auth.MakeJWT(userId, cfg.JWTSecret)
+		user, errUser := cfg.DB.FindUserById(r.Context(), userId)
+		if errUser != nil {
+			e := customErrors.CodedError{
+				Message: "invalid jwt",
+				StatusCode: http.StatusUnauthorized,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		newToken, refreshToken, errToken := auth.MakeJWT(userId, auth.Role(user.Role), cfg.JWTSecret)
 		if errToken != nil {
 			respondWithError(&w, errToken)
 		}
@@ -705,6 +902,16 @@ func postRevokeHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *htt
 			return
 		}
 
+		tokenObj, errObj := cfg.DB.GetRefreshToken(r.Context(), token)
+		if errObj != nil {
+			e := customErrors.CodedError{
+				Message: "token not in database",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
 		errRevoke := cfg.DB.RevokeToken(r.Context(), token)
 		if errRevoke != nil {
 			e := customErrors.CodedError{
@@ -712,9 +919,16 @@ func postRevokeHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *htt
 				StatusCode: http.StatusNotFound,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
 		}
 
+		cfg.recordAudit(r, auditEvent{
+			Action: auditTokenRevoked,
+			ActorId: tokenObj.UserID,
+			TargetType: "user",
+			TargetId: tokenObj.UserID.String(),
+		})
+
 		respNoContent(&w)
 	}
 
@@ -747,7 +961,7 @@ func postPolkaWebhookHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter,
 				StatusCode: http.StatusInternalServerError,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
 		}
 
 		if req.Event != "user.upgraded" {
@@ -765,7 +979,7 @@ func postPolkaWebhookHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter,
 				StatusCode: http.StatusNotFound,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
 		}
 
 		errUpgrade := cfg.DB.UpgradeChirpyRed(r.Context(), *userId)
@@ -777,11 +991,130 @@ func postPolkaWebhookHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter,
 				StatusCode: http.StatusInternalServerError,
 			}
 			respondWithError(&w, &e)
-			return 
+			return
 		}
 
+		cfg.recordAudit(r, auditEvent{
+			Action: auditUserUpgraded,
+			TargetType: "user",
+			TargetId: userId.String(),
+			Metadata: map[string]any{"event": req.Event, "source": "polka"},
+		})
+
+		cfg.notify(r, notificationEvent{
+			Kind: notificationRedUpgrade,
+			UserId: *userId,
+		})
+
 		respNoContent(&w)
 	}
 
 	return postPolkaWebhookHandler
+}
+
+func putUserRoleHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	putUserRoleHandler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type: application/json", "charset=utf-8")
+		userUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s", 
+					errUUID, 
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		req := userRolePutRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s", 
+					errDecode, 
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		role, errRole := auth.ParseRole(req.Role)
+		if errRole != nil {
+			respondWithError(&w, errRole)
+			return
+		}
+
+		// admins can not demote themselves, so there is always one left
+		if userUUID == userIdFromContext(r.Context()) && role != auth.RoleAdmin {
+			e := customErrors.CodedError{
+				Message: "admins can not change their own role",
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		oldUser, errSearchUser := cfg.DB.FindUserById(r.Context(), userUUID)
+		if errSearchUser != nil {
+			e := customErrors.CodedError{
+				Message: "user not found",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		rolePars := database.SetUserRoleParams{
+			ID: userUUID,
+			Role: string(role),
+		}
+
+		errSet := cfg.DB.SetUserRole(r.Context(), rolePars)
+		if errSet != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to set user role: %w, function: %s", 
+					errSet, 
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		user, errUser := cfg.DB.FindUserById(r.Context(), userUUID)
+		if errUser != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to retrieve updated user: %w, function: %s", 
+					errUser, 
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		cfg.recordAudit(r, auditEvent{
+			Action: auditRoleChanged,
+			ActorId: userIdFromContext(r.Context()),
+			TargetType: "user",
+			TargetId: userUUID.String(),
+			Metadata: map[string]any{"old_role": oldUser.Role, "new_role": user.Role},
+		})
+
+		u := User{}
+		u.mapUser(&user)
+
+		errCounts := cfg.setFollowCounts(r.Context(), &u)
+		if errCounts != nil {
+			respondWithError(&w, errCounts)
+			return
+		}
+
+		respSuccesfullUserPut(&w, &u)
+	}
+
+	return putUserRoleHandler
 }
\ No newline at end of file
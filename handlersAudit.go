@@ -0,0 +1,388 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/audit"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+)
+
+
+const (
+	auditLogin = "user.login"
+	auditLoginFailed = "user.login_failed"
+	auditTokenRevoked = "token.revoked"
+	auditEmailChanged = "user.email_changed"
+	auditHandleChanged = "user.handle_changed"
+	auditPasswordChanged = "user.password_changed"
+	auditUserDeleted = "user.deleted"
+	auditUserUpgraded = "user.upgraded"
+	auditChirpDeleted = "chirp.deleted"
+	auditReset = "admin.reset"
+	auditRoleChanged = "admin.role_changed"
+	auditWordListCreated = "admin.wordlist_created"
+	auditWordListUpdated = "admin.wordlist_updated"
+	auditWordListDeleted = "admin.wordlist_deleted"
+	auditWordListWordsAdded = "admin.wordlist_words_added"
+	auditWordListWordRemoved = "admin.wordlist_word_removed"
+	auditChirpApproved = "admin.chirp_approved"
+	auditChirpWarningForced = "admin.chirp_warning_forced"
+	auditReportResolved = "admin.report_resolved"
+	auditUserSuspended = "admin.user_suspended"
+	auditSuspensionLifted = "admin.suspension_lifted"
+	auditBlocklistAdded = "admin.blocklist_added"
+	auditBlocklistRemoved = "admin.blocklist_removed"
+)
+
+const (
+	auditPageSize = 50
+	auditMaxPageSize = 500
+	auditExportBatch = 1000
+)
+
+type auditEvent struct {
+	Action string
+	ActorId uuid.UUID // uuid.Nil when nobody is logged in
+	TargetType string
+	TargetId string
+	Metadata map[string]any
+}
+
+// recordAudit appends an event to the audit log. The action it describes
+// already happened, so a failure is logged instead of being sent to the client
+func (cfg *apiConfig) recordAudit(r *http.Request, event auditEvent) {
+	errAudit := cfg.appendAudit(context.WithoutCancel(r.Context()), r, &event)
+	if errAudit != nil {
+		log.Printf("audit log: %s", errAudit.Message)
+	}
+}
+
+func (cfg *apiConfig) appendAudit(ctx context.Context, r *http.Request, event *auditEvent) *customErrors.CodedError {
+	metadata := []byte("{}")
+	if len(event.Metadata) > 0 {
+		var errMarshal error
+		metadata, errMarshal = json.Marshal(event.Metadata)
+		if errMarshal != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to encode audit metadata: %w, function: %s",
+					errMarshal,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+	}
+
+	entry := audit.Entry{
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		Action: event.Action,
+		TargetType: event.TargetType,
+		TargetID: event.TargetId,
+		IP: clientIP(r),
+		UserAgent: r.UserAgent(),
+		RequestID: requestIdFromContext(r.Context()),
+		Metadata: string(metadata),
+	}
+
+	actorId := uuid.NullUUID{}
+	if event.ActorId != uuid.Nil {
+		actorId = uuid.NullUUID{UUID: event.ActorId, Valid: true}
+		entry.ActorID = event.ActorId.String()
+	}
+
+	return cfg.withTx(ctx, func(q *database.Queries) *customErrors.CodedError {
+		// appends are serialized so that every entry links to the last one
+		errLock := q.LockAuditLog(ctx)
+		if errLock != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to lock audit log: %w, function: %s",
+					errLock,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+
+		prevHash, errPrev := q.GetLastAuditHash(ctx)
+		if errPrev == sql.ErrNoRows {
+			prevHash = audit.Genesis
+		} else if errPrev != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get last audit entry: %w, function: %s",
+					errPrev,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+
+		entryPars := database.CreateAuditEntryParams{
+			CreatedAt: entry.CreatedAt,
+			Action: entry.Action,
+			ActorID: actorId,
+			TargetType: entry.TargetType,
+			TargetID: entry.TargetID,
+			Ip: entry.IP,
+			UserAgent: entry.UserAgent,
+			RequestID: entry.RequestID,
+			Metadata: entry.Metadata,
+			PrevHash: prevHash,
+			Hash: audit.Hash(prevHash, &entry),
+		}
+
+		errCreate := q.CreateAuditEntry(ctx, entryPars)
+		if errCreate != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to write audit entry: %w, function: %s",
+					errCreate,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+
+		return nil
+	})
+}
+
+func getAuditHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getAuditHandler := func(w http.ResponseWriter, r *http.Request) {
+		filters, errFilters := auditFiltersFromQuery(r)
+		if errFilters != nil {
+			respondWithError(&w, errFilters)
+			return
+		}
+
+		limit := auditPageSize
+		if l := r.URL.Query().Get("limit"); l != "" {
+			parsed, errLimit := strconv.Atoi(l)
+			if errLimit != nil || parsed < 1 || parsed > auditMaxPageSize {
+				e := customErrors.CodedError{
+					Message: fmt.Sprintf("limit must be between 1 and %d", auditMaxPageSize),
+					StatusCode: http.StatusBadRequest,
+				}
+				respondWithError(&w, &e)
+				return
+			}
+			limit = parsed
+		}
+
+		beforeId := sql.NullInt64{}
+		if b := r.URL.Query().Get("before"); b != "" {
+			parsed, errBefore := strconv.ParseInt(b, 10, 64)
+			if errBefore != nil {
+				e := customErrors.CodedError{
+					Message: "before must be an audit entry id",
+					StatusCode: http.StatusBadRequest,
+				}
+				respondWithError(&w, &e)
+				return
+			}
+			beforeId = sql.NullInt64{Int64: parsed, Valid: true}
+		}
+
+		entriesPars := database.GetAuditEntriesParams{
+			Action: filters.Action,
+			ActorID: filters.ActorID,
+			TargetType: filters.TargetType,
+			TargetID: filters.TargetID,
+			Since: filters.Since,
+			Until: filters.Until,
+			BeforeID: beforeId,
+			MaxEntries: int32(limit),
+		}
+
+		entries, errEntries := cfg.DB.GetAuditEntries(r.Context(), entriesPars)
+		if errEntries != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get audit log: %w, function: %s",
+					errEntries,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		aArr := make([]AuditEntry, len(entries))
+		for i, entry := range entries {
+			aArr[i].mapAuditEntry(&entry)
+		}
+
+		respSuccesfullAuditGet(&w, aArr)
+	}
+
+	return getAuditHandler
+}
+
+// getAuditExportHandlerWrapped streams the matching entries oldest first,
+// one json object per line, reading them from the database in batches
+func getAuditExportHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getAuditExportHandler := func(w http.ResponseWriter, r *http.Request) {
+		filters, errFilters := auditFiltersFromQuery(r)
+		if errFilters != nil {
+			respondWithError(&w, errFilters)
+			return
+		}
+
+		filename := fmt.Sprintf("audit-%s.jsonl", time.Now().UTC().Format("20060102T150405Z"))
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.WriteHeader(http.StatusOK)
+
+		encoder := json.NewEncoder(w)
+		filters.MaxEntries = auditExportBatch
+		for {
+			entries, errEntries := cfg.DB.GetAuditEntriesAfter(r.Context(), filters)
+			if errEntries != nil {
+				// the status is already sent, all that is left is cutting the stream short
+				log.Printf("audit export: %v", errEntries)
+				return
+			}
+
+			for _, entry := range entries {
+				a := AuditEntry{}
+				a.mapAuditEntry(&entry)
+				if errEncode := encoder.Encode(&a); errEncode != nil {
+					return
+				}
+			}
+
+			if len(entries) < auditExportBatch {
+				return
+			}
+			filters.AfterID = entries[len(entries)-1].ID
+		}
+	}
+
+	return getAuditExportHandler
+}
+
+// getAuditVerifyHandlerWrapped recomputes the whole hash chain and reports
+// the first entry that does not match, if any
+func getAuditVerifyHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getAuditVerifyHandler := func(w http.ResponseWriter, r *http.Request) {
+		verifier := audit.NewVerifier()
+		res := AuditVerification{
+			Valid: true,
+		}
+
+		pagePars := database.GetAuditEntriesAfterParams{
+			MaxEntries: auditExportBatch,
+		}
+
+		for res.Valid {
+			entries, errEntries := cfg.DB.GetAuditEntriesAfter(r.Context(), pagePars)
+			if errEntries != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to get audit log: %w, function: %s",
+						errEntries,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				respondWithError(&w, &e)
+				return
+			}
+
+			for _, entry := range entries {
+				if !verifier.Check(entry.PrevHash, entry.Hash, auditEntryFromRow(&entry)) {
+					res.Valid = false
+					res.BrokenAt = &entry.ID
+					break
+				}
+			}
+
+			if len(entries) < auditExportBatch {
+				break
+			}
+			pagePars.AfterID = entries[len(entries)-1].ID
+		}
+
+		res.Checked = verifier.Checked
+
+		respSuccesfullAuditVerify(&w, &res)
+	}
+
+	return getAuditVerifyHandler
+}
+
+// auditFiltersFromQuery reads the filters shared by the listing and the export
+func auditFiltersFromQuery(r *http.Request) (database.GetAuditEntriesAfterParams, *customErrors.CodedError) {
+	query := r.URL.Query()
+	filters := database.GetAuditEntriesAfterParams{
+		Action: sql.NullString{String: query.Get("action"), Valid: query.Get("action") != ""},
+		TargetType: sql.NullString{String: query.Get("target_type"), Valid: query.Get("target_type") != ""},
+		TargetID: sql.NullString{String: query.Get("target_id"), Valid: query.Get("target_id") != ""},
+	}
+
+	if a := query.Get("actor_id"); a != "" {
+		actorUUID, errUUID := uuid.Parse(a)
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			return filters, &e
+		}
+		filters.ActorID = uuid.NullUUID{UUID: actorUUID, Valid: true}
+	}
+
+	for param, dest := range map[string]*sql.NullTime{"since": &filters.Since, "until": &filters.Until} {
+		v := query.Get(param)
+		if v == "" {
+			continue
+		}
+
+		t, errTime := time.Parse(time.RFC3339, v)
+		if errTime != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Sprintf("%s must be a RFC3339 timestamp", param),
+				StatusCode: http.StatusBadRequest,
+			}
+			return filters, &e
+		}
+		*dest = sql.NullTime{Time: t.UTC(), Valid: true}
+	}
+
+	return filters, nil
+}
+
+func auditEntryFromRow(row *database.AuditLog) *audit.Entry {
+	e := audit.Entry{
+		CreatedAt: row.CreatedAt,
+		Action: row.Action,
+		TargetType: row.TargetType,
+		TargetID: row.TargetID,
+		IP: row.Ip,
+		UserAgent: row.UserAgent,
+		RequestID: row.RequestID,
+		Metadata: row.Metadata,
+	}
+
+	if row.ActorID.Valid {
+		e.ActorID = row.ActorID.UUID.String()
+	}
+
+	return &e
+}
+
+func clientIP(r *http.Request) string {
+	host, _, errSplit := net.SplitHostPort(r.RemoteAddr)
+	if errSplit != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
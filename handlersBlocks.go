@@ -0,0 +1,521 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+)
+
+
+func postBlockHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postBlockHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		blocked, errBlocked := userFromPath(r, cfg)
+		if errBlocked != nil {
+			respondWithError(&w, errBlocked)
+			return
+		}
+
+		if blocked.ID == userId {
+			e := customErrors.CodedError{
+				Message: "cannot block yourself",
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		errBlock := cfg.withTx(r.Context(), func(q *database.Queries) *customErrors.CodedError {
+			return blockUser(r.Context(), q, userId, blocked.ID)
+		})
+		if errBlock != nil {
+			respondWithError(&w, errBlock)
+			return
+		}
+
+		respNoContent(&w)
+	}
+
+	return postBlockHandler
+}
+
+func deleteBlockHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	deleteBlockHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		blockedUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		unblockPars := database.UnblockUserParams{
+			BlockerID: userId,
+			BlockedID: blockedUUID,
+		}
+
+		deleted, errUnblock := cfg.DB.UnblockUser(r.Context(), unblockPars)
+		if errUnblock != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to unblock user: %w, function: %s",
+					errUnblock,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if deleted == 0 {
+			e := customErrors.CodedError{
+				Message: "user not blocked",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		respNoContent(&w)
+	}
+
+	return deleteBlockHandler
+}
+
+func getBlocksHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getBlocksHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		p, errPage := pageFromQuery(r)
+		if errPage != nil {
+			respondWithError(&w, errPage)
+			return
+		}
+
+		blocksPars := database.GetBlocksParams{
+			UserID: userId,
+			BeforeTime: p.CursorTime,
+			BeforeID: p.CursorId,
+			MaxEntries: p.Limit,
+		}
+
+		rows, errRows := cfg.DB.GetBlocks(r.Context(), blocksPars)
+		if errRows != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get blocked users: %w, function: %s",
+					errRows,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		bp := BlockPage{
+			Users: make([]Block, len(rows)),
+		}
+		for i, row := range rows {
+			bp.Users[i] = Block{UserId: row.UserID, BlockedAt: row.CreatedAt}
+		}
+
+		if len(rows) > 0 {
+			last := rows[len(rows)-1]
+			bp.NextCursor = nextCursor(&p, len(rows), cursor{Time: last.CreatedAt, Id: last.UserID})
+		}
+
+		respSuccesfullBlocksGet(&w, &bp)
+	}
+
+	return getBlocksHandler
+}
+
+func postMuteHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postMuteHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		muted, errMuted := userFromPath(r, cfg)
+		if errMuted != nil {
+			respondWithError(&w, errMuted)
+			return
+		}
+
+		if muted.ID == userId {
+			e := customErrors.CodedError{
+				Message: "cannot mute yourself",
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		// an empty body mutes the user until the mute is removed
+		decoder := json.NewDecoder(r.Body)
+		req := mutePostRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil && errDecode != io.EOF {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if req.DurationHours < 0 {
+			e := customErrors.CodedError{
+				Message: "a mute needs a non negative duration",
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		expiresAt := sql.NullTime{}
+		if req.DurationHours > 0 {
+			expiresAt = sql.NullTime{
+				Time: time.Now().UTC().Add(time.Duration(req.DurationHours) * time.Hour),
+				Valid: true,
+			}
+		}
+
+		// muting again replaces the duration of the previous mute
+		mutePars := database.MuteUserParams{
+			MuterID: userId,
+			MutedID: muted.ID,
+			ExpiresAt: expiresAt,
+		}
+
+		errMute := cfg.DB.MuteUser(r.Context(), mutePars)
+		if errMute != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to mute user: %w, function: %s",
+					errMute,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		respNoContent(&w)
+	}
+
+	return postMuteHandler
+}
+
+func deleteMuteHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	deleteMuteHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		mutedUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		unmutePars := database.UnmuteUserParams{
+			MuterID: userId,
+			MutedID: mutedUUID,
+		}
+
+		deleted, errUnmute := cfg.DB.UnmuteUser(r.Context(), unmutePars)
+		if errUnmute != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to unmute user: %w, function: %s",
+					errUnmute,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if deleted == 0 {
+			e := customErrors.CodedError{
+				Message: "user not muted",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		respNoContent(&w)
+	}
+
+	return deleteMuteHandler
+}
+
+func getMutesHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getMutesHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		p, errPage := pageFromQuery(r)
+		if errPage != nil {
+			respondWithError(&w, errPage)
+			return
+		}
+
+		mutesPars := database.GetMutesParams{
+			UserID: userId,
+			BeforeTime: p.CursorTime,
+			BeforeID: p.CursorId,
+			MaxEntries: p.Limit,
+		}
+
+		rows, errRows := cfg.DB.GetMutes(r.Context(), mutesPars)
+		if errRows != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get muted users: %w, function: %s",
+					errRows,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		mp := MutePage{
+			Users: make([]Mute, len(rows)),
+		}
+		for i, row := range rows {
+			mp.Users[i].mapMute(&row)
+		}
+
+		if len(rows) > 0 {
+			last := rows[len(rows)-1]
+			mp.NextCursor = nextCursor(&p, len(rows), cursor{Time: last.CreatedAt, Id: last.UserID})
+		}
+
+		respSuccesfullMutesGet(&w, &mp)
+	}
+
+	return getMutesHandler
+}
+
+// blockUser adds the block and removes the follows between the two users
+// in both directions, together with their chirps in the home timelines.
+// Both users are locked, always in the same order, so that a concurrent
+// follow either is removed here or sees the block
+func blockUser(ctx context.Context, q *database.Queries, blockerId uuid.UUID, blockedId uuid.UUID) *customErrors.CodedError {
+	lockIds := []uuid.UUID{blockerId, blockedId}
+	if blockedId.String() < blockerId.String() {
+		lockIds = []uuid.UUID{blockedId, blockerId}
+	}
+
+	for _, id := range lockIds {
+		errLock := q.LockUserFollows(ctx, id)
+		if errLock != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to lock user: %w, function: %s",
+					errLock,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+	}
+
+	blockPars := database.BlockUserParams{
+		BlockerID: blockerId,
+		BlockedID: blockedId,
+	}
+
+	created, errBlock := q.BlockUser(ctx, blockPars)
+	if errBlock != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to block user: %w, function: %s",
+				errBlock,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	if created == 0 {
+		e := customErrors.CodedError{
+			Message: "user already blocked",
+			StatusCode: http.StatusConflict,
+		}
+		return &e
+	}
+
+	for _, pair := range [][2]uuid.UUID{{blockerId, blockedId}, {blockedId, blockerId}} {
+		unfollowPars := database.UnfollowUserParams{
+			FollowerID: pair[0],
+			FolloweeID: pair[1],
+		}
+
+		_, errUnfollow := q.UnfollowUser(ctx, unfollowPars)
+		if errUnfollow != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to remove follow: %w, function: %s",
+					errUnfollow,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+
+		timelinePars := database.DeleteHomeTimelineAuthorParams{
+			UserID: pair[0],
+			AuthorID: pair[1],
+		}
+
+		errTimeline := q.DeleteHomeTimelineAuthor(ctx, timelinePars)
+		if errTimeline != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to clean home timeline: %w, function: %s",
+					errTimeline,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+	}
+
+	return nil
+}
+
+// blockedAmong returns the users among userIds that blocked userId or were
+// blocked by userId, a block hides both users from each other.
+// Anonymous requests have nobody blocked
+func blockedAmong(ctx context.Context, q *database.Queries, userId uuid.UUID, userIds []uuid.UUID) (map[uuid.UUID]bool, *customErrors.CodedError) {
+	blocked := map[uuid.UUID]bool{}
+	if userId == uuid.Nil || len(userIds) == 0 {
+		return blocked, nil
+	}
+
+	blockedPars := database.GetBlockedAmongParams{
+		UserID: userId,
+		UserIds: userIds,
+	}
+
+	ids, errBlocked := q.GetBlockedAmong(ctx, blockedPars)
+	if errBlocked != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to check blocks: %w, function: %s",
+				errBlocked,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	for _, id := range ids {
+		blocked[id] = true
+	}
+
+	return blocked, nil
+}
+
+// mutedAmong returns the users among userIds muted by muterId, expired
+// mutes are ignored
+func mutedAmong(ctx context.Context, q *database.Queries, muterId uuid.UUID, userIds []uuid.UUID) (map[uuid.UUID]bool, *customErrors.CodedError) {
+	muted := map[uuid.UUID]bool{}
+	if muterId == uuid.Nil || len(userIds) == 0 {
+		return muted, nil
+	}
+
+	mutedPars := database.GetMutedAmongParams{
+		MuterID: muterId,
+		UserIds: userIds,
+	}
+
+	ids, errMuted := q.GetMutedAmong(ctx, mutedPars)
+	if errMuted != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to check mutes: %w, function: %s",
+				errMuted,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	for _, id := range ids {
+		muted[id] = true
+	}
+
+	return muted, nil
+}
+
+// withoutMuted drops the chirps of the users muted by the viewer, it is
+// used by the timelines while profiles and threads still show them
+func (cfg *apiConfig) withoutMuted(ctx context.Context, viewerId uuid.UUID, chirps []database.Chirp) ([]database.Chirp, *customErrors.CodedError) {
+	muted, errMuted := mutedAmong(ctx, cfg.DB, viewerId, chirpAuthors(chirps))
+	if errMuted != nil {
+		return nil, errMuted
+	}
+
+	return withoutAuthors(chirps, muted), nil
+}
+
+func chirpAuthors(chirps []database.Chirp) []uuid.UUID {
+	ids := make([]uuid.UUID, len(chirps))
+	for i, c := range chirps {
+		ids[i] = c.UserID
+	}
+
+	return ids
+}
+
+func withoutAuthors(chirps []database.Chirp, hidden map[uuid.UUID]bool) []database.Chirp {
+	if len(hidden) == 0 {
+		return chirps
+	}
+
+	kept := make([]database.Chirp, 0, len(chirps))
+	for _, c := range chirps {
+		if !hidden[c.UserID] {
+			kept = append(kept, c)
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,542 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+)
+
+
+const (
+	maxBookmarkFolders = 50
+	maxBookmarkFolderNameLength = 50
+)
+
+func postBookmarkHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postBookmarkHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		chirpUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		// an empty body bookmarks the chirp outside of any folder
+		decoder := json.NewDecoder(r.Body)
+		req := bookmarkPostRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil && errDecode != io.EOF {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		folderId, errFolder := cfg.bookmarkFolder(r.Context(), userId, req.FolderId)
+		if errFolder != nil {
+			respondWithError(&w, errFolder)
+			return
+		}
+
+		chirp, errChirp := cfg.sharedChirp(r.Context(), userId, chirpUUID)
+		if errChirp == sql.ErrNoRows {
+			e := customErrors.CodedError{
+				Message: "chirp not found",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if errChirp != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get chirp: %w, function: %s",
+					errChirp,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		// bookmarking a chirp again moves it to the given folder
+		bookmarkPars := database.BookmarkChirpParams{
+			UserID: userId,
+			ChirpID: chirp.ID,
+			FolderID: folderId,
+		}
+
+		errBookmark := cfg.DB.BookmarkChirp(r.Context(), bookmarkPars)
+		if errBookmark != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to bookmark chirp: %w, function: %s",
+					errBookmark,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		respNoContent(&w)
+	}
+
+	return postBookmarkHandler
+}
+
+func deleteBookmarkHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	deleteBookmarkHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		chirpUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		deletePars := database.DeleteBookmarkParams{
+			UserID: userId,
+			ChirpID: chirpUUID,
+		}
+
+		deleted, errDelete := cfg.DB.DeleteBookmark(r.Context(), deletePars)
+		if errDelete != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to delete bookmark: %w, function: %s",
+					errDelete,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if deleted == 0 {
+			e := customErrors.CodedError{
+				Message: "chirp not bookmarked",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		respNoContent(&w)
+	}
+
+	return deleteBookmarkHandler
+}
+
+func getBookmarksHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getBookmarksHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		p, errPage := pageFromQuery(r)
+		if errPage != nil {
+			respondWithError(&w, errPage)
+			return
+		}
+
+		var folderUUID *uuid.UUID
+		if folderParam := r.URL.Query().Get("folder_id"); folderParam != "" {
+			parsed, errUUID := uuid.Parse(folderParam)
+			if errUUID != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+						errUUID,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusBadRequest,
+				}
+				respondWithError(&w, &e)
+				return
+			}
+			folderUUID = &parsed
+		}
+
+		folderId, errFolder := cfg.bookmarkFolder(r.Context(), userId, folderUUID)
+		if errFolder != nil {
+			respondWithError(&w, errFolder)
+			return
+		}
+
+		bookmarksPars := database.GetBookmarksParams{
+			UserID: userId,
+			FolderID: folderId,
+			BeforeTime: p.CursorTime,
+			BeforeID: p.CursorId,
+			MaxEntries: p.Limit,
+		}
+
+		bookmarks, errBookmarks := cfg.DB.GetBookmarks(r.Context(), bookmarksPars)
+		if errBookmarks != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get bookmarks: %w, function: %s",
+					errBookmarks,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		ids := make([]uuid.UUID, len(bookmarks))
+		for i, b := range bookmarks {
+			ids[i] = b.ChirpID
+		}
+
+		chirps, errChirps := cfg.visibleChirpsInOrder(r, ids)
+		if errChirps != nil {
+			respondWithError(&w, errChirps)
+			return
+		}
+
+		byId := make(map[uuid.UUID]*Chirp, len(chirps))
+		for i := range chirps {
+			byId[chirps[i].Id] = &chirps[i]
+		}
+
+		// chirps that are not visible anymore are kept as tombstones, so
+		// that the user can still remove the bookmark
+		bp := BookmarkPage{
+			Bookmarks: make([]Bookmark, len(bookmarks)),
+		}
+		for i, b := range bookmarks {
+			bp.Bookmarks[i].mapBookmark(&b)
+			bp.Bookmarks[i].Chirp = byId[b.ChirpID]
+			bp.Bookmarks[i].Tombstone = bp.Bookmarks[i].Chirp == nil
+		}
+
+		if len(bookmarks) > 0 {
+			last := bookmarks[len(bookmarks)-1]
+			bp.NextCursor = nextCursor(&p, len(bookmarks), cursor{Time: last.CreatedAt, Id: last.ChirpID})
+		}
+
+		respSuccesfullBookmarksGet(&w, &bp)
+	}
+
+	return getBookmarksHandler
+}
+
+func postBookmarkFolderHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postBookmarkFolderHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		req := bookmarkFolderRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		name, errName := validateBookmarkFolderName(req.Name)
+		if errName != nil {
+			respondWithError(&w, errName)
+			return
+		}
+
+		count, errCount := cfg.DB.CountBookmarkFolders(r.Context(), userId)
+		if errCount != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to count bookmark folders: %w, function: %s",
+					errCount,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if count >= maxBookmarkFolders {
+			e := customErrors.CodedError{
+				Message: fmt.Sprintf("at most %d bookmark folders can be created", maxBookmarkFolders),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		folderPars := database.CreateBookmarkFolderParams{
+			UserID: userId,
+			Name: name,
+		}
+
+		folder, errFolder := cfg.DB.CreateBookmarkFolder(r.Context(), folderPars)
+		if errFolder != nil {
+			respondWithError(&w, bookmarkFolderError(errFolder))
+			return
+		}
+
+		bf := BookmarkFolder{}
+		bf.mapBookmarkFolder(&folder)
+
+		respSuccesfullBookmarkFolderPost(&w, &bf)
+	}
+
+	return postBookmarkFolderHandler
+}
+
+func getBookmarkFoldersHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getBookmarkFoldersHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		folders, errFolders := cfg.DB.GetBookmarkFolders(r.Context(), userId)
+		if errFolders != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get bookmark folders: %w, function: %s",
+					errFolders,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		bfArr := make([]BookmarkFolder, len(folders))
+		for i, f := range folders {
+			bfArr[i].mapBookmarkFolder(&f)
+		}
+
+		respSuccesfullBookmarkFoldersGet(&w, bfArr)
+	}
+
+	return getBookmarkFoldersHandler
+}
+
+func putBookmarkFolderHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	putBookmarkFolderHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		folderUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		req := bookmarkFolderRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		name, errName := validateBookmarkFolderName(req.Name)
+		if errName != nil {
+			respondWithError(&w, errName)
+			return
+		}
+
+		renamePars := database.RenameBookmarkFolderParams{
+			ID: folderUUID,
+			UserID: userId,
+			Name: name,
+		}
+
+		folder, errFolder := cfg.DB.RenameBookmarkFolder(r.Context(), renamePars)
+		if errFolder != nil {
+			respondWithError(&w, bookmarkFolderError(errFolder))
+			return
+		}
+
+		bf := BookmarkFolder{}
+		bf.mapBookmarkFolder(&folder)
+
+		respSuccesfullBookmarkFolderPut(&w, &bf)
+	}
+
+	return putBookmarkFolderHandler
+}
+
+func deleteBookmarkFolderHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	deleteBookmarkFolderHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		folderUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		// the bookmarks of the folder are kept, outside of any folder
+		deletePars := database.DeleteBookmarkFolderParams{
+			ID: folderUUID,
+			UserID: userId,
+		}
+
+		deleted, errDelete := cfg.DB.DeleteBookmarkFolder(r.Context(), deletePars)
+		if errDelete != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to delete bookmark folder: %w, function: %s",
+					errDelete,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if deleted == 0 {
+			e := customErrors.CodedError{
+				Message: "folder not found",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		respNoContent(&w)
+	}
+
+	return deleteBookmarkFolderHandler
+}
+
+// bookmarkFolder checks that the folder belongs to the user, a nil
+// folderId stands for no folder
+func (cfg *apiConfig) bookmarkFolder(ctx context.Context, userId uuid.UUID, folderId *uuid.UUID) (uuid.NullUUID, *customErrors.CodedError) {
+	if folderId == nil {
+		return uuid.NullUUID{}, nil
+	}
+
+	folderPars := database.GetBookmarkFolderParams{
+		ID: *folderId,
+		UserID: userId,
+	}
+
+	folder, errFolder := cfg.DB.GetBookmarkFolder(ctx, folderPars)
+	if errFolder == sql.ErrNoRows {
+		e := customErrors.CodedError{
+			Message: "folder not found",
+			StatusCode: http.StatusNotFound,
+		}
+		return uuid.NullUUID{}, &e
+	}
+
+	if errFolder != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to get bookmark folder: %w, function: %s",
+				errFolder,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return uuid.NullUUID{}, &e
+	}
+
+	return uuid.NullUUID{UUID: folder.ID, Valid: true}, nil
+}
+
+func validateBookmarkFolderName(name string) (string, *customErrors.CodedError) {
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > maxBookmarkFolderNameLength {
+		e := customErrors.CodedError{
+			Message: fmt.Sprintf("a folder name must be 1 to %d characters long", maxBookmarkFolderNameLength),
+			StatusCode: http.StatusBadRequest,
+		}
+		return "", &e
+	}
+
+	return name, nil
+}
+
+// bookmarkFolderError maps the errors of the queries writing a folder
+func bookmarkFolderError(err error) *customErrors.CodedError {
+	if err == sql.ErrNoRows {
+		e := customErrors.CodedError{
+			Message: "folder not found",
+			StatusCode: http.StatusNotFound,
+		}
+		return &e
+	}
+
+	if isUniqueViolation(err) {
+		e := customErrors.CodedError{
+			Message: "folder name already used",
+			StatusCode: http.StatusConflict,
+		}
+		return &e
+	}
+
+	e := customErrors.CodedError{
+		Message: fmt.Errorf("failed to save bookmark folder: %w, function: %s",
+			err,
+			customErrors.GetFunctionName()).Error(),
+		StatusCode: http.StatusInternalServerError,
+	}
+	return &e
+}
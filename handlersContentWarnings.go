@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+)
+
+
+const maxContentWarningLength = 100
+
+func putChirpWarningHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	putChirpWarningHandler := func(w http.ResponseWriter, r *http.Request) {
+		chirpUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		req := chirpWarningRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		errWarning := validateContentWarning(&req.ContentWarning, cfg)
+		if errWarning != nil {
+			respondWithError(&w, errWarning)
+			return
+		}
+
+		warningPars := database.ForceChirpWarningParams{
+			ForcedContentWarning: req.ContentWarning,
+			ForcedSensitive: req.Sensitive,
+			ID: chirpUUID,
+		}
+
+		updated, errForce := cfg.DB.ForceChirpWarning(r.Context(), warningPars)
+		if errForce != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to set chirp warning: %w, function: %s",
+					errForce,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if updated == 0 {
+			e := customErrors.CodedError{
+				Message: "chirp not found",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		cfg.recordAudit(r, auditEvent{
+			Action: auditChirpWarningForced,
+			ActorId: userIdFromContext(r.Context()),
+			TargetType: "chirp",
+			TargetId: chirpUUID.String(),
+			Metadata: map[string]any{"content_warning": req.ContentWarning, "sensitive": req.Sensitive},
+		})
+
+		respNoContent(&w)
+	}
+
+	return putChirpWarningHandler
+}
+
+func getPreferencesHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getPreferencesHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		user, errUser := cfg.DB.FindUserById(r.Context(), userId)
+		if errUser != nil {
+			e := customErrors.CodedError{
+				Message: "user not found",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		p := Preferences{}
+		p.mapPreferences(&user)
+
+		respSuccesfullPreferencesGet(&w, &p)
+	}
+
+	return getPreferencesHandler
+}
+
+func putPreferencesHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	putPreferencesHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		req := preferencesPutRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if req.ExpandFlaggedContent != nil {
+			expandPars := database.SetUserExpandFlaggedContentParams{
+				ID: userId,
+				ExpandFlaggedContent: *req.ExpandFlaggedContent,
+			}
+
+			errExpand := cfg.DB.SetUserExpandFlaggedContent(r.Context(), expandPars)
+			if errExpand != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to update preferences: %w, function: %s",
+						errExpand,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				respondWithError(&w, &e)
+				return
+			}
+		}
+
+		user, errUser := cfg.DB.FindUserById(r.Context(), userId)
+		if errUser != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to retrieve updated user: %w, function: %s",
+					errUser,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		p := Preferences{}
+		p.mapPreferences(&user)
+
+		respSuccesfullPreferencesPut(&w, &p)
+	}
+
+	return putPreferencesHandler
+}
+
+func validateContentWarning(text *string, cfg *apiConfig) *customErrors.CodedError {
+	*text = strings.TrimSpace(*text)
+	if utf8.RuneCountInString(*text) > maxContentWarningLength {
+		e := customErrors.CodedError{
+			Message: fmt.Sprintf("content warning can be at most %d characters long", maxContentWarningLength),
+			StatusCode: http.StatusBadRequest,
+		}
+		return &e
+	}
+
+	_, errProfanity := cleanProfanity(text, cfg.Moderation)
+
+	return errProfanity
+}
+
+// expandPreference tells whether a user chose to see flagged content
+// expanded
+type expandPreference func(ctx context.Context, userId uuid.UUID) (bool, error)
+
+func (cfg *apiConfig) expandPreference(ctx context.Context, userId uuid.UUID) (bool, error) {
+	user, errUser := cfg.DB.FindUserById(ctx, userId)
+	if errUser != nil {
+		return false, errUser
+	}
+
+	return user.ExpandFlaggedContent, nil
+}
+
+// expandFlagged opens the content warnings of chirps, embedded chirps
+// included, when the viewer chose to see flagged content expanded
+func (cfg *apiConfig) expandFlagged(ctx context.Context, viewerId uuid.UUID, chirps []Chirp) ([]Chirp, *customErrors.CodedError) {
+	return expandFlaggedFor(ctx, viewerId, chirps, cfg.expandPreference)
+}
+
+func expandFlaggedFor(ctx context.Context, viewerId uuid.UUID, chirps []Chirp, preference expandPreference) ([]Chirp, *customErrors.CodedError) {
+	if viewerId == uuid.Nil || len(chirps) == 0 {
+		return chirps, nil
+	}
+
+	expand, errPreference := preference(ctx, viewerId)
+	if errPreference != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to get viewer preferences: %w, function: %s",
+				errPreference,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	if !expand {
+		return chirps, nil
+	}
+
+	expandWarnings(chirps)
+
+	return chirps, nil
+}
+
+// expandWarnings opens the content warnings of chirps and of the chirps
+// they embed
+func expandWarnings(chirps []Chirp) {
+	for i := range chirps {
+		targets := []*Chirp{&chirps[i]}
+		for _, ref := range []*ChirpRef{chirps[i].RechirpOf, chirps[i].QuoteOf} {
+			if ref != nil && ref.Chirp != nil {
+				targets = append(targets, ref.Chirp)
+			}
+		}
+
+		for _, c := range targets {
+			if c.ContentWarning != nil {
+				c.ContentWarning.Collapsed = false
+			}
+		}
+	}
+}
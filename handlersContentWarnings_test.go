@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/database"
+	"github.com/niccolot/Chirpy/internal/moderation"
+)
+
+func testConfig() *apiConfig {
+	return &apiConfig{
+		Moderation: moderation.NewFilter(nil),
+	}
+}
+
+func decodePutRequest(t *testing.T, body string) chirpPutRequest {
+	t.Helper()
+
+	req := chirpPutRequest{}
+	errDecode := json.NewDecoder(strings.NewReader(body)).Decode(&req)
+	if errDecode != nil {
+		t.Fatalf("unexpected error: %v", errDecode)
+	}
+
+	return req
+}
+
+func TestChirpEditKeepsContentWarning(t *testing.T) {
+	chirp := database.Chirp{
+		ID: uuid.New(),
+		Body: "the butler did it",
+		Visibility: visibilityFollowers,
+		ContentWarning: "spoilers",
+		Sensitive: true,
+		ForcedContentWarning: "graphic violence",
+	}
+
+	tests := []struct {
+		name string
+		body string
+		wantWarning string
+		wantSensitive bool
+		wantVisibility string
+	}{
+		{
+			name: "body only",
+			body: `{"body": "the gardener did it"}`,
+			wantWarning: "spoilers",
+			wantSensitive: true,
+			wantVisibility: visibilityFollowers,
+		},
+		{
+			name: "new warning",
+			body: `{"body": "the gardener did it", "content_warning": "  finale  "}`,
+			wantWarning: "finale",
+			wantSensitive: true,
+			wantVisibility: visibilityFollowers,
+		},
+		{
+			name: "warning removed",
+			body: `{"body": "the gardener did it", "content_warning": "", "sensitive": false}`,
+			wantWarning: "",
+			wantSensitive: false,
+			wantVisibility: visibilityFollowers,
+		},
+		{
+			name: "visibility only",
+			body: `{"body": "the gardener did it", "visibility": "public"}`,
+			wantWarning: "spoilers",
+			wantSensitive: true,
+			wantVisibility: visibilityPublic,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := decodePutRequest(t, tt.body)
+
+			params, visibility, err := chirpEdit(testConfig(), &chirp, &req)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Message)
+			}
+
+			if params.ID != chirp.ID || params.Body != "the gardener did it" {
+				t.Errorf("update of %s with body %q", params.ID, params.Body)
+			}
+			if params.ContentWarning != tt.wantWarning || params.Sensitive != tt.wantSensitive {
+				t.Errorf("got warning %q sensitive %v, expected %q %v",
+					params.ContentWarning, params.Sensitive, tt.wantWarning, tt.wantSensitive)
+			}
+			if visibility != tt.wantVisibility {
+				t.Errorf("got visibility %q, expected %q", visibility, tt.wantVisibility)
+			}
+		})
+	}
+}
+
+func TestChirpEditRefusesLongWarning(t *testing.T) {
+	chirp := database.Chirp{ID: uuid.New(), Visibility: visibilityPublic}
+	req := decodePutRequest(t, `{"body": "hello", "content_warning": "`+strings.Repeat("a", maxContentWarningLength+1)+`"}`)
+
+	_, _, err := chirpEdit(testConfig(), &chirp, &req)
+	if err == nil || err.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a bad request, got %+v", err)
+	}
+}
+
+func TestMapChirpContentWarning(t *testing.T) {
+	tests := []struct {
+		name string
+		chirp database.Chirp
+		want *ContentWarning
+	}{
+		{
+			name: "no warning",
+			chirp: database.Chirp{},
+			want: nil,
+		},
+		{
+			name: "author warning",
+			chirp: database.Chirp{ContentWarning: "spoilers", Sensitive: true},
+			want: &ContentWarning{Text: "spoilers", Sensitive: true, Collapsed: true},
+		},
+		{
+			name: "forced warning shown over the author one",
+			chirp: database.Chirp{ContentWarning: "spoilers", ForcedContentWarning: "graphic violence"},
+			want: &ContentWarning{Text: "graphic violence", ByModerator: true, Collapsed: true},
+		},
+		{
+			name: "forced warning on a sensitive chirp",
+			chirp: database.Chirp{ContentWarning: "spoilers", Sensitive: true, ForcedContentWarning: "graphic violence"},
+			want: &ContentWarning{Text: "graphic violence", Sensitive: true, ByModerator: true, Collapsed: true},
+		},
+		{
+			name: "forced sensitive",
+			chirp: database.Chirp{ForcedSensitive: true},
+			want: &ContentWarning{Sensitive: true, ByModerator: true, Collapsed: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Chirp{}
+			c.mapChirp(&tt.chirp)
+
+			if (c.ContentWarning == nil) != (tt.want == nil) {
+				t.Fatalf("got %+v, expected %+v", c.ContentWarning, tt.want)
+			}
+			if tt.want != nil && *c.ContentWarning != *tt.want {
+				t.Errorf("got %+v, expected %+v", *c.ContentWarning, *tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandWarningsOpensEmbeddedChirps(t *testing.T) {
+	warned := func() *Chirp {
+		return &Chirp{ContentWarning: &ContentWarning{Text: "spoilers", Collapsed: true}}
+	}
+
+	chirps := []Chirp{
+		*warned(),
+		{RechirpOf: &ChirpRef{Chirp: warned()}},
+		{QuoteOf: &ChirpRef{Chirp: warned()}, ContentWarning: &ContentWarning{Sensitive: true, Collapsed: true}},
+		{QuoteOf: &ChirpRef{Unavailable: true}},
+	}
+
+	expandWarnings(chirps)
+
+	if chirps[0].ContentWarning.Collapsed {
+		t.Errorf("chirp warning still collapsed")
+	}
+	if chirps[1].RechirpOf.Chirp.ContentWarning.Collapsed {
+		t.Errorf("rechirped warning still collapsed")
+	}
+	if chirps[2].ContentWarning.Collapsed || chirps[2].QuoteOf.Chirp.ContentWarning.Collapsed {
+		t.Errorf("quote or quoted warning still collapsed")
+	}
+	if chirps[3].ContentWarning != nil {
+		t.Errorf("warning added to a chirp without one")
+	}
+}
+
+func TestExpandFlaggedFollowsThePreference(t *testing.T) {
+	viewerId := uuid.New()
+
+	tests := []struct {
+		name string
+		viewerId uuid.UUID
+		expand bool
+		errLookup error
+		wantLookup bool
+		wantCollapsed bool
+		wantStatus int
+	}{
+		{"anonymous viewer", uuid.Nil, true, nil, false, true, 0},
+		{"preference off", viewerId, false, nil, true, true, 0},
+		{"preference on", viewerId, true, nil, true, false, 0},
+		{"lookup failed", viewerId, true, errors.New("connection refused"), true, true, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quoted := Chirp{}
+			quoted.mapChirp(&database.Chirp{ForcedContentWarning: "graphic violence"})
+			chirp := Chirp{}
+			chirp.mapChirp(&database.Chirp{ContentWarning: "spoilers"})
+			chirp.QuoteOf = &ChirpRef{Chirp: &quoted}
+
+			looked := false
+			preference := func(ctx context.Context, userId uuid.UUID) (bool, error) {
+				looked = true
+				if userId != tt.viewerId {
+					t.Errorf("looked up the preference of %s, expected %s", userId, tt.viewerId)
+				}
+				return tt.expand, tt.errLookup
+			}
+
+			chirps, err := expandFlaggedFor(context.Background(), tt.viewerId, []Chirp{chirp}, preference)
+			if looked != tt.wantLookup {
+				t.Errorf("preference looked up: %v, expected %v", looked, tt.wantLookup)
+			}
+			if tt.wantStatus != 0 {
+				if err == nil || err.StatusCode != tt.wantStatus {
+					t.Errorf("expected status %d, got %+v", tt.wantStatus, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Message)
+			}
+
+			got := chirps[0]
+			if got.ContentWarning.Collapsed != tt.wantCollapsed || got.QuoteOf.Chirp.ContentWarning.Collapsed != tt.wantCollapsed {
+				t.Errorf("got collapsed %v and quoted %v, expected %v",
+					got.ContentWarning.Collapsed, got.QuoteOf.Chirp.ContentWarning.Collapsed, tt.wantCollapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+)
+
+
+func postFollowHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postFollowHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		followee, errFollowee := userFromPath(r, cfg)
+		if errFollowee != nil {
+			respondWithError(&w, errFollowee)
+			return
+		}
+
+		if followee.ID == userId {
+			e := customErrors.CodedError{
+				Message: "cannot follow yourself",
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		errFollow := cfg.withTx(r.Context(), func(q *database.Queries) *customErrors.CodedError {
+			return followUser(r.Context(), q, userId, followee.ID)
+		})
+		if errFollow != nil {
+			respondWithError(&w, errFollow)
+			return
+		}
+
+		cfg.notify(r, notificationEvent{
+			Kind: notificationFollow,
+			UserId: followee.ID,
+			ActorId: userId,
+		})
+
+		respNoContent(&w)
+	}
+
+	return postFollowHandler
+}
+
+func deleteFollowHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	deleteFollowHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		followeeUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		errUnfollow := cfg.withTx(r.Context(), func(q *database.Queries) *customErrors.CodedError {
+			return unfollowUser(r.Context(), q, userId, followeeUUID)
+		})
+		if errUnfollow != nil {
+			respondWithError(&w, errUnfollow)
+			return
+		}
+
+		respNoContent(&w)
+	}
+
+	return deleteFollowHandler
+}
+
+func getFollowersHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getFollowersHandler := func(w http.ResponseWriter, r *http.Request) {
+		user, errUser := userFromPath(r, cfg)
+		if errUser != nil {
+			respondWithError(&w, errUser)
+			return
+		}
+
+		p, errPage := pageFromQuery(r)
+		if errPage != nil {
+			respondWithError(&w, errPage)
+			return
+		}
+
+		followersPars := database.GetFollowersParams{
+			UserID: user.ID,
+			BeforeTime: p.CursorTime,
+			BeforeID: p.CursorId,
+			MaxEntries: p.Limit,
+		}
+
+		rows, errRows := cfg.DB.GetFollowers(r.Context(), followersPars)
+		if errRows != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get followers: %w, function: %s",
+					errRows,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		follows := make([]Follow, len(rows))
+		for i, row := range rows {
+			follows[i] = Follow{UserId: row.UserID, FollowedAt: row.CreatedAt}
+		}
+
+		respSuccesfullFollowsGet(&w, followPage(&p, follows))
+	}
+
+	return getFollowersHandler
+}
+
+func getFollowingHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getFollowingHandler := func(w http.ResponseWriter, r *http.Request) {
+		user, errUser := userFromPath(r, cfg)
+		if errUser != nil {
+			respondWithError(&w, errUser)
+			return
+		}
+
+		p, errPage := pageFromQuery(r)
+		if errPage != nil {
+			respondWithError(&w, errPage)
+			return
+		}
+
+		followingPars := database.GetFollowingParams{
+			UserID: user.ID,
+			BeforeTime: p.CursorTime,
+			BeforeID: p.CursorId,
+			MaxEntries: p.Limit,
+		}
+
+		rows, errRows := cfg.DB.GetFollowing(r.Context(), followingPars)
+		if errRows != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get followed users: %w, function: %s",
+					errRows,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		follows := make([]Follow, len(rows))
+		for i, row := range rows {
+			follows[i] = Follow{UserId: row.UserID, FollowedAt: row.CreatedAt}
+		}
+
+		respSuccesfullFollowsGet(&w, followPage(&p, follows))
+	}
+
+	return getFollowingHandler
+}
+
+// followUser adds the follow and copies the chirps of the followee that were
+// already fanned out in the home timeline of the follower, users with a block
+// in place between them can not follow each other. The followee is locked
+// so that a chirp being fanned out at the same time is not missed
+func followUser(ctx context.Context, q *database.Queries, followerId uuid.UUID, followeeId uuid.UUID) *customErrors.CodedError {
+	errLock := q.LockUserFollows(ctx, followeeId)
+	if errLock != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to lock followee: %w, function: %s",
+				errLock,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	blocked, errBlocked := blockedAmong(ctx, q, followerId, []uuid.UUID{followeeId})
+	if errBlocked != nil {
+		return errBlocked
+	}
+
+	if blocked[followeeId] {
+		e := customErrors.CodedError{
+			Message: "cannot follow this user",
+			StatusCode: http.StatusForbidden,
+		}
+		return &e
+	}
+
+	followPars := database.FollowUserParams{
+		FollowerID: followerId,
+		FolloweeID: followeeId,
+	}
+
+	created, errFollow := q.FollowUser(ctx, followPars)
+	if errFollow != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to follow user: %w, function: %s",
+				errFollow,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	if created == 0 {
+		e := customErrors.CodedError{
+			Message: "already following this user",
+			StatusCode: http.StatusConflict,
+		}
+		return &e
+	}
+
+	backfillPars := database.BackfillHomeTimelineParams{
+		FollowerID: followerId,
+		FolloweeID: followeeId,
+	}
+
+	errBackfill := q.BackfillHomeTimeline(ctx, backfillPars)
+	if errBackfill != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to backfill home timeline: %w, function: %s",
+				errBackfill,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	return nil
+}
+
+// unfollowUser removes the follow and the chirps of the followee from
+// the home timeline of the follower
+func unfollowUser(ctx context.Context, q *database.Queries, followerId uuid.UUID, followeeId uuid.UUID) *customErrors.CodedError {
+	unfollowPars := database.UnfollowUserParams{
+		FollowerID: followerId,
+		FolloweeID: followeeId,
+	}
+
+	deleted, errUnfollow := q.UnfollowUser(ctx, unfollowPars)
+	if errUnfollow != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to unfollow user: %w, function: %s",
+				errUnfollow,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	if deleted == 0 {
+		e := customErrors.CodedError{
+			Message: "not following this user",
+			StatusCode: http.StatusNotFound,
+		}
+		return &e
+	}
+
+	timelinePars := database.DeleteHomeTimelineAuthorParams{
+		UserID: followerId,
+		AuthorID: followeeId,
+	}
+
+	errTimeline := q.DeleteHomeTimelineAuthor(ctx, timelinePars)
+	if errTimeline != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to clean home timeline: %w, function: %s",
+				errTimeline,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	return nil
+}
+
+func followPage(p *page, follows []Follow) *FollowPage {
+	fp := FollowPage{
+		Users: follows,
+	}
+
+	if len(follows) > 0 {
+		last := follows[len(follows)-1]
+		fp.NextCursor = nextCursor(p, len(follows), cursor{Time: last.FollowedAt, Id: last.UserId})
+	}
+
+	return &fp
+}
+
+func userFromPath(r *http.Request, cfg *apiConfig) (database.User, *customErrors.CodedError) {
+	userUUID, errUUID := uuid.Parse(r.PathValue("id"))
+	if errUUID != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+				errUUID,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+		return database.User{}, &e
+	}
+
+	// the account holding the tombstones of deleted users can not be
+	// followed, blocked or looked at
+	user, errUser := cfg.DB.FindUserById(r.Context(), userUUID)
+	if errUser != nil || user.ID == deletedUserId {
+		e := customErrors.CodedError{
+			Message: "user not found",
+			StatusCode: http.StatusNotFound,
+		}
+		return database.User{}, &e
+	}
+
+	return user, nil
+}
+
+// setFollowCounts fills the follower and following counts of a user response
+func (cfg *apiConfig) setFollowCounts(ctx context.Context, u *User) *customErrors.CodedError {
+	counts, errCounts := cfg.DB.GetFollowCounts(ctx, u.Id)
+	if errCounts != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to count follows: %w, function: %s",
+				errCounts,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	u.FollowersCount = counts.FollowersCount
+	u.FollowingCount = counts.FollowingCount
+
+	return nil
+}
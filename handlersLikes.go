@@ -0,0 +1,214 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+)
+
+
+func postLikeHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postLikeHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		chirpUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		// liking a rechirp likes the chirp it reposts, as replies and shares do
+		chirp, errChirp := cfg.sharedChirp(r.Context(), userId, chirpUUID)
+		if errChirp == sql.ErrNoRows {
+			e := customErrors.CodedError{
+				Message: "chirp not found",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if errChirp != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get chirp: %w, function: %s",
+					errChirp,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		likePars := database.LikeChirpParams{
+			UserID: userId,
+			ChirpID: chirp.ID,
+		}
+
+		// the primary key on (user_id, chirp_id) makes concurrent likes of
+		// the same user collapse into one, counts are read from the rows
+		created, errLike := cfg.DB.LikeChirp(r.Context(), likePars)
+		if errLike != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to like chirp: %w, function: %s",
+					errLike,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if created == 0 {
+			e := customErrors.CodedError{
+				Message: "chirp already liked",
+				StatusCode: http.StatusConflict,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		cfg.notify(r, notificationEvent{
+			Kind: notificationLike,
+			UserId: chirp.UserID,
+			ActorId: userId,
+			ChirpId: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		})
+
+		respNoContent(&w)
+	}
+
+	return postLikeHandler
+}
+
+func deleteLikeHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	deleteLikeHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		chirpUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		// a rechirp was liked through the chirp it reposts
+		rechirp, errRechirp := cfg.DB.GetVisibleChirp(r.Context(), chirpUUID)
+		if errRechirp == nil && rechirp.RechirpOf.Valid {
+			chirpUUID = rechirp.RechirpOf.UUID
+		}
+
+		unlikePars := database.UnlikeChirpParams{
+			UserID: userId,
+			ChirpID: chirpUUID,
+		}
+
+		deleted, errUnlike := cfg.DB.UnlikeChirp(r.Context(), unlikePars)
+		if errUnlike != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to unlike chirp: %w, function: %s",
+					errUnlike,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if deleted == 0 {
+			e := customErrors.CodedError{
+				Message: "chirp not liked",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		respNoContent(&w)
+	}
+
+	return deleteLikeHandler
+}
+
+func getUserLikesHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getUserLikesHandler := func(w http.ResponseWriter, r *http.Request) {
+		user, errUser := userFromPath(r, cfg)
+		if errUser != nil {
+			respondWithError(&w, errUser)
+			return
+		}
+
+		p, errPage := pageFromQuery(r)
+		if errPage != nil {
+			respondWithError(&w, errPage)
+			return
+		}
+
+		likesPars := database.GetUserLikesParams{
+			UserID: user.ID,
+			BeforeTime: p.CursorTime,
+			BeforeID: p.CursorId,
+			MaxEntries: p.Limit,
+		}
+
+		likes, errLikes := cfg.DB.GetUserLikes(r.Context(), likesPars)
+		if errLikes != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get liked chirps: %w, function: %s",
+					errLikes,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		ids := make([]uuid.UUID, len(likes))
+		for i, like := range likes {
+			ids[i] = like.ChirpID
+		}
+
+		chirps, errChirps := cfg.visibleChirpsInOrder(r, ids)
+		if errChirps != nil {
+			respondWithError(&w, errChirps)
+			return
+		}
+
+		cp := ChirpPage{
+			Chirps: chirps,
+		}
+
+		// the cursor follows the likes, chirps that are not visible anymore
+		// are skipped without ending the pagination early
+		if len(likes) > 0 {
+			last := likes[len(likes)-1]
+			cp.NextCursor = nextCursor(&p, len(likes), cursor{Time: last.CreatedAt, Id: last.ChirpID})
+		}
+
+		respSuccesfullLikesGet(&w, &cp)
+	}
+
+	return getUserLikesHandler
+}
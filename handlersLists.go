@@ -0,0 +1,882 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+)
+
+const (
+	maxListNameLength = 25
+	maxListDescriptionLength = 100
+)
+
+// Chirpy Red members get higher limits
+const (
+	maxLists = 20
+	maxListsRed = 100
+	maxListMembers = 250
+	maxListMembersRed = 1000
+)
+
+func postListHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postListHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		req := listRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		errValidate := validateList(&req, cfg)
+		if errValidate != nil {
+			respondWithError(&w, errValidate)
+			return
+		}
+
+		owner, errOwner := cfg.DB.FindUserById(r.Context(), userId)
+		if errOwner != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get user: %w, function: %s",
+					errOwner,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		var list database.List
+		errCreate := cfg.withTx(r.Context(), func(q *database.Queries) *customErrors.CodedError {
+			// the owner is locked so that concurrent requests can not
+			// go past the limit
+			errLock := q.LockUserFollows(r.Context(), userId)
+			if errLock != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to lock user: %w, function: %s",
+						errLock,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				return &e
+			}
+
+			count, errCount := q.CountOwnedLists(r.Context(), userId)
+			if errCount != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to count lists: %w, function: %s",
+						errCount,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				return &e
+			}
+
+			limit := listLimit(&owner, maxLists, maxListsRed)
+			if count >= limit {
+				e := customErrors.CodedError{
+					Message: fmt.Sprintf("at most %d lists can be created", limit),
+					StatusCode: http.StatusForbidden,
+				}
+				return &e
+			}
+
+			listPars := database.CreateListParams{
+				OwnerID: userId,
+				Name: req.Name,
+				Description: req.Description,
+				Private: req.Private,
+			}
+
+			var errList error
+			list, errList = q.CreateList(r.Context(), listPars)
+			if errList != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to create list: %w, function: %s",
+						errList,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				return &e
+			}
+
+			return nil
+		})
+		if errCreate != nil {
+			respondWithError(&w, errCreate)
+			return
+		}
+
+		l, errDecorate := cfg.decorateList(r.Context(), userId, &list)
+		if errDecorate != nil {
+			respondWithError(&w, errDecorate)
+			return
+		}
+
+		respSuccesfullListPost(&w, l)
+	}
+
+	return postListHandler
+}
+
+func getListsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getListsHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		lists, errLists := cfg.DB.GetUserLists(r.Context(), userId)
+		if errLists != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get lists: %w, function: %s",
+					errLists,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		lArr, errDecorate := cfg.decorateLists(r.Context(), userId, lists)
+		if errDecorate != nil {
+			respondWithError(&w, errDecorate)
+			return
+		}
+
+		respSuccesfullListsGet(&w, lArr)
+	}
+
+	return getListsHandler
+}
+
+func getListHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getListHandler := func(w http.ResponseWriter, r *http.Request) {
+		viewerId := cfg.viewer(r)
+
+		list, errList := listFromPath(r, cfg, viewerId)
+		if errList != nil {
+			respondWithError(&w, errList)
+			return
+		}
+
+		l, errDecorate := cfg.decorateList(r.Context(), viewerId, &list)
+		if errDecorate != nil {
+			respondWithError(&w, errDecorate)
+			return
+		}
+
+		respSuccesfullListGet(&w, l)
+	}
+
+	return getListHandler
+}
+
+func putListHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	putListHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		list, errList := ownedListFromPath(r, cfg, userId)
+		if errList != nil {
+			respondWithError(&w, errList)
+			return
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		req := listRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		errValidate := validateList(&req, cfg)
+		if errValidate != nil {
+			respondWithError(&w, errValidate)
+			return
+		}
+
+		var updated database.List
+		errUpdate := cfg.withTx(r.Context(), func(q *database.Queries) *customErrors.CodedError {
+			updatePars := database.UpdateListParams{
+				ID: list.ID,
+				Name: req.Name,
+				Description: req.Description,
+				Private: req.Private,
+			}
+
+			var errList error
+			updated, errList = q.UpdateList(r.Context(), updatePars)
+			if errList != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to update list: %w, function: %s",
+						errList,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				return &e
+			}
+
+			// a private list is only visible to its owner, so it loses
+			// its subscribers
+			if !req.Private {
+				return nil
+			}
+
+			errSubscriptions := q.DeleteListSubscriptions(r.Context(), list.ID)
+			if errSubscriptions != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to delete list subscriptions: %w, function: %s",
+						errSubscriptions,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				return &e
+			}
+
+			return nil
+		})
+		if errUpdate != nil {
+			respondWithError(&w, errUpdate)
+			return
+		}
+
+		l, errDecorate := cfg.decorateList(r.Context(), userId, &updated)
+		if errDecorate != nil {
+			respondWithError(&w, errDecorate)
+			return
+		}
+
+		respSuccesfullListPut(&w, l)
+	}
+
+	return putListHandler
+}
+
+func deleteListHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	deleteListHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		list, errList := ownedListFromPath(r, cfg, userId)
+		if errList != nil {
+			respondWithError(&w, errList)
+			return
+		}
+
+		errDelete := cfg.DB.DeleteList(r.Context(), list.ID)
+		if errDelete != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to delete list: %w, function: %s",
+					errDelete,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		respNoContent(&w)
+	}
+
+	return deleteListHandler
+}
+
+func getListMembersHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getListMembersHandler := func(w http.ResponseWriter, r *http.Request) {
+		list, errList := listFromPath(r, cfg, cfg.viewer(r))
+		if errList != nil {
+			respondWithError(&w, errList)
+			return
+		}
+
+		p, errPage := pageFromQuery(r)
+		if errPage != nil {
+			respondWithError(&w, errPage)
+			return
+		}
+
+		membersPars := database.GetListMembersParams{
+			ListID: list.ID,
+			BeforeTime: p.CursorTime,
+			BeforeID: p.CursorId,
+			MaxEntries: p.Limit,
+		}
+
+		rows, errRows := cfg.DB.GetListMembers(r.Context(), membersPars)
+		if errRows != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get list members: %w, function: %s",
+					errRows,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		mp := ListMemberPage{
+			Users: make([]ListMember, len(rows)),
+		}
+		for i, row := range rows {
+			mp.Users[i] = ListMember{UserId: row.UserID, AddedAt: row.CreatedAt}
+		}
+
+		if len(rows) > 0 {
+			last := rows[len(rows)-1]
+			mp.NextCursor = nextCursor(&p, len(rows), cursor{Time: last.CreatedAt, Id: last.UserID})
+		}
+
+		respSuccesfullListMembersGet(&w, &mp)
+	}
+
+	return getListMembersHandler
+}
+
+func postListMemberHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postListMemberHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		list, errList := ownedListFromPath(r, cfg, userId)
+		if errList != nil {
+			respondWithError(&w, errList)
+			return
+		}
+
+		memberUUID, errUUID := uuid.Parse(r.PathValue("userId"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		member, errMember := cfg.DB.FindUserById(r.Context(), memberUUID)
+		if errMember != nil {
+			e := customErrors.CodedError{
+				Message: "user not found",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		blocked, errBlocked := blockedAmong(r.Context(), cfg.DB, userId, []uuid.UUID{member.ID})
+		if errBlocked != nil {
+			respondWithError(&w, errBlocked)
+			return
+		}
+
+		if blocked[member.ID] {
+			e := customErrors.CodedError{
+				Message: "cannot add this user to a list",
+				StatusCode: http.StatusForbidden,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		owner, errOwner := cfg.DB.FindUserById(r.Context(), userId)
+		if errOwner != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get user: %w, function: %s",
+					errOwner,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		errAdd := cfg.withTx(r.Context(), func(q *database.Queries) *customErrors.CodedError {
+			errLock := q.LockList(r.Context(), list.ID)
+			if errLock != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to lock list: %w, function: %s",
+						errLock,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				return &e
+			}
+
+			count, errCount := q.CountListMembers(r.Context(), list.ID)
+			if errCount != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to count list members: %w, function: %s",
+						errCount,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				return &e
+			}
+
+			limit := listLimit(&owner, maxListMembers, maxListMembersRed)
+			if count >= limit {
+				e := customErrors.CodedError{
+					Message: fmt.Sprintf("a list can have at most %d members", limit),
+					StatusCode: http.StatusForbidden,
+				}
+				return &e
+			}
+
+			memberPars := database.AddListMemberParams{
+				ListID: list.ID,
+				UserID: member.ID,
+			}
+
+			added, errMember := q.AddListMember(r.Context(), memberPars)
+			if errMember != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to add list member: %w, function: %s",
+						errMember,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				return &e
+			}
+
+			if added == 0 {
+				e := customErrors.CodedError{
+					Message: "user already in the list",
+					StatusCode: http.StatusConflict,
+				}
+				return &e
+			}
+
+			return nil
+		})
+		if errAdd != nil {
+			respondWithError(&w, errAdd)
+			return
+		}
+
+		respNoContent(&w)
+	}
+
+	return postListMemberHandler
+}
+
+func deleteListMemberHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	deleteListMemberHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		list, errList := ownedListFromPath(r, cfg, userId)
+		if errList != nil {
+			respondWithError(&w, errList)
+			return
+		}
+
+		memberUUID, errUUID := uuid.Parse(r.PathValue("userId"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		removePars := database.RemoveListMemberParams{
+			ListID: list.ID,
+			UserID: memberUUID,
+		}
+
+		removed, errRemove := cfg.DB.RemoveListMember(r.Context(), removePars)
+		if errRemove != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to remove list member: %w, function: %s",
+					errRemove,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if removed == 0 {
+			e := customErrors.CodedError{
+				Message: "user not in the list",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		respNoContent(&w)
+	}
+
+	return deleteListMemberHandler
+}
+
+func postListSubscriptionHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postListSubscriptionHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		list, errList := listFromPath(r, cfg, userId)
+		if errList != nil {
+			respondWithError(&w, errList)
+			return
+		}
+
+		if list.OwnerID == userId {
+			e := customErrors.CodedError{
+				Message: "cannot subscribe to your own list",
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		subscribePars := database.SubscribeListParams{
+			ListID: list.ID,
+			UserID: userId,
+		}
+
+		created, errSubscribe := cfg.DB.SubscribeList(r.Context(), subscribePars)
+		if errSubscribe != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to subscribe to list: %w, function: %s",
+					errSubscribe,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if created == 0 {
+			e := customErrors.CodedError{
+				Message: "already subscribed to this list",
+				StatusCode: http.StatusConflict,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		respNoContent(&w)
+	}
+
+	return postListSubscriptionHandler
+}
+
+func deleteListSubscriptionHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	deleteListSubscriptionHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		listUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		unsubscribePars := database.UnsubscribeListParams{
+			ListID: listUUID,
+			UserID: userId,
+		}
+
+		deleted, errUnsubscribe := cfg.DB.UnsubscribeList(r.Context(), unsubscribePars)
+		if errUnsubscribe != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to unsubscribe from list: %w, function: %s",
+					errUnsubscribe,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if deleted == 0 {
+			e := customErrors.CodedError{
+				Message: "not subscribed to this list",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		respNoContent(&w)
+	}
+
+	return deleteListSubscriptionHandler
+}
+
+func getListTimelineHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getListTimelineHandler := func(w http.ResponseWriter, r *http.Request) {
+		viewerId := cfg.viewer(r)
+
+		list, errList := listFromPath(r, cfg, viewerId)
+		if errList != nil {
+			respondWithError(&w, errList)
+			return
+		}
+
+		p, errPage := pageFromQuery(r)
+		if errPage != nil {
+			respondWithError(&w, errPage)
+			return
+		}
+
+		timelinePars := database.GetListTimelineParams{
+			ListID: list.ID,
+			BeforeTime: p.CursorTime,
+			BeforeID: p.CursorId,
+			MaxEntries: p.Limit,
+		}
+
+		chirps, errChirps := cfg.DB.GetListTimeline(r.Context(), timelinePars)
+		if errChirps != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get list timeline: %w, function: %s",
+					errChirps,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		visible, errMuted := cfg.withoutMuted(r.Context(), viewerId, chirps)
+		if errMuted != nil {
+			respondWithError(&w, errMuted)
+			return
+		}
+
+		cArr, errDecorate := cfg.decorateChirpsFor(r.Context(), viewerId, visible, mutedWordHome)
+		if errDecorate != nil {
+			respondWithError(&w, errDecorate)
+			return
+		}
+
+		respSuccesfullTimelineGet(&w, chirpPage(&p, chirps, cArr))
+	}
+
+	return getListTimelineHandler
+}
+
+// listFromPath returns the list in the path, private lists of other users
+// and lists of users with a block in place with the viewer are reported
+// as missing
+func listFromPath(r *http.Request, cfg *apiConfig, viewerId uuid.UUID) (database.List, *customErrors.CodedError) {
+	listUUID, errUUID := uuid.Parse(r.PathValue("id"))
+	if errUUID != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+				errUUID,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+		return database.List{}, &e
+	}
+
+	notFound := customErrors.CodedError{
+		Message: "list not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	list, errList := cfg.DB.GetList(r.Context(), listUUID)
+	if errList != nil || (list.Private && list.OwnerID != viewerId) {
+		return database.List{}, &notFound
+	}
+
+	blocked, errBlocked := blockedAmong(r.Context(), cfg.DB, viewerId, []uuid.UUID{list.OwnerID})
+	if errBlocked != nil {
+		return database.List{}, errBlocked
+	}
+
+	if blocked[list.OwnerID] {
+		return database.List{}, &notFound
+	}
+
+	return list, nil
+}
+
+// ownedListFromPath is listFromPath for the endpoints editing a list
+func ownedListFromPath(r *http.Request, cfg *apiConfig, userId uuid.UUID) (database.List, *customErrors.CodedError) {
+	list, errList := listFromPath(r, cfg, userId)
+	if errList != nil {
+		return database.List{}, errList
+	}
+
+	if list.OwnerID != userId {
+		e := customErrors.CodedError{
+			Message: "only the owner can edit a list",
+			StatusCode: http.StatusForbidden,
+		}
+		return database.List{}, &e
+	}
+
+	return list, nil
+}
+
+func validateList(req *listRequest, cfg *apiConfig) *customErrors.CodedError {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+
+	if req.Name == "" || utf8.RuneCountInString(req.Name) > maxListNameLength {
+		e := customErrors.CodedError{
+			Message: fmt.Sprintf("a list name must be 1 to %d characters long", maxListNameLength),
+			StatusCode: http.StatusBadRequest,
+		}
+		return &e
+	}
+
+	if utf8.RuneCountInString(req.Description) > maxListDescriptionLength {
+		e := customErrors.CodedError{
+			Message: fmt.Sprintf("description can be at most %d characters long", maxListDescriptionLength),
+			StatusCode: http.StatusBadRequest,
+		}
+		return &e
+	}
+
+	for _, text := range []*string{&req.Name, &req.Description} {
+		_, errProfanity := cleanProfanity(text, cfg.Moderation)
+		if errProfanity != nil {
+			return errProfanity
+		}
+	}
+
+	return nil
+}
+
+func listLimit(owner *database.User, limit int64, redLimit int64) int64 {
+	if owner.IsChirpyRed {
+		return redLimit
+	}
+
+	return limit
+}
+
+// decorateLists maps lists adding their counters and whether the viewer
+// subscribed to them
+func (cfg *apiConfig) decorateLists(ctx context.Context, viewerId uuid.UUID, lists []database.List) ([]List, *customErrors.CodedError) {
+	lArr := make([]List, len(lists))
+	if len(lists) == 0 {
+		return lArr, nil
+	}
+
+	ids := make([]uuid.UUID, len(lists))
+	for i, l := range lists {
+		ids[i] = l.ID
+	}
+
+	counts, errCounts := cfg.DB.GetListCounts(ctx, ids)
+	if errCounts != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to count list members: %w, function: %s",
+				errCounts,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	byList := make(map[uuid.UUID]database.GetListCountsRow, len(counts))
+	for _, c := range counts {
+		byList[c.ListID] = c
+	}
+
+	subscribed := map[uuid.UUID]bool{}
+	if viewerId != uuid.Nil {
+		subscribedPars := database.GetSubscribedAmongParams{
+			UserID: viewerId,
+			ListIds: ids,
+		}
+
+		subscribedIds, errSubscribed := cfg.DB.GetSubscribedAmong(ctx, subscribedPars)
+		if errSubscribed != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get list subscriptions: %w, function: %s",
+					errSubscribed,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return nil, &e
+		}
+
+		for _, id := range subscribedIds {
+			subscribed[id] = true
+		}
+	}
+
+	for i, l := range lists {
+		lArr[i].mapList(&l)
+		lArr[i].MemberCount = byList[l.ID].MemberCount
+		lArr[i].SubscriberCount = byList[l.ID].SubscriberCount
+		lArr[i].SubscribedByMe = subscribed[l.ID]
+	}
+
+	return lArr, nil
+}
+
+func (cfg *apiConfig) decorateList(ctx context.Context, viewerId uuid.UUID, list *database.List) (*List, *customErrors.CodedError) {
+	lArr, errDecorate := cfg.decorateLists(ctx, viewerId, []database.List{*list})
+	if errDecorate != nil {
+		return nil, errDecorate
+	}
+
+	return &lArr[0], nil
+}
@@ -0,0 +1,511 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/blobstore"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+	"github.com/niccolot/Chirpy/internal/media"
+)
+
+
+const (
+	maxMediaSize = 8 << 20
+	maxMediaSide = 2048
+	mediaThumbnailSide = 400
+	maxAltTextLength = 1000
+	maxChirpMedia = 4
+)
+
+const (
+	mediaGCInterval = 10 * time.Minute
+	// uploads not attached to a chirp within this time are collected
+	mediaOrphanAge = 24 * time.Hour
+	mediaGCBatchSize = 100
+)
+
+// the files are named after their media id or, for the profile images,
+// after their content, so a name never changes content
+const mediaCacheControl = "public, max-age=31536000, immutable"
+
+func postMediaHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postMediaHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		data, altText, errUpload := readImageUpload(w, r, maxMediaSize)
+		if errUpload != nil {
+			respondWithError(&w, errUpload)
+			return
+		}
+
+		altText = strings.TrimSpace(altText)
+		errAlt := validateAltText(&altText, cfg)
+		if errAlt != nil {
+			respondWithError(&w, errAlt)
+			return
+		}
+
+		img, contentType, errDecode := media.Decode(data)
+		if errDecode != nil {
+			respondWithError(&w, imageError(errDecode))
+			return
+		}
+
+		original, errOriginal := media.Encode(media.Fit(img, maxMediaSide), contentType)
+		if errOriginal != nil {
+			respondWithError(&w, imageError(errOriginal))
+			return
+		}
+
+		thumbnailImg := media.Fit(img, mediaThumbnailSide)
+		thumbnail, errThumbnail := media.Encode(thumbnailImg, contentType)
+		if errThumbnail != nil {
+			respondWithError(&w, imageError(errThumbnail))
+			return
+		}
+
+		id := uuid.New()
+		originalKey := fmt.Sprintf("media/%s/original%s", id, original.Ext)
+		thumbnailKey := fmt.Sprintf("media/%s/thumbnail%s", id, thumbnail.Ext)
+
+		errStore := cfg.putBlobs(r.Context(), map[string]*media.Encoded{
+			originalKey: &original,
+			thumbnailKey: &thumbnail,
+		})
+		if errStore != nil {
+			respondWithError(&w, errStore)
+			return
+		}
+
+		mediaPars := database.CreateMediaParams{
+			ID: id,
+			UserID: uuid.NullUUID{UUID: userId, Valid: true},
+			ContentType: original.ContentType,
+			Width: int32(original.Width),
+			Height: int32(original.Height),
+			Size: int64(len(original.Data)),
+			AltText: altText,
+			Blurhash: media.Blurhash(thumbnailImg),
+			OriginalKey: originalKey,
+			ThumbnailKey: thumbnailKey,
+		}
+
+		created, errCreate := cfg.DB.CreateMedia(r.Context(), mediaPars)
+		if errCreate != nil {
+			cfg.deleteBlobs(context.WithoutCancel(r.Context()), originalKey, thumbnailKey)
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to create media: %w, function: %s",
+					errCreate,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		m := Media{}
+		m.mapMedia(&created)
+
+		respSuccesfullMediaPost(&w, &m)
+	}
+
+	return postMediaHandler
+}
+
+func putMediaHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	putMediaHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		mediaUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		req := mediaPutRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		req.AltText = strings.TrimSpace(req.AltText)
+		errAlt := validateAltText(&req.AltText, cfg)
+		if errAlt != nil {
+			respondWithError(&w, errAlt)
+			return
+		}
+
+		altPars := database.UpdateMediaAltTextParams{
+			ID: mediaUUID,
+			UserID: uuid.NullUUID{UUID: userId, Valid: true},
+			AltText: req.AltText,
+		}
+
+		updated, errUpdate := cfg.DB.UpdateMediaAltText(r.Context(), altPars)
+		if errUpdate != nil {
+			e := customErrors.CodedError{
+				Message: "media not found",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		m := Media{}
+		m.mapMedia(&updated)
+
+		respSuccesfullMediaPut(&w, &m)
+	}
+
+	return putMediaHandler
+}
+
+// getMediaFileHandlerWrapped serves the stored files whatever the store,
+// so that clients do not depend on where the blobs are kept
+func getMediaFileHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getMediaFileHandler := func(w http.ResponseWriter, r *http.Request) {
+		key := r.PathValue("key")
+		notFound := customErrors.CodedError{
+			Message: "file not found",
+			StatusCode: http.StatusNotFound,
+		}
+
+		if !blobstore.ValidKey(key) {
+			respondWithError(&w, &notFound)
+			return
+		}
+
+		body, contentType, errGet := cfg.Blobs.Get(r.Context(), key)
+		if errors.Is(errGet, blobstore.ErrNotFound) {
+			respondWithError(&w, &notFound)
+			return
+		}
+		if errGet != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to read file: %w, function: %s",
+					errGet,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+		defer body.Close()
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", mediaCacheControl)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+		io.Copy(w, body)
+	}
+
+	return getMediaFileHandler
+}
+
+// readImageUpload reads the file part of a multipart upload and its
+// alt_text field, the declared content type of the part is ignored
+func readImageUpload(w http.ResponseWriter, r *http.Request, maxSize int64) ([]byte, string, *customErrors.CodedError) {
+	tooLarge := customErrors.CodedError{
+		Message: fmt.Sprintf("file larger than %d MB", maxSize>>20),
+		StatusCode: http.StatusRequestEntityTooLarge,
+	}
+
+	// room for the other fields and the multipart boundaries
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+64<<10)
+	errParse := r.ParseMultipartForm(maxSize)
+	if errParse != nil {
+		var errMaxBytes *http.MaxBytesError
+		if errors.As(errParse, &errMaxBytes) {
+			return nil, "", &tooLarge
+		}
+
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to parse upload: %w, function: %s",
+				errParse,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+		return nil, "", &e
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, errFile := r.FormFile("file")
+	if errFile != nil {
+		e := customErrors.CodedError{
+			Message: "missing file",
+			StatusCode: http.StatusBadRequest,
+		}
+		return nil, "", &e
+	}
+	defer file.Close()
+
+	data, errRead := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if errRead != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to read upload: %w, function: %s",
+				errRead,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, "", &e
+	}
+
+	if int64(len(data)) > maxSize {
+		return nil, "", &tooLarge
+	}
+
+	return data, r.FormValue("alt_text"), nil
+}
+
+func imageError(err error) *customErrors.CodedError {
+	if errors.Is(err, media.ErrUnsupported) {
+		e := customErrors.CodedError{
+			Message: "only jpeg, png, gif and webp images are supported",
+			StatusCode: http.StatusUnsupportedMediaType,
+		}
+		return &e
+	}
+
+	e := customErrors.CodedError{
+		Message: err.Error(),
+		StatusCode: http.StatusBadRequest,
+	}
+	return &e
+}
+
+func validateAltText(altText *string, cfg *apiConfig) *customErrors.CodedError {
+	if utf8.RuneCountInString(*altText) > maxAltTextLength {
+		e := customErrors.CodedError{
+			Message: fmt.Sprintf("alt text can be at most %d characters long", maxAltTextLength),
+			StatusCode: http.StatusBadRequest,
+		}
+		return &e
+	}
+
+	_, errProfanity := cleanProfanity(altText, cfg.Moderation)
+
+	return errProfanity
+}
+
+// putBlobs stores the files, removing the ones already written if one fails
+func (cfg *apiConfig) putBlobs(ctx context.Context, files map[string]*media.Encoded) *customErrors.CodedError {
+	written := []string{}
+	for key, file := range files {
+		errPut := cfg.Blobs.Put(ctx, key, file.ContentType, file.Data)
+		if errPut != nil {
+			cfg.deleteBlobs(context.WithoutCancel(ctx), written...)
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to store file: %w, function: %s",
+					errPut,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+		written = append(written, key)
+	}
+
+	return nil
+}
+
+// deleteBlobs is best effort, a file left behind only takes space
+func (cfg *apiConfig) deleteBlobs(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		errDelete := cfg.Blobs.Delete(ctx, key)
+		if errDelete != nil {
+			log.Printf("delete blob %s: %s", key, errDelete)
+		}
+	}
+}
+
+// checkChirpMedia validates the media ids sent with a new chirp, they are
+// checked against the owner when attached
+func checkChirpMedia(ids []uuid.UUID) *customErrors.CodedError {
+	if len(ids) > maxChirpMedia {
+		e := customErrors.CodedError{
+			Message: fmt.Sprintf("a chirp can have at most %d media", maxChirpMedia),
+			StatusCode: http.StatusBadRequest,
+		}
+		return &e
+	}
+
+	seen := map[uuid.UUID]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			e := customErrors.CodedError{
+				Message: "duplicate media id",
+				StatusCode: http.StatusBadRequest,
+			}
+			return &e
+		}
+		seen[id] = true
+	}
+
+	return nil
+}
+
+func attachMedia(ctx context.Context, q *database.Queries, chirp *database.Chirp, ids []uuid.UUID) *customErrors.CodedError {
+	for i, id := range ids {
+		attachPars := database.AttachMediaParams{
+			ID: id,
+			UserID: uuid.NullUUID{UUID: chirp.UserID, Valid: true},
+			ChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			Position: int32(i),
+		}
+
+		attached, errAttach := q.AttachMedia(ctx, attachPars)
+		if errAttach != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to attach media: %w, function: %s",
+					errAttach,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+
+		if attached == 0 {
+			e := customErrors.CodedError{
+				Message: fmt.Sprintf("media %s not found or already used", id),
+				StatusCode: http.StatusBadRequest,
+			}
+			return &e
+		}
+	}
+
+	return nil
+}
+
+// mediaFor returns the media of the chirps with the given ids in order,
+// chirps without media are not in the map
+func (cfg *apiConfig) mediaFor(ctx context.Context, chirpIds []uuid.UUID) (map[uuid.UUID][]Media, *customErrors.CodedError) {
+	rows, errMedia := cfg.DB.GetChirpMedia(ctx, chirpIds)
+	if errMedia != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to get chirp media: %w, function: %s",
+				errMedia,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	byChirp := map[uuid.UUID][]Media{}
+	for _, row := range rows {
+		m := Media{}
+		m.mapMedia(&row)
+		byChirp[row.ChirpID.UUID] = append(byChirp[row.ChirpID.UUID], m)
+	}
+
+	return byChirp, nil
+}
+
+// collectMedia deletes the orphaned media with their files, a media whose
+// files can not be deleted is kept for the next run
+func (cfg *apiConfig) collectMedia(ctx context.Context) *customErrors.CodedError {
+	for {
+		collected := 0
+		errCollect := cfg.withTx(ctx, func(q *database.Queries) *customErrors.CodedError {
+			claimPars := database.ClaimOrphanedMediaParams{
+				CreatedBefore: time.Now().UTC().Add(-mediaOrphanAge),
+				MaxEntries: mediaGCBatchSize,
+			}
+
+			orphans, errOrphans := q.ClaimOrphanedMedia(ctx, claimPars)
+			if errOrphans != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to get orphaned media: %w, function: %s",
+						errOrphans,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				return &e
+			}
+
+			ids := []uuid.UUID{}
+			for _, o := range orphans {
+				errOriginal := cfg.Blobs.Delete(ctx, o.OriginalKey)
+				errThumbnail := cfg.Blobs.Delete(ctx, o.ThumbnailKey)
+				if errOriginal != nil || errThumbnail != nil {
+					log.Printf("media %s: %s", o.ID, errors.Join(errOriginal, errThumbnail))
+					continue
+				}
+				ids = append(ids, o.ID)
+			}
+
+			errDelete := q.DeleteMediaByIds(ctx, ids)
+			if errDelete != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to delete orphaned media: %w, function: %s",
+						errDelete,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				return &e
+			}
+			collected = len(ids)
+
+			return nil
+		})
+		if errCollect != nil {
+			return errCollect
+		}
+
+		if collected < mediaGCBatchSize {
+			return nil
+		}
+	}
+}
+
+// runMediaWorker collects the orphaned media every mediaGCInterval until
+// ctx is done
+func (cfg *apiConfig) runMediaWorker(ctx context.Context) {
+	ticker := time.NewTicker(mediaGCInterval)
+	defer ticker.Stop()
+
+	for {
+		errCollect := cfg.collectMedia(ctx)
+		if errCollect != nil {
+			log.Printf("media worker: %s", errCollect.Message)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
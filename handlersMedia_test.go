@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func uploadRequest(t *testing.T, file []byte, altText string) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("file", "upload.png")
+	part.Write(file)
+	mw.WriteField("alt_text", altText)
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/media", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	return req
+}
+
+func TestReadImageUploadSizeLimit(t *testing.T) {
+	const maxSize = 1 << 20
+
+	tests := []struct {
+		name string
+		size int
+		wantStatus int
+	}{
+		{"below the limit", maxSize - 1, 0},
+		{"at the limit", maxSize, 0},
+		{"one byte over", maxSize + 1, http.StatusRequestEntityTooLarge},
+		{"far over", 4 * maxSize, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			data, altText, err := readImageUpload(w, uploadRequest(t, make([]byte, tt.size), "a cat"), maxSize)
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err.Message)
+				}
+				if len(data) != tt.size || altText != "a cat" {
+					t.Errorf("read %d bytes and alt text %q", len(data), altText)
+				}
+				return
+			}
+			if err == nil || err.StatusCode != tt.wantStatus {
+				t.Errorf("expected status %d, got %+v", tt.wantStatus, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,602 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+)
+
+
+const (
+	maxConversationParticipants = 10 // creator included
+	maxMessageLength = 1000
+)
+
+func postConversationHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postConversationHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		req := conversationPostRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		others := []uuid.UUID{}
+		seen := map[uuid.UUID]bool{userId: true}
+		for _, id := range req.ParticipantIds {
+			if !seen[id] {
+				seen[id] = true
+				others = append(others, id)
+			}
+		}
+
+		if len(others) == 0 || len(others)+1 > maxConversationParticipants {
+			e := customErrors.CodedError{
+				Message: fmt.Sprintf("a conversation needs 2 to %d participants", maxConversationParticipants),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		errAllowed := cfg.checkCanMessage(r.Context(), userId, others)
+		if errAllowed != nil {
+			respondWithError(&w, errAllowed)
+			return
+		}
+
+		directKey := sql.NullString{}
+		if len(others) == 1 {
+			directKey = sql.NullString{String: conversationDirectKey(userId, others[0]), Valid: true}
+
+			existing, errExisting := cfg.DB.GetDirectConversation(r.Context(), directKey)
+			if errExisting == nil {
+				cfg.respondConversation(&w, r, userId, &existing, http.StatusOK)
+				return
+			}
+
+			if errExisting != sql.ErrNoRows {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to get conversation: %w, function: %s",
+						errExisting,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				respondWithError(&w, &e)
+				return
+			}
+		}
+
+		var conversation database.Conversation
+		errCreate := cfg.withTx(r.Context(), func(q *database.Queries) *customErrors.CodedError {
+			conversationPars := database.CreateConversationParams{
+				CreatedBy: uuid.NullUUID{UUID: userId, Valid: true},
+				DirectKey: directKey,
+			}
+
+			var errConversation error
+			conversation, errConversation = q.CreateConversation(r.Context(), conversationPars)
+			if errConversation != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to create conversation: %w, function: %s",
+						errConversation,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				return &e
+			}
+
+			participantsPars := database.AddConversationParticipantsParams{
+				ConversationID: conversation.ID,
+				UserIds: append([]uuid.UUID{userId}, others...),
+			}
+
+			errParticipants := q.AddConversationParticipants(r.Context(), participantsPars)
+			if errParticipants != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to add participants: %w, function: %s",
+						errParticipants,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				return &e
+			}
+
+			return nil
+		})
+		if errCreate != nil {
+			// the other user opened the same conversation in the meantime
+			if directKey.Valid {
+				existing, errExisting := cfg.DB.GetDirectConversation(r.Context(), directKey)
+				if errExisting == nil {
+					cfg.respondConversation(&w, r, userId, &existing, http.StatusOK)
+					return
+				}
+			}
+
+			respondWithError(&w, errCreate)
+			return
+		}
+
+		cfg.respondConversation(&w, r, userId, &conversation, http.StatusCreated)
+	}
+
+	return postConversationHandler
+}
+
+func getConversationsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getConversationsHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		p, errPage := pageFromQuery(r)
+		if errPage != nil {
+			respondWithError(&w, errPage)
+			return
+		}
+
+		conversationsPars := database.GetConversationsParams{
+			UserID: userId,
+			BeforeTime: p.CursorTime,
+			BeforeID: p.CursorId,
+			MaxEntries: p.Limit,
+		}
+
+		conversations, errConversations := cfg.DB.GetConversations(r.Context(), conversationsPars)
+		if errConversations != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get conversations: %w, function: %s",
+					errConversations,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		cArr, errDecorate := cfg.decorateConversations(r.Context(), userId, conversations)
+		if errDecorate != nil {
+			respondWithError(&w, errDecorate)
+			return
+		}
+
+		cp := ConversationPage{
+			Conversations: cArr,
+		}
+
+		if len(conversations) > 0 {
+			last := conversations[len(conversations)-1]
+			cp.NextCursor = nextCursor(&p, len(conversations), cursor{Time: last.UpdatedAt, Id: last.ID})
+		}
+
+		respSuccesfullConversationsGet(&w, &cp)
+	}
+
+	return getConversationsHandler
+}
+
+func postMessageHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postMessageHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		conversation, errConversation := conversationFromPath(r, cfg, userId)
+		if errConversation != nil {
+			respondWithError(&w, errConversation)
+			return
+		}
+
+		participants, errParticipants := cfg.DB.GetConversationParticipants(r.Context(), []uuid.UUID{conversation.ID})
+		if errParticipants != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get participants: %w, function: %s",
+					errParticipants,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		recipients := make([]uuid.UUID, 0, len(participants))
+		for _, p := range participants {
+			if p.UserID != userId {
+				recipients = append(recipients, p.UserID)
+			}
+		}
+
+		errBlocked := cfg.checkNotBlocked(r.Context(), userId, recipients)
+		if errBlocked != nil {
+			respondWithError(&w, errBlocked)
+			return
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		req := messagePostRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if req.Body == "" || len(req.Body) > maxMessageLength {
+			e := customErrors.CodedError{
+				Message: fmt.Sprintf("a message must be 1 to %d characters long", maxMessageLength),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		_, errProfanity := cleanProfanity(&req.Body, cfg.Moderation)
+		if errProfanity != nil {
+			respondWithError(&w, errProfanity)
+			return
+		}
+
+		spamResult, errSpam := cfg.checkMessageSpam(r.Context(), userId, req.Body)
+		if errSpam != nil {
+			if errSpam.StatusCode == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(spamResult.RetryAfter.Seconds()))))
+			}
+			respondWithError(&w, errSpam)
+			return
+		}
+
+		var message database.Message
+		errSend := cfg.withTx(r.Context(), func(q *database.Queries) *customErrors.CodedError {
+			messagePars := database.CreateMessageParams{
+				ConversationID: conversation.ID,
+				SenderID: userId,
+				Body: req.Body,
+				BodyHash: spamResult.Hash,
+				Simhash: int64(spamResult.Simhash),
+			}
+
+			var errMessage error
+			message, errMessage = q.CreateMessage(r.Context(), messagePars)
+			if errMessage != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to send message: %w, function: %s",
+						errMessage,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				return &e
+			}
+
+			errTouch := q.TouchConversation(r.Context(), conversation.ID)
+			if errTouch != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to update conversation: %w, function: %s",
+						errTouch,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				return &e
+			}
+
+			return nil
+		})
+		if errSend != nil {
+			respondWithError(&w, errSend)
+			return
+		}
+
+		m := Message{}
+		m.mapMessage(&message)
+
+		respSuccesfullMessagePost(&w, &m)
+	}
+
+	return postMessageHandler
+}
+
+func getMessagesHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getMessagesHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		conversation, errConversation := conversationFromPath(r, cfg, userId)
+		if errConversation != nil {
+			respondWithError(&w, errConversation)
+			return
+		}
+
+		p, errPage := pageFromQuery(r)
+		if errPage != nil {
+			respondWithError(&w, errPage)
+			return
+		}
+
+		messagesPars := database.GetMessagesParams{
+			ConversationID: conversation.ID,
+			BeforeTime: p.CursorTime,
+			BeforeID: p.CursorId,
+			MaxEntries: p.Limit,
+		}
+
+		messages, errMessages := cfg.DB.GetMessages(r.Context(), messagesPars)
+		if errMessages != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get messages: %w, function: %s",
+					errMessages,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		mp := MessagePage{
+			Messages: make([]Message, len(messages)),
+		}
+		for i, m := range messages {
+			mp.Messages[i].mapMessage(&m)
+		}
+
+		if len(messages) > 0 {
+			last := messages[len(messages)-1]
+			mp.NextCursor = nextCursor(&p, len(messages), cursor{Time: last.CreatedAt, Id: last.ID})
+		}
+
+		respSuccesfullMessagesGet(&w, &mp)
+	}
+
+	return getMessagesHandler
+}
+
+func postConversationReadHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postConversationReadHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		conversation, errConversation := conversationFromPath(r, cfg, userId)
+		if errConversation != nil {
+			respondWithError(&w, errConversation)
+			return
+		}
+
+		readPars := database.MarkConversationReadParams{
+			ConversationID: conversation.ID,
+			UserID: userId,
+		}
+
+		errRead := cfg.DB.MarkConversationRead(r.Context(), readPars)
+		if errRead != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to mark conversation as read: %w, function: %s",
+					errRead,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		respNoContent(&w)
+	}
+
+	return postConversationReadHandler
+}
+
+// conversationFromPath returns the conversation in the path, conversations
+// the user is not part of are reported as missing
+func conversationFromPath(r *http.Request, cfg *apiConfig, userId uuid.UUID) (database.Conversation, *customErrors.CodedError) {
+	conversationUUID, errUUID := uuid.Parse(r.PathValue("id"))
+	if errUUID != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+				errUUID,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+		return database.Conversation{}, &e
+	}
+
+	conversationPars := database.GetConversationForUserParams{
+		ID: conversationUUID,
+		UserID: userId,
+	}
+
+	conversation, errConversation := cfg.DB.GetConversationForUser(r.Context(), conversationPars)
+	if errConversation != nil {
+		e := customErrors.CodedError{
+			Message: "conversation not found",
+			StatusCode: http.StatusNotFound,
+		}
+		return database.Conversation{}, &e
+	}
+
+	return conversation, nil
+}
+
+// checkCanMessage lets a user start a conversation only with users
+// following them, so that nobody gets messages from strangers
+func (cfg *apiConfig) checkCanMessage(ctx context.Context, senderId uuid.UUID, recipients []uuid.UUID) *customErrors.CodedError {
+	errBlocked := cfg.checkNotBlocked(ctx, senderId, recipients)
+	if errBlocked != nil {
+		return errBlocked
+	}
+
+	followersPars := database.GetFollowersAmongParams{
+		FolloweeID: senderId,
+		UserIds: recipients,
+	}
+
+	followers, errFollowers := cfg.DB.GetFollowersAmong(ctx, followersPars)
+	if errFollowers != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to check followers: %w, function: %s",
+				errFollowers,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	if len(followers) != len(recipients) {
+		e := customErrors.CodedError{
+			Message: "users can only be messaged by the users they follow",
+			StatusCode: http.StatusForbidden,
+		}
+		return &e
+	}
+
+	return nil
+}
+
+// checkNotBlocked refuses messages between users with a block in place,
+// in group conversations a single block stops the sender
+func (cfg *apiConfig) checkNotBlocked(ctx context.Context, senderId uuid.UUID, recipients []uuid.UUID) *customErrors.CodedError {
+	blocked, errBlocked := blockedAmong(ctx, cfg.DB, senderId, recipients)
+	if errBlocked != nil {
+		return errBlocked
+	}
+
+	if len(blocked) > 0 {
+		e := customErrors.CodedError{
+			Message: "cannot message these users",
+			StatusCode: http.StatusForbidden,
+		}
+		return &e
+	}
+
+	return nil
+}
+
+// conversationDirectKey is the same for both users of a one to one conversation
+func conversationDirectKey(a uuid.UUID, b uuid.UUID) string {
+	ids := []string{a.String(), b.String()}
+	sort.Strings(ids)
+
+	return ids[0] + ":" + ids[1]
+}
+
+// decorateConversations maps conversations adding their participants,
+// their last message and how many messages userId did not read
+func (cfg *apiConfig) decorateConversations(ctx context.Context, userId uuid.UUID, conversations []database.Conversation) ([]Conversation, *customErrors.CodedError) {
+	cArr := make([]Conversation, len(conversations))
+	if len(conversations) == 0 {
+		return cArr, nil
+	}
+
+	ids := make([]uuid.UUID, len(conversations))
+	for i, c := range conversations {
+		ids[i] = c.ID
+	}
+
+	participants, errParticipants := cfg.DB.GetConversationParticipants(ctx, ids)
+	if errParticipants != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to get participants: %w, function: %s",
+				errParticipants,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	lastMessages, errLast := cfg.DB.GetLastMessages(ctx, ids)
+	if errLast != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to get last messages: %w, function: %s",
+				errLast,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	unreadPars := database.GetUnreadMessageCountsParams{
+		UserID: userId,
+		ConversationIds: ids,
+	}
+
+	unreadCounts, errUnread := cfg.DB.GetUnreadMessageCounts(ctx, unreadPars)
+	if errUnread != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to count unread messages: %w, function: %s",
+				errUnread,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	members := map[uuid.UUID][]uuid.UUID{}
+	for _, p := range participants {
+		members[p.ConversationID] = append(members[p.ConversationID], p.UserID)
+	}
+
+	last := map[uuid.UUID]*Message{}
+	for _, m := range lastMessages {
+		msg := Message{}
+		msg.mapMessage(&m)
+		last[m.ConversationID] = &msg
+	}
+
+	unread := map[uuid.UUID]int64{}
+	for _, u := range unreadCounts {
+		unread[u.ConversationID] = u.UnreadCount
+	}
+
+	for i, c := range conversations {
+		cArr[i].mapConversation(&c)
+		cArr[i].Participants = members[c.ID]
+		cArr[i].LastMessage = last[c.ID]
+		cArr[i].UnreadCount = unread[c.ID]
+	}
+
+	return cArr, nil
+}
+
+func (cfg *apiConfig) respondConversation(w *http.ResponseWriter, r *http.Request, userId uuid.UUID, conversation *database.Conversation, code int) {
+	cArr, errDecorate := cfg.decorateConversations(r.Context(), userId, []database.Conversation{*conversation})
+	if errDecorate != nil {
+		respondWithError(w, errDecorate)
+		return
+	}
+
+	respSuccesfullConversationPost(w, &cArr[0], code)
+}
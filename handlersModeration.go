@@ -0,0 +1,479 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+	"github.com/niccolot/Chirpy/internal/moderation"
+)
+
+
+func getWordListsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getWordListsHandler := func(w http.ResponseWriter, r *http.Request) {
+		lists, errLists := cfg.DB.GetWordLists(r.Context())
+		if errLists != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get word lists: %w, function: %s",
+					errLists,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		entries, errEntries := cfg.DB.GetWordListEntries(r.Context())
+		if errEntries != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get word list entries: %w, function: %s",
+					errEntries,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		wlArr := make([]WordList, len(lists))
+		for i, l := range lists {
+			wlArr[i].mapWordList(&l, entries)
+		}
+
+		respSuccesfullWordListsGet(&w, wlArr)
+	}
+
+	return getWordListsHandler
+}
+
+func postWordListHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postWordListHandler := func(w http.ResponseWriter, r *http.Request) {
+		decoder := json.NewDecoder(r.Body)
+		req := wordListPostRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		action, ok := moderation.ParseAction(req.Action)
+		if !ok || strings.TrimSpace(req.Name) == "" {
+			e := customErrors.CodedError{
+				Message: "word list needs a name and one of the actions: censor, reject, flag",
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		listPars := database.CreateWordListParams{
+			Name: strings.TrimSpace(req.Name),
+			Action: string(action),
+		}
+
+		list, errList := cfg.DB.CreateWordList(r.Context(), listPars)
+		if errList != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to create word list: %w, function: %s",
+					errList,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		errAdd := addWordListWords(r, cfg, list.ID, req.Words)
+		if errAdd != nil {
+			respondWithError(&w, errAdd)
+			return
+		}
+
+		wl, errResp := wordListResponse(r, cfg, &list)
+		if errResp != nil {
+			respondWithError(&w, errResp)
+			return
+		}
+
+		cfg.recordAudit(r, auditEvent{
+			Action: auditWordListCreated,
+			ActorId: userIdFromContext(r.Context()),
+			TargetType: "wordlist",
+			TargetId: list.ID.String(),
+			Metadata: map[string]any{"name": list.Name, "action": list.Action, "words": req.Words},
+		})
+
+		respSuccesfullWordListPost(&w, wl)
+	}
+
+	return postWordListHandler
+}
+
+func putWordListHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	putWordListHandler := func(w http.ResponseWriter, r *http.Request) {
+		list, errList := wordListFromPath(r, cfg)
+		if errList != nil {
+			respondWithError(&w, errList)
+			return
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		req := wordListPutRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		action, ok := moderation.ParseAction(req.Action)
+		if !ok {
+			e := customErrors.CodedError{
+				Message: "action must be one of: censor, reject, flag",
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		updatePars := database.UpdateWordListActionParams{
+			ID: list.ID,
+			Action: string(action),
+		}
+
+		errUpdate := cfg.DB.UpdateWordListAction(r.Context(), updatePars)
+		if errUpdate != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to update word list: %w, function: %s",
+					errUpdate,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		updated, errFind := cfg.DB.GetWordList(r.Context(), list.ID)
+		if errFind != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to retrieve updated word list: %w, function: %s",
+					errFind,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		errReload := cfg.reloadWordLists(r.Context())
+		if errReload != nil {
+			respondWithError(&w, errReload)
+			return
+		}
+
+		wl, errResp := wordListResponse(r, cfg, &updated)
+		if errResp != nil {
+			respondWithError(&w, errResp)
+			return
+		}
+
+		cfg.recordAudit(r, auditEvent{
+			Action: auditWordListUpdated,
+			ActorId: userIdFromContext(r.Context()),
+			TargetType: "wordlist",
+			TargetId: list.ID.String(),
+			Metadata: map[string]any{"old_action": list.Action, "new_action": updated.Action},
+		})
+
+		respSuccesfullWordListPut(&w, wl)
+	}
+
+	return putWordListHandler
+}
+
+func deleteWordListHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	deleteWordListHandler := func(w http.ResponseWriter, r *http.Request) {
+		list, errList := wordListFromPath(r, cfg)
+		if errList != nil {
+			respondWithError(&w, errList)
+			return
+		}
+
+		errDelete := cfg.DB.DeleteWordList(r.Context(), list.ID)
+		if errDelete != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to delete word list: %w, function: %s",
+					errDelete,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		errReload := cfg.reloadWordLists(r.Context())
+		if errReload != nil {
+			respondWithError(&w, errReload)
+			return
+		}
+
+		cfg.recordAudit(r, auditEvent{
+			Action: auditWordListDeleted,
+			ActorId: userIdFromContext(r.Context()),
+			TargetType: "wordlist",
+			TargetId: list.ID.String(),
+			Metadata: map[string]any{"name": list.Name},
+		})
+
+		respNoContent(&w)
+	}
+
+	return deleteWordListHandler
+}
+
+func postWordListWordsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postWordListWordsHandler := func(w http.ResponseWriter, r *http.Request) {
+		list, errList := wordListFromPath(r, cfg)
+		if errList != nil {
+			respondWithError(&w, errList)
+			return
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		req := wordListWordsPostRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		errAdd := addWordListWords(r, cfg, list.ID, req.Words)
+		if errAdd != nil {
+			respondWithError(&w, errAdd)
+			return
+		}
+
+		wl, errResp := wordListResponse(r, cfg, &list)
+		if errResp != nil {
+			respondWithError(&w, errResp)
+			return
+		}
+
+		cfg.recordAudit(r, auditEvent{
+			Action: auditWordListWordsAdded,
+			ActorId: userIdFromContext(r.Context()),
+			TargetType: "wordlist",
+			TargetId: list.ID.String(),
+			Metadata: map[string]any{"words": req.Words},
+		})
+
+		respSuccesfullWordListPut(&w, wl)
+	}
+
+	return postWordListWordsHandler
+}
+
+func deleteWordListWordHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	deleteWordListWordHandler := func(w http.ResponseWriter, r *http.Request) {
+		list, errList := wordListFromPath(r, cfg)
+		if errList != nil {
+			respondWithError(&w, errList)
+			return
+		}
+
+		deletePars := database.DeleteWordListEntryParams{
+			ListID: list.ID,
+			Word: strings.ToLower(strings.TrimSpace(r.PathValue("word"))),
+		}
+
+		errDelete := cfg.DB.DeleteWordListEntry(r.Context(), deletePars)
+		if errDelete != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to delete word: %w, function: %s",
+					errDelete,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		errReload := cfg.reloadWordLists(r.Context())
+		if errReload != nil {
+			respondWithError(&w, errReload)
+			return
+		}
+
+		cfg.recordAudit(r, auditEvent{
+			Action: auditWordListWordRemoved,
+			ActorId: userIdFromContext(r.Context()),
+			TargetType: "wordlist",
+			TargetId: list.ID.String(),
+			Metadata: map[string]any{"word": deletePars.Word},
+		})
+
+		respNoContent(&w)
+	}
+
+	return deleteWordListWordHandler
+}
+
+func getFlaggedChirpsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getFlaggedChirpsHandler := func(w http.ResponseWriter, r *http.Request) {
+		chirpsArr, errChirps := cfg.DB.GetChirpsNeedingReview(r.Context())
+		if errChirps != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get flagged chirps: %w, function: %s",
+					errChirps,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		// decorated as an anonymous moderator so that neither the blocks
+		// of the moderator nor the visibility hide chirps waiting for review
+		cArr, errDecorate := cfg.decorateChirpsAs(r.Context(), uuid.Nil, chirpsArr, mutedWordEverywhere, true)
+		if errDecorate != nil {
+			respondWithError(&w, errDecorate)
+			return
+		}
+
+		respSuccesfullFlaggedChirpsGet(&w, cArr)
+	}
+
+	return getFlaggedChirpsHandler
+}
+
+func postApproveChirpHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postApproveChirpHandler := func(w http.ResponseWriter, r *http.Request) {
+		chirpUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		errClear := cfg.DB.ClearChirpReview(r.Context(), chirpUUID)
+		if errClear != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to approve chirp: %w, function: %s",
+					errClear,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		cfg.recordAudit(r, auditEvent{
+			Action: auditChirpApproved,
+			ActorId: userIdFromContext(r.Context()),
+			TargetType: "chirp",
+			TargetId: chirpUUID.String(),
+		})
+
+		respNoContent(&w)
+	}
+
+	return postApproveChirpHandler
+}
+
+func wordListFromPath(r *http.Request, cfg *apiConfig) (database.WordList, *customErrors.CodedError) {
+	listUUID, errUUID := uuid.Parse(r.PathValue("id"))
+	if errUUID != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+				errUUID,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+		return database.WordList{}, &e
+	}
+
+	list, errList := cfg.DB.GetWordList(r.Context(), listUUID)
+	if errList != nil {
+		e := customErrors.CodedError{
+			Message: "word list not found",
+			StatusCode: http.StatusNotFound,
+		}
+		return database.WordList{}, &e
+	}
+
+	return list, nil
+}
+
+// addWordListWords stores the words lower cased and reloads the
+// filter so the new entries apply to the next chirp
+func addWordListWords(r *http.Request, cfg *apiConfig, listId uuid.UUID, words []string) *customErrors.CodedError {
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word == "" {
+			continue
+		}
+
+		entryPars := database.AddWordListEntryParams{
+			ListID: listId,
+			Word: word,
+		}
+
+		errAdd := cfg.DB.AddWordListEntry(r.Context(), entryPars)
+		if errAdd != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to add word %s: %w, function: %s",
+					word,
+					errAdd,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+	}
+
+	return cfg.reloadWordLists(r.Context())
+}
+
+func wordListResponse(r *http.Request, cfg *apiConfig, list *database.WordList) (*WordList, *customErrors.CodedError) {
+	entries, errEntries := cfg.DB.GetWordListEntries(r.Context())
+	if errEntries != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to get word list entries: %w, function: %s",
+				errEntries,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	wl := WordList{}
+	wl.mapWordList(list, entries)
+
+	return &wl, nil
+}
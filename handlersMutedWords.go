@@ -0,0 +1,446 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+	"github.com/niccolot/Chirpy/internal/moderation"
+	"github.com/niccolot/Chirpy/internal/tags"
+)
+
+
+const (
+	mutedWordHome = "home"
+	mutedWordNotifications = "notifications"
+	mutedWordEverywhere = "everywhere"
+)
+
+const (
+	mutedWordHide = "hide"
+	mutedWordWarn = "warn"
+)
+
+const (
+	maxMutedWords = 100
+	maxMutedPhraseLength = 100
+)
+
+var mutedWordScopes = map[string]bool{
+	mutedWordHome: true,
+	mutedWordNotifications: true,
+	mutedWordEverywhere: true,
+}
+
+var mutedWordActions = map[string]bool{
+	mutedWordHide: true,
+	mutedWordWarn: true,
+}
+
+func postMutedWordHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postMutedWordHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		req := mutedWordPostRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		phrase, errPhrase := validateMutedPhrase(req.Phrase)
+		if errPhrase != nil {
+			respondWithError(&w, errPhrase)
+			return
+		}
+
+		if req.Scope == "" {
+			req.Scope = mutedWordEverywhere
+		}
+		if req.Action == "" {
+			req.Action = mutedWordHide
+		}
+
+		if !mutedWordScopes[req.Scope] || !mutedWordActions[req.Action] {
+			e := customErrors.CodedError{
+				Message: "scope must be one of: home, notifications, everywhere and action one of: hide, warn",
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if req.DurationHours < 0 {
+			e := customErrors.CodedError{
+				Message: "a muted word needs a non negative duration",
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		wholeWord := true
+		if req.WholeWord != nil {
+			wholeWord = *req.WholeWord
+		}
+
+		expiresAt := sql.NullTime{}
+		if req.DurationHours > 0 {
+			expiresAt = sql.NullTime{
+				Time: time.Now().UTC().Add(time.Duration(req.DurationHours) * time.Hour),
+				Valid: true,
+			}
+		}
+
+		var mutedWord database.MutedWord
+		errCreate := cfg.withTx(r.Context(), func(q *database.Queries) *customErrors.CodedError {
+			// expired entries are dropped here so that the same phrase
+			// can be muted again
+			errExpired := q.DeleteExpiredMutedWords(r.Context(), userId)
+			if errExpired != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to delete expired muted words: %w, function: %s",
+						errExpired,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				return &e
+			}
+
+			count, errCount := q.CountMutedWords(r.Context(), userId)
+			if errCount != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to count muted words: %w, function: %s",
+						errCount,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				return &e
+			}
+
+			if count >= maxMutedWords {
+				e := customErrors.CodedError{
+					Message: fmt.Sprintf("at most %d words can be muted", maxMutedWords),
+					StatusCode: http.StatusBadRequest,
+				}
+				return &e
+			}
+
+			mutedWordPars := database.CreateMutedWordParams{
+				UserID: userId,
+				Phrase: phrase,
+				Scope: req.Scope,
+				Action: req.Action,
+				WholeWord: wholeWord,
+				ExpiresAt: expiresAt,
+			}
+
+			var errMuted error
+			mutedWord, errMuted = q.CreateMutedWord(r.Context(), mutedWordPars)
+			if errMuted != nil {
+				if isUniqueViolation(errMuted) {
+					e := customErrors.CodedError{
+						Message: "phrase already muted",
+						StatusCode: http.StatusConflict,
+					}
+					return &e
+				}
+
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to mute word: %w, function: %s",
+						errMuted,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				return &e
+			}
+
+			return nil
+		})
+		if errCreate != nil {
+			respondWithError(&w, errCreate)
+			return
+		}
+
+		mw := MutedWord{}
+		mw.mapMutedWord(&mutedWord)
+
+		respSuccesfullMutedWordPost(&w, &mw)
+	}
+
+	return postMutedWordHandler
+}
+
+func getMutedWordsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getMutedWordsHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		mutedWords, errMuted := cfg.DB.GetMutedWords(r.Context(), userId)
+		if errMuted != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get muted words: %w, function: %s",
+					errMuted,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		mwArr := make([]MutedWord, len(mutedWords))
+		for i, mw := range mutedWords {
+			mwArr[i].mapMutedWord(&mw)
+		}
+
+		respSuccesfullMutedWordsGet(&w, mwArr)
+	}
+
+	return getMutedWordsHandler
+}
+
+func deleteMutedWordHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	deleteMutedWordHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		mutedWordUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		deletePars := database.DeleteMutedWordParams{
+			ID: mutedWordUUID,
+			UserID: userId,
+		}
+
+		deleted, errDelete := cfg.DB.DeleteMutedWord(r.Context(), deletePars)
+		if errDelete != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to delete muted word: %w, function: %s",
+					errDelete,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if deleted == 0 {
+			e := customErrors.CodedError{
+				Message: "muted word not found",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		respNoContent(&w)
+	}
+
+	return deleteMutedWordHandler
+}
+
+// validateMutedPhrase trims the phrase, hashtags are stored normalized
+// since they are matched against the tags of the chirps
+func validateMutedPhrase(phrase string) (string, *customErrors.CodedError) {
+	phrase = strings.TrimSpace(phrase)
+	if phrase == "" || len([]rune(phrase)) > maxMutedPhraseLength {
+		e := customErrors.CodedError{
+			Message: fmt.Sprintf("a muted phrase must be 1 to %d characters long", maxMutedPhraseLength),
+			StatusCode: http.StatusBadRequest,
+		}
+		return "", &e
+	}
+
+	if !strings.HasPrefix(phrase, "#") {
+		return phrase, nil
+	}
+
+	tag, ok := tags.Normalize(phrase)
+	if !ok {
+		e := customErrors.CodedError{
+			Message: "invalid tag",
+			StatusCode: http.StatusBadRequest,
+		}
+		return "", &e
+	}
+
+	return "#" + tag, nil
+}
+
+// wordFilter matches texts against the muted words of a user: hashtags
+// against the tags of the text, whole words through a moderation.Matcher
+// with a list per action and the rest as case insensitive substrings
+type wordFilter struct {
+	matcher *moderation.Matcher
+	tags map[string]string
+	partial []database.MutedWord
+}
+
+func newWordFilter(mutedWords []database.MutedWord) *wordFilter {
+	f := wordFilter{
+		tags: map[string]string{},
+	}
+
+	lists := map[string]*moderation.WordList{}
+	for _, mw := range mutedWords {
+		switch {
+		case strings.HasPrefix(mw.Phrase, "#"):
+			tag := strings.TrimPrefix(mw.Phrase, "#")
+			f.tags[tag] = strongerMutedAction(f.tags[tag], mw.Action)
+		case mw.WholeWord:
+			if lists[mw.Action] == nil {
+				lists[mw.Action] = &moderation.WordList{Name: mw.Action}
+			}
+			lists[mw.Action].Words = append(lists[mw.Action].Words, mw.Phrase)
+		default:
+			f.partial = append(f.partial, mw)
+		}
+	}
+
+	wordLists := make([]moderation.WordList, 0, len(lists))
+	for _, l := range lists {
+		wordLists = append(wordLists, *l)
+	}
+	f.matcher = moderation.NewMatcher(wordLists)
+
+	return &f
+}
+
+// match returns the strongest action among the muted words found in text,
+// hide wins over warn, together with the matched phrases. The action is
+// empty when nothing matches
+func (f *wordFilter) match(text string) (string, []string) {
+	action := ""
+	phrases := []string{}
+
+	for _, tag := range tags.Extract(text) {
+		if a, ok := f.tags[tag]; ok {
+			action = strongerMutedAction(action, a)
+			phrases = append(phrases, "#" + tag)
+		}
+	}
+
+	for _, m := range f.matcher.Find(text) {
+		action = strongerMutedAction(action, m.List)
+		phrases = append(phrases, m.Word)
+	}
+
+	lower := strings.ToLower(text)
+	for _, mw := range f.partial {
+		if strings.Contains(lower, strings.ToLower(mw.Phrase)) {
+			action = strongerMutedAction(action, mw.Action)
+			phrases = append(phrases, mw.Phrase)
+		}
+	}
+
+	return action, phrases
+}
+
+func strongerMutedAction(a string, b string) string {
+	if a == mutedWordHide || b == mutedWordHide {
+		return mutedWordHide
+	}
+	if a == mutedWordWarn || b == mutedWordWarn {
+		return mutedWordWarn
+	}
+
+	return ""
+}
+
+// wordFilterFor loads the muted words of userId valid in scope, the ones
+// valid everywhere included. It returns nil when there is nothing to filter
+func (cfg *apiConfig) wordFilterFor(ctx context.Context, userId uuid.UUID, scope string) (*wordFilter, *customErrors.CodedError) {
+	if userId == uuid.Nil {
+		return nil, nil
+	}
+
+	mutedPars := database.GetActiveMutedWordsParams{
+		UserID: userId,
+		Scopes: []string{mutedWordEverywhere, scope},
+	}
+
+	mutedWords, errMuted := cfg.DB.GetActiveMutedWords(ctx, mutedPars)
+	if errMuted != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to get muted words: %w, function: %s",
+				errMuted,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	if len(mutedWords) == 0 {
+		return nil, nil
+	}
+
+	return newWordFilter(mutedWords), nil
+}
+
+// filterChirps applies the muted words of the viewer to decorated chirps:
+// chirps matching a hide entry are dropped and the ones matching a warn
+// entry are marked as filtered. Rechirps are matched on the chirp they
+// repost and the chirps of the viewer are never filtered
+func (cfg *apiConfig) filterChirps(ctx context.Context, viewerId uuid.UUID, chirps []Chirp, scope string) ([]Chirp, *customErrors.CodedError) {
+	f, errFilter := cfg.wordFilterFor(ctx, viewerId, scope)
+	if errFilter != nil || f == nil {
+		return chirps, errFilter
+	}
+
+	kept := make([]Chirp, 0, len(chirps))
+	for _, c := range chirps {
+		target := &c
+		if c.RechirpOf != nil && c.RechirpOf.Chirp != nil {
+			target = c.RechirpOf.Chirp
+		}
+
+		if target.UserId == viewerId {
+			kept = append(kept, c)
+			continue
+		}
+
+		action, phrases := f.match(target.Body)
+		if action == mutedWordHide {
+			continue
+		}
+		if action == mutedWordWarn {
+			c.Filtered = &FilterMatch{Phrases: phrases}
+		}
+		kept = append(kept, c)
+	}
+
+	return kept, nil
+}
@@ -0,0 +1,439 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+	"github.com/niccolot/Chirpy/internal/mentions"
+)
+
+
+const (
+	notificationMention = "mention"
+	notificationReply = "reply"
+	notificationLike = "like"
+	notificationFollow = "follow"
+	notificationRechirp = "rechirp"
+	notificationRedUpgrade = "red_upgrade"
+	notificationPollClosed = "poll_closed"
+)
+
+// number of actors returned with every notification, the rest are counted
+const maxNotificationActors = 3
+
+// notificationEvent is something that happened to UserId, ActorId is
+// uuid.Nil for the events not caused by another user
+type notificationEvent struct {
+	Kind string
+	UserId uuid.UUID
+	ActorId uuid.UUID
+	ChirpId uuid.NullUUID
+}
+
+// groupKey identifies the notification the event is merged into, events
+// of the same kind on the same chirp are grouped while unread. Mentions
+// and replies point to the new chirp, so they are never grouped
+func (e *notificationEvent) groupKey() string {
+	if e.ChirpId.Valid {
+		return e.Kind + ":" + e.ChirpId.UUID.String()
+	}
+
+	return e.Kind
+}
+
+func getNotificationsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getNotificationsHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		p, errPage := pageFromQuery(r)
+		if errPage != nil {
+			respondWithError(&w, errPage)
+			return
+		}
+
+		notificationsPars := database.GetNotificationsParams{
+			UserID: userId,
+			BeforeTime: p.CursorTime,
+			BeforeID: p.CursorId,
+			MaxEntries: p.Limit,
+		}
+
+		notifications, errNotifications := cfg.DB.GetNotifications(r.Context(), notificationsPars)
+		if errNotifications != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get notifications: %w, function: %s",
+					errNotifications,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		ids := make([]uuid.UUID, len(notifications))
+		for i, n := range notifications {
+			ids[i] = n.ID
+		}
+
+		actorsPars := database.GetNotificationActorsParams{
+			NotificationIds: ids,
+			MaxActors: maxNotificationActors,
+		}
+
+		actors, errActors := cfg.DB.GetNotificationActors(r.Context(), actorsPars)
+		if errActors != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get notification actors: %w, function: %s",
+					errActors,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		unread, errUnread := cfg.DB.CountUnreadNotifications(r.Context(), userId)
+		if errUnread != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to count unread notifications: %w, function: %s",
+					errUnread,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		actorIds := map[uuid.UUID][]uuid.UUID{}
+		actorCounts := map[uuid.UUID]int64{}
+		for _, a := range actors {
+			actorIds[a.NotificationID] = append(actorIds[a.NotificationID], a.ActorID)
+			actorCounts[a.NotificationID] = a.Total
+		}
+
+		np := NotificationPage{
+			Notifications: make([]Notification, len(notifications)),
+			UnreadCount: unread,
+		}
+		for i, n := range notifications {
+			np.Notifications[i].mapNotification(&n, actorIds[n.ID], actorCounts[n.ID])
+		}
+
+		if len(notifications) > 0 {
+			last := notifications[len(notifications)-1]
+			np.NextCursor = nextCursor(&p, len(notifications), cursor{Time: last.UpdatedAt, Id: last.ID})
+		}
+
+		respSuccesfullNotificationsGet(&w, &np)
+	}
+
+	return getNotificationsHandler
+}
+
+func postNotificationsReadHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postNotificationsReadHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		// an empty body marks every notification as read
+		decoder := json.NewDecoder(r.Body)
+		req := notificationsReadPostRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil && errDecode != io.EOF {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		var errRead error
+		if len(req.Ids) == 0 {
+			_, errRead = cfg.DB.MarkAllNotificationsRead(r.Context(), userId)
+		} else {
+			readPars := database.MarkNotificationsReadParams{
+				UserID: userId,
+				Ids: req.Ids,
+			}
+			_, errRead = cfg.DB.MarkNotificationsRead(r.Context(), readPars)
+		}
+
+		if errRead != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to mark notifications as read: %w, function: %s",
+					errRead,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		respNoContent(&w)
+	}
+
+	return postNotificationsReadHandler
+}
+
+// notify delivers the events, a failure is only logged since the action
+// that caused the notification already succeeded
+func (cfg *apiConfig) notify(r *http.Request, events ...notificationEvent) {
+	cfg.deliver(context.WithoutCancel(r.Context()), events...)
+}
+
+// deliver is notify for the events raised outside of a request
+func (cfg *apiConfig) deliver(ctx context.Context, events ...notificationEvent) {
+	for _, event := range events {
+		if event.ActorId == event.UserId {
+			continue
+		}
+
+		silenced, errSilenced := cfg.silenced(ctx, &event)
+		if errSilenced != nil {
+			log.Printf("notification %s for user %s: %s", event.Kind, event.UserId, errSilenced.Message)
+			continue
+		}
+		if silenced {
+			continue
+		}
+
+		errNotify := cfg.withTx(ctx, func(q *database.Queries) *customErrors.CodedError {
+			return addNotification(ctx, q, &event)
+		})
+		if errNotify != nil {
+			log.Printf("notification %s for user %s: %s", event.Kind, event.UserId, errNotify.Message)
+		}
+	}
+}
+
+// silenced tells if the user of the event blocked, was blocked by or muted
+// the actor, if the user can not read the chirp of the event or if it
+// contains a word muted by the user for notifications. The event is then
+// dropped without the actor knowing
+func (cfg *apiConfig) silenced(ctx context.Context, event *notificationEvent) (bool, *customErrors.CodedError) {
+	if event.ActorId == uuid.Nil {
+		return false, nil
+	}
+
+	actors := []uuid.UUID{event.ActorId}
+	blocked, errBlocked := blockedAmong(ctx, cfg.DB, event.UserId, actors)
+	if errBlocked != nil {
+		return false, errBlocked
+	}
+
+	muted, errMuted := mutedAmong(ctx, cfg.DB, event.UserId, actors)
+	if errMuted != nil {
+		return false, errMuted
+	}
+
+	if blocked[event.ActorId] || muted[event.ActorId] || !event.ChirpId.Valid {
+		return blocked[event.ActorId] || muted[event.ActorId], nil
+	}
+
+	chirp, errChirp := cfg.DB.GetChirp(ctx, event.ChirpId.UUID)
+	if errChirp != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to get notified chirp: %w, function: %s",
+				errChirp,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return false, &e
+	}
+
+	// e.g. a followers only reply to somebody not following the author
+	readable, errRead := canRead(ctx, cfg.DB, event.UserId, &chirp)
+	if errRead != nil || !readable {
+		return !readable, errRead
+	}
+
+	f, errFilter := cfg.wordFilterFor(ctx, event.UserId, mutedWordNotifications)
+	if errFilter != nil || f == nil {
+		return false, errFilter
+	}
+
+	// likes and rechirps point to the chirp of the user, which is never filtered
+	if chirp.UserID == event.UserId {
+		return false, nil
+	}
+
+	action, _ := f.match(chirp.Body)
+	return action != "", nil
+}
+
+func addNotification(ctx context.Context, q *database.Queries, event *notificationEvent) *customErrors.CodedError {
+	upsertPars := database.UpsertNotificationParams{
+		UserID: event.UserId,
+		Kind: event.Kind,
+		ChirpID: event.ChirpId,
+		GroupKey: event.groupKey(),
+	}
+
+	notificationId, errUpsert := q.UpsertNotification(ctx, upsertPars)
+	if errUpsert != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to create notification: %w, function: %s",
+				errUpsert,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	if event.ActorId == uuid.Nil {
+		return nil
+	}
+
+	actorPars := database.AddNotificationActorParams{
+		NotificationID: notificationId,
+		ActorID: event.ActorId,
+	}
+
+	errActor := q.AddNotificationActor(ctx, actorPars)
+	if errActor != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to add notification actor: %w, function: %s",
+				errActor,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	return nil
+}
+
+// mentionChirp stores the users mentioned in body, which may be the new body
+// of an edited chirp, returning the ones that were not mentioned before.
+// Handles that do not belong to anybody are ignored
+func mentionChirp(ctx context.Context, q *database.Queries, chirp *database.Chirp, body string) ([]uuid.UUID, *customErrors.CodedError) {
+	userIds := []uuid.UUID{}
+	handles := mentions.Extract(body)
+	if len(handles) > 0 {
+		users, errUsers := q.GetUsersByHandles(ctx, handles)
+		if errUsers != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to resolve mentions: %w, function: %s",
+					errUsers,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return nil, &e
+		}
+
+		ids := make([]uuid.UUID, len(users))
+		for i, u := range users {
+			ids[i] = u.ID
+		}
+
+		// users with a block in place with the author are left as plain text
+		blocked, errBlocked := blockedAmong(ctx, q, chirp.UserID, ids)
+		if errBlocked != nil {
+			return nil, errBlocked
+		}
+
+		for _, id := range ids {
+			if !blocked[id] {
+				userIds = append(userIds, id)
+			}
+		}
+	}
+
+	stalePars := database.DeleteStaleChirpMentionsParams{
+		ChirpID: chirp.ID,
+		UserIds: userIds,
+	}
+
+	errStale := q.DeleteStaleChirpMentions(ctx, stalePars)
+	if errStale != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to delete mentions: %w, function: %s",
+				errStale,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	if len(userIds) == 0 {
+		return nil, nil
+	}
+
+	mentionsPars := database.AddChirpMentionsParams{
+		ChirpID: chirp.ID,
+		UserIds: userIds,
+	}
+
+	added, errAdd := q.AddChirpMentions(ctx, mentionsPars)
+	if errAdd != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to add mentions: %w, function: %s",
+				errAdd,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	return added, nil
+}
+
+// notifyChirp tells the author of the chirp being replied to and the
+// newly mentioned users about chirp, the replied author is not notified
+// twice when the reply also mentions them
+func (cfg *apiConfig) notifyChirp(ctx context.Context, chirp *database.Chirp, mentioned []uuid.UUID) {
+	events := []notificationEvent{}
+
+	if chirp.InReplyTo.Valid {
+		parent, errParent := cfg.DB.GetChirp(ctx, chirp.InReplyTo.UUID)
+		if errParent == nil {
+			events = append(events, notificationEvent{
+				Kind: notificationReply,
+				UserId: parent.UserID,
+				ActorId: chirp.UserID,
+				ChirpId: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			})
+
+			withoutParent := make([]uuid.UUID, 0, len(mentioned))
+			for _, userId := range mentioned {
+				if userId != parent.UserID {
+					withoutParent = append(withoutParent, userId)
+				}
+			}
+			mentioned = withoutParent
+		}
+	}
+
+	cfg.deliver(ctx, append(events, mentionEvents(chirp, mentioned)...)...)
+}
+
+func mentionEvents(chirp *database.Chirp, mentioned []uuid.UUID) []notificationEvent {
+	events := make([]notificationEvent, len(mentioned))
+	for i, userId := range mentioned {
+		events[i] = notificationEvent{
+			Kind: notificationMention,
+			UserId: userId,
+			ActorId: chirp.UserID,
+			ChirpId: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		}
+	}
+
+	return events
+}
@@ -0,0 +1,432 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+)
+
+
+const (
+	minPollOptions = 2
+	maxPollOptions = 4
+	maxPollOptionLength = 25
+	minPollDuration = 5 * time.Minute
+	maxPollDuration = 7 * 24 * time.Hour
+)
+
+const (
+	pollsInterval = time.Minute
+	pollsBatchSize = 100
+)
+
+func postPollVoteHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postPollVoteHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		chirpUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		req := pollVotePostRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		chirp, errChirp := cfg.sharedChirp(r.Context(), userId, chirpUUID)
+		if errChirp != nil {
+			e := customErrors.CodedError{
+				Message: "chirp not found",
+				StatusCode: http.StatusNotFound,
+			}
+			if errChirp != sql.ErrNoRows {
+				e.Message = fmt.Errorf("failed to get chirp: %w, function: %s",
+					errChirp,
+					customErrors.GetFunctionName()).Error()
+				e.StatusCode = http.StatusInternalServerError
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		poll, errPoll := cfg.DB.GetPoll(r.Context(), chirp.ID)
+		if errPoll != nil {
+			e := customErrors.CodedError{
+				Message: "chirp has no poll",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if !poll.ClosesAt.After(time.Now()) {
+			e := customErrors.CodedError{
+				Message: "poll closed",
+				StatusCode: http.StatusConflict,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		// the vote and the tally are written by the same statement, which
+		// does nothing for a second vote, an option of another poll or a
+		// poll that closed in the meantime
+		votePars := database.CastPollVoteParams{
+			UserID: userId,
+			OptionID: req.OptionId,
+			ChirpID: chirp.ID,
+		}
+
+		voted, errVote := cfg.DB.CastPollVote(r.Context(), votePars)
+		if errVote != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to cast vote: %w, function: %s",
+					errVote,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		polls, errPolls := cfg.pollsFor(r.Context(), userId, []uuid.UUID{chirp.ID})
+		if errPolls != nil {
+			respondWithError(&w, errPolls)
+			return
+		}
+
+		p := polls[chirp.ID]
+		if voted == 0 {
+			e := customErrors.CodedError{
+				Message: "invalid option",
+				StatusCode: http.StatusBadRequest,
+			}
+			if p.VotedOption != nil {
+				e.Message = "already voted"
+				e.StatusCode = http.StatusConflict
+			} else if p.Closed {
+				e.Message = "poll closed"
+				e.StatusCode = http.StatusConflict
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		respSuccesfullPollVotePost(&w, p)
+	}
+
+	return postPollVoteHandler
+}
+
+// validatePoll checks the poll posted with a chirp, options are trimmed
+// and must be different from each other ignoring case
+func validatePoll(req *pollRequest, cfg *apiConfig) *customErrors.CodedError {
+	if len(req.Options) < minPollOptions || len(req.Options) > maxPollOptions {
+		e := customErrors.CodedError{
+			Message: fmt.Sprintf("a poll must have %d to %d options", minPollOptions, maxPollOptions),
+			StatusCode: http.StatusBadRequest,
+		}
+		return &e
+	}
+
+	seen := map[string]bool{}
+	for i := range req.Options {
+		req.Options[i] = strings.TrimSpace(req.Options[i])
+		option := req.Options[i]
+		if option == "" || utf8.RuneCountInString(option) > maxPollOptionLength {
+			e := customErrors.CodedError{
+				Message: fmt.Sprintf("poll options must be 1 to %d characters long", maxPollOptionLength),
+				StatusCode: http.StatusBadRequest,
+			}
+			return &e
+		}
+
+		if seen[strings.ToLower(option)] {
+			e := customErrors.CodedError{
+				Message: "poll options must be different",
+				StatusCode: http.StatusBadRequest,
+			}
+			return &e
+		}
+		seen[strings.ToLower(option)] = true
+
+		_, errProfanity := cleanProfanity(&req.Options[i], cfg.Moderation)
+		if errProfanity != nil {
+			return errProfanity
+		}
+	}
+
+	duration := time.Until(req.ClosesAt)
+	if duration < minPollDuration || duration > maxPollDuration {
+		e := customErrors.CodedError{
+			Message: "a poll must close between 5 minutes and 7 days from now",
+			StatusCode: http.StatusBadRequest,
+		}
+		return &e
+	}
+
+	return nil
+}
+
+func createPoll(ctx context.Context, q *database.Queries, chirpId uuid.UUID, req *pollRequest) *customErrors.CodedError {
+	pollPars := database.CreatePollParams{
+		ChirpID: chirpId,
+		// the client can send any offset, the column keeps the instant in UTC
+		ClosesAt: req.ClosesAt.UTC(),
+	}
+
+	errPoll := q.CreatePoll(ctx, pollPars)
+	if errPoll != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to create poll: %w, function: %s",
+				errPoll,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	for i, option := range req.Options {
+		optionPars := database.CreatePollOptionParams{
+			ChirpID: chirpId,
+			Position: int32(i),
+			Text: option,
+		}
+
+		errOption := q.CreatePollOption(ctx, optionPars)
+		if errOption != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to create poll option: %w, function: %s",
+					errOption,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+	}
+
+	return nil
+}
+
+// pollsFor returns the polls of the chirps with the given ids as seen by
+// the viewer, chirps without a poll are not in the map. The results are
+// left out until the viewer voted or the poll closed
+func (cfg *apiConfig) pollsFor(ctx context.Context, viewerId uuid.UUID, chirpIds []uuid.UUID) (map[uuid.UUID]*Poll, *customErrors.CodedError) {
+	polls, errPolls := cfg.DB.GetPolls(ctx, chirpIds)
+	if errPolls != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to get polls: %w, function: %s",
+				errPolls,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	byChirp := make(map[uuid.UUID]*Poll, len(polls))
+	if len(polls) == 0 {
+		return byChirp, nil
+	}
+
+	ids := make([]uuid.UUID, len(polls))
+	for i, p := range polls {
+		ids[i] = p.ChirpID
+	}
+
+	options, errOptions := cfg.DB.GetPollOptions(ctx, ids)
+	if errOptions != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to get poll options: %w, function: %s",
+				errOptions,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	votes := map[uuid.UUID]uuid.UUID{}
+	if viewerId != uuid.Nil {
+		votesPars := database.GetPollVotesAmongParams{
+			UserID: viewerId,
+			ChirpIds: ids,
+		}
+
+		rows, errVotes := cfg.DB.GetPollVotesAmong(ctx, votesPars)
+		if errVotes != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get poll votes: %w, function: %s",
+					errVotes,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return nil, &e
+		}
+
+		for _, row := range rows {
+			votes[row.ChirpID] = row.OptionID
+		}
+	}
+
+	now := time.Now()
+	for _, p := range polls {
+		poll := Poll{
+			ClosesAt: p.ClosesAt,
+			Closed: !p.ClosesAt.After(now),
+			Options: []PollOption{},
+		}
+		if option, ok := votes[p.ChirpID]; ok {
+			poll.VotedOption = &option
+		}
+		byChirp[p.ChirpID] = &poll
+	}
+
+	for _, o := range options {
+		poll := byChirp[o.ChirpID]
+		option := PollOption{
+			Id: o.ID,
+			Text: o.Text,
+		}
+
+		if poll.Closed || poll.VotedOption != nil {
+			count := o.VoteCount
+			option.Votes = &count
+			if poll.TotalVotes == nil {
+				poll.TotalVotes = new(int64)
+			}
+			*poll.TotalVotes += count
+		}
+
+		poll.Options = append(poll.Options, option)
+	}
+
+	return byChirp, nil
+}
+
+// finalizePolls marks the polls that closed as finalized and tells their
+// voters and authors. A poll is finalized before the notifications are
+// sent, so a failure loses them instead of sending them twice
+func (cfg *apiConfig) finalizePolls(ctx context.Context) *customErrors.CodedError {
+	for {
+		closed, errClosed := cfg.DB.FinalizeClosedPolls(ctx, pollsBatchSize)
+		if errClosed != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to finalize polls: %w, function: %s",
+					errClosed,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+
+		for _, chirpId := range closed {
+			errNotify := cfg.notifyPollClosed(ctx, chirpId)
+			if errNotify != nil {
+				log.Printf("poll %s: %s", chirpId, errNotify.Message)
+			}
+		}
+
+		if len(closed) < pollsBatchSize {
+			return nil
+		}
+	}
+}
+
+func (cfg *apiConfig) notifyPollClosed(ctx context.Context, chirpId uuid.UUID) *customErrors.CodedError {
+	chirp, errChirp := cfg.DB.GetChirp(ctx, chirpId)
+	if errChirp != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to get poll chirp: %w, function: %s",
+				errChirp,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	if chirp.DeletedAt.Valid {
+		return nil
+	}
+
+	voters, errVoters := cfg.DB.GetPollVoters(ctx, chirpId)
+	if errVoters != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to get poll voters: %w, function: %s",
+				errVoters,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	// the author is notified even without voting, but only once
+	recipients := append(voters, chirp.UserID)
+	for _, userId := range voters {
+		if userId == chirp.UserID {
+			recipients = voters
+			break
+		}
+	}
+
+	events := make([]notificationEvent, 0, len(recipients))
+	for _, userId := range recipients {
+		events = append(events, notificationEvent{
+			Kind: notificationPollClosed,
+			UserId: userId,
+			ChirpId: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		})
+	}
+
+	cfg.deliver(ctx, events...)
+	return nil
+}
+
+// runPollsWorker finalizes the closed polls every pollsInterval until ctx
+// is done, several instances can run at once since claimed polls are skipped
+func (cfg *apiConfig) runPollsWorker(ctx context.Context) {
+	ticker := time.NewTicker(pollsInterval)
+	defer ticker.Stop()
+
+	for {
+		errPolls := cfg.finalizePolls(ctx)
+		if errPolls != nil {
+			log.Printf("polls worker: %s", errPolls.Message)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+	"github.com/niccolot/Chirpy/internal/media"
+)
+
+
+type profileImageSize struct {
+	name string
+	// the longest side, the other one follows the ratio of the image
+	side int
+}
+
+// profileImage describes one of the images of a profile, uploads are
+// cropped to its ratio and stored in each of its sizes
+type profileImage struct {
+	name string
+	ratioWidth int
+	ratioHeight int
+	// from the largest to the smallest
+	sizes []profileImageSize
+	set func(ctx context.Context, q *database.Queries, userId uuid.UUID, key sql.NullString) (sql.NullString, error)
+}
+
+var avatarImage = profileImage{
+	name: "avatar",
+	ratioWidth: 1,
+	ratioHeight: 1,
+	sizes: []profileImageSize{
+		{"large", 400},
+		{"medium", 200},
+		{"small", 48},
+	},
+	set: func(ctx context.Context, q *database.Queries, userId uuid.UUID, key sql.NullString) (sql.NullString, error) {
+		return q.SetUserAvatar(ctx, database.SetUserAvatarParams{ID: userId, AvatarKey: key})
+	},
+}
+
+var bannerImage = profileImage{
+	name: "banner",
+	ratioWidth: 3,
+	ratioHeight: 1,
+	sizes: []profileImageSize{
+		{"large", 1500},
+		{"small", 600},
+	},
+	set: func(ctx context.Context, q *database.Queries, userId uuid.UUID, key sql.NullString) (sql.NullString, error) {
+		return q.SetUserBanner(ctx, database.SetUserBannerParams{ID: userId, BannerKey: key})
+	},
+}
+
+func putAvatarHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	return profileImagePutHandler(cfg, &avatarImage)
+}
+
+func deleteAvatarHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	return profileImageDeleteHandler(cfg, &avatarImage)
+}
+
+func putBannerHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	return profileImagePutHandler(cfg, &bannerImage)
+}
+
+func deleteBannerHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	return profileImageDeleteHandler(cfg, &bannerImage)
+}
+
+func profileImagePutHandler(cfg *apiConfig, kind *profileImage) func(w http.ResponseWriter, r *http.Request) {
+	putProfileImageHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		data, _, errUpload := readImageUpload(w, r, maxMediaSize)
+		if errUpload != nil {
+			respondWithError(&w, errUpload)
+			return
+		}
+
+		img, contentType, errDecode := media.Decode(data)
+		if errDecode != nil {
+			respondWithError(&w, imageError(errDecode))
+			return
+		}
+
+		cropped := media.CropToRatio(img, kind.ratioWidth, kind.ratioHeight)
+		encoded := make([]media.Encoded, len(kind.sizes))
+		for i, size := range kind.sizes {
+			enc, errEncode := media.Encode(media.Fit(cropped, size.side), contentType)
+			if errEncode != nil {
+				respondWithError(&w, imageError(errEncode))
+				return
+			}
+			encoded[i] = enc
+		}
+
+		// named after the content so that the files can be cached forever,
+		// and under the user so that two users uploading the same image do
+		// not share the files
+		sum := sha256.Sum256(encoded[0].Data)
+		key := fmt.Sprintf("%ss/%s/%s%s", kind.name, userId, hex.EncodeToString(sum[:16]), encoded[0].Ext)
+
+		files := map[string]*media.Encoded{}
+		blobKeys := kind.blobKeys(key)
+		for i, size := range kind.sizes {
+			files[blobKeys[size.name]] = &encoded[i]
+		}
+
+		errStore := cfg.putBlobs(r.Context(), files)
+		if errStore != nil {
+			respondWithError(&w, errStore)
+			return
+		}
+
+		oldKey, errSet := kind.set(r.Context(), cfg.DB, userId, sql.NullString{String: key, Valid: true})
+		if errSet != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to set %s: %w, function: %s",
+					kind.name,
+					errSet,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		// uploading the current image again rewrites the same files
+		if oldKey.Valid && oldKey.String != key {
+			cfg.deleteProfileImage(r.Context(), kind, oldKey.String)
+		}
+
+		p, errProfile := cfg.ownProfile(r.Context(), userId)
+		if errProfile != nil {
+			respondWithError(&w, errProfile)
+			return
+		}
+
+		respSuccesfullProfileImagePut(&w, &p)
+	}
+
+	return putProfileImageHandler
+}
+
+func profileImageDeleteHandler(cfg *apiConfig, kind *profileImage) func(w http.ResponseWriter, r *http.Request) {
+	deleteProfileImageHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		oldKey, errSet := kind.set(r.Context(), cfg.DB, userId, sql.NullString{})
+		if errSet != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to remove %s: %w, function: %s",
+					kind.name,
+					errSet,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if oldKey.Valid {
+			cfg.deleteProfileImage(r.Context(), kind, oldKey.String)
+		}
+
+		respNoContent(&w)
+	}
+
+	return deleteProfileImageHandler
+}
+
+// blobKeys returns the names of the files of each size of the image key
+func (kind *profileImage) blobKeys(key string) map[string]string {
+	ext := path.Ext(key)
+	base := strings.TrimSuffix(key, ext)
+
+	keys := map[string]string{}
+	for _, size := range kind.sizes {
+		keys[size.name] = fmt.Sprintf("%s_%s%s", base, size.name, ext)
+	}
+
+	return keys
+}
+
+// urls returns the urls of each size of the image key, nil without an image
+func (kind *profileImage) urls(key sql.NullString) map[string]string {
+	if !key.Valid {
+		return nil
+	}
+
+	urls := map[string]string{}
+	for size, blobKey := range kind.blobKeys(key.String) {
+		urls[size] = mediaFileURL(blobKey)
+	}
+
+	return urls
+}
+
+func (cfg *apiConfig) deleteProfileImage(ctx context.Context, kind *profileImage, key string) {
+	keys := []string{}
+	for _, blobKey := range kind.blobKeys(key) {
+		keys = append(keys, blobKey)
+	}
+
+	cfg.deleteBlobs(context.WithoutCancel(ctx), keys...)
+}
+
+func (cfg *apiConfig) ownProfile(ctx context.Context, userId uuid.UUID) (Profile, *customErrors.CodedError) {
+	user, errUser := cfg.DB.FindUserById(ctx, userId)
+	if errUser != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to retrieve updated user: %w, function: %s",
+				errUser,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return Profile{}, &e
+	}
+
+	u := User{}
+	u.mapUser(&user)
+
+	errCounts := cfg.setFollowCounts(ctx, &u)
+	if errCounts != nil {
+		return Profile{}, errCounts
+	}
+
+	p := Profile{}
+	p.mapProfile(&u)
+
+	return p, nil
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+	"github.com/niccolot/Chirpy/internal/mentions"
+	"github.com/niccolot/Chirpy/internal/moderation"
+)
+
+
+const handleChangeCooldown = 30 * 24 * time.Hour
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength = 160
+	maxLocationLength = 30
+	maxWebsiteLength = 100
+)
+
+// handles that could be mistaken for the service or clash with the routes
+var reservedHandles = map[string]bool{
+	"me": true,
+	"admin": true,
+	"administrator": true,
+	"api": true,
+	"app": true,
+	"chirpy": true,
+	"help": true,
+	"login": true,
+	"logout": true,
+	"moderator": true,
+	"root": true,
+	"settings": true,
+	"support": true,
+	"system": true,
+	"everyone": true,
+	"null": true,
+}
+
+func getProfileHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getProfileHandler := func(w http.ResponseWriter, r *http.Request) {
+		var user database.User
+		var errUser error
+
+		// users without a handle can still be looked up by id
+		key := r.PathValue("handle")
+		if userUUID, errUUID := uuid.Parse(key); errUUID == nil {
+			user, errUser = cfg.DB.FindUserById(r.Context(), userUUID)
+		} else if handle, ok := mentions.Normalize(key); ok {
+			user, errUser = cfg.DB.FindUserByHandle(r.Context(), handle)
+		} else {
+			errUser = sql.ErrNoRows
+		}
+
+		if errUser != nil || user.ID == deletedUserId {
+			e := customErrors.CodedError{
+				Message: "user not found",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		u := User{}
+		u.mapUser(&user)
+
+		errCounts := cfg.setFollowCounts(r.Context(), &u)
+		if errCounts != nil {
+			respondWithError(&w, errCounts)
+			return
+		}
+
+		p := Profile{}
+		p.mapProfile(&u)
+
+		respSuccesfullProfileGet(&w, &p)
+	}
+
+	return getProfileHandler
+}
+
+func patchProfileHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	patchProfileHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		req := profilePatchRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		user, errUser := cfg.DB.FindUserById(r.Context(), userId)
+		if errUser != nil {
+			e := customErrors.CodedError{
+				Message: "user not found",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		profilePars := database.UpdateUserProfileParams{
+			ID: userId,
+			DisplayName: user.DisplayName,
+			Bio: user.Bio,
+			Location: user.Location,
+			Website: user.Website,
+		}
+
+		errFields := validateProfileFields(&req, &profilePars, cfg.Moderation)
+		if errFields != nil {
+			respondWithError(&w, errFields)
+			return
+		}
+
+		newHandle := ""
+		if req.Handle != nil {
+			handle := strings.TrimPrefix(*req.Handle, "@")
+			errHandle := validateHandle(handle)
+			if errHandle != nil {
+				respondWithError(&w, errHandle)
+				return
+			}
+
+			if !user.Handle.Valid || user.Handle.String != handle {
+				newHandle = handle
+			}
+		}
+
+		errUpdate := cfg.withTx(r.Context(), func(q *database.Queries) *customErrors.CodedError {
+			if newHandle != "" {
+				errHandle := setHandle(r, q, &user, newHandle)
+				if errHandle != nil {
+					return errHandle
+				}
+			}
+
+			errProfile := q.UpdateUserProfile(r.Context(), profilePars)
+			if errProfile != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to update profile: %w, function: %s",
+						errProfile,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				return &e
+			}
+
+			return nil
+		})
+		if errUpdate != nil {
+			respondWithError(&w, errUpdate)
+			return
+		}
+
+		if newHandle != "" {
+			cfg.recordAudit(r, auditEvent{
+				Action: auditHandleChanged,
+				ActorId: userId,
+				TargetType: "user",
+				TargetId: userId.String(),
+				Metadata: map[string]any{"old_handle": user.Handle.String, "new_handle": newHandle},
+			})
+		}
+
+		updated, errFind := cfg.DB.FindUserById(r.Context(), userId)
+		if errFind != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to retrieve updated user: %w, function: %s",
+					errFind,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		u := User{}
+		u.mapUser(&updated)
+
+		errCounts := cfg.setFollowCounts(r.Context(), &u)
+		if errCounts != nil {
+			respondWithError(&w, errCounts)
+			return
+		}
+
+		p := Profile{}
+		p.mapProfile(&u)
+
+		respSuccesfullProfilePatch(&w, &p)
+	}
+
+	return patchProfileHandler
+}
+
+func validateHandle(handle string) *customErrors.CodedError {
+	normalized, ok := mentions.Normalize(handle)
+	if !ok {
+		e := customErrors.CodedError{
+			Message: fmt.Sprintf("a handle must be 1 to %d letters, digits or underscores", mentions.MaxHandleLength),
+			StatusCode: http.StatusBadRequest,
+		}
+		return &e
+	}
+
+	if reservedHandles[normalized] {
+		e := customErrors.CodedError{
+			Message: "handle is reserved",
+			StatusCode: http.StatusBadRequest,
+		}
+		return &e
+	}
+
+	return nil
+}
+
+// validateProfileFields copies the fields set in req into pars, the free
+// text ones go through the word lists like chirps do
+func validateProfileFields(req *profilePatchRequest, pars *database.UpdateUserProfileParams, filter *moderation.Filter) *customErrors.CodedError {
+	fields := []struct {
+		name string
+		value *string
+		dest *string
+		maxLength int
+	}{
+		{"display_name", req.DisplayName, &pars.DisplayName, maxDisplayNameLength},
+		{"bio", req.Bio, &pars.Bio, maxBioLength},
+		{"location", req.Location, &pars.Location, maxLocationLength},
+	}
+
+	for _, f := range fields {
+		if f.value == nil {
+			continue
+		}
+
+		text := strings.TrimSpace(*f.value)
+		if utf8.RuneCountInString(text) > f.maxLength {
+			e := customErrors.CodedError{
+				Message: fmt.Sprintf("%s can be at most %d characters long", f.name, f.maxLength),
+				StatusCode: http.StatusBadRequest,
+			}
+			return &e
+		}
+
+		_, errProfanity := cleanProfanity(&text, filter)
+		if errProfanity != nil {
+			return errProfanity
+		}
+
+		*f.dest = text
+	}
+
+	if req.Website != nil {
+		website := strings.TrimSpace(*req.Website)
+		if website != "" {
+			parsed, errParse := url.Parse(website)
+			if errParse != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || len(website) > maxWebsiteLength {
+				e := customErrors.CodedError{
+					Message: fmt.Sprintf("website must be an http or https url of at most %d characters", maxWebsiteLength),
+					StatusCode: http.StatusBadRequest,
+				}
+				return &e
+			}
+		}
+		pars.Website = website
+	}
+
+	return nil
+}
+
+// setHandle changes the handle of user unless it was changed less than
+// handleChangeCooldown ago, the check is done by the update itself so that
+// two concurrent changes can not both pass it
+func setHandle(r *http.Request, q *database.Queries, user *database.User, handle string) *customErrors.CodedError {
+	handlePars := database.SetUserHandleParams{
+		Handle: sql.NullString{String: handle, Valid: true},
+		ID: user.ID,
+		CooldownStart: time.Now().UTC().Add(-handleChangeCooldown),
+	}
+
+	updated, errHandle := q.SetUserHandle(r.Context(), handlePars)
+	if errHandle != nil {
+		if isUniqueViolation(errHandle) {
+			e := customErrors.CodedError{
+				Message: "handle already taken",
+				StatusCode: http.StatusConflict,
+			}
+			return &e
+		}
+
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to set handle: %w, function: %s",
+				errHandle,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	if updated == 0 {
+		e := customErrors.CodedError{
+			Message: fmt.Sprintf("handle can be changed again after %s",
+				user.HandleChangedAt.Time.Add(handleChangeCooldown).UTC().Format(time.RFC3339)),
+			StatusCode: http.StatusTooManyRequests,
+		}
+		return &e
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+)
+
+
+func postRechirpHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postRechirpHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		chirpUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		original, errOriginal := cfg.sharedChirp(r.Context(), userId, chirpUUID)
+		if errOriginal == sql.ErrNoRows {
+			e := customErrors.CodedError{
+				Message: "chirp not found",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if errOriginal != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get chirp: %w, function: %s",
+					errOriginal,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		// a rechirp would show the chirp to the followers of the rechirper
+		if original.Visibility != visibilityPublic {
+			e := customErrors.CodedError{
+				Message: "only public chirps can be rechirped",
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		rechirpPars := database.CreateRechirpParams{
+			UserID: userId,
+			RechirpOf: uuid.NullUUID{UUID: original.ID, Valid: true},
+		}
+
+		rechirp, errRechirp := cfg.DB.CreateRechirp(r.Context(), rechirpPars)
+		if errRechirp != nil {
+			if isUniqueViolation(errRechirp) {
+				e := customErrors.CodedError{
+					Message: "chirp already rechirped",
+					StatusCode: http.StatusConflict,
+				}
+				respondWithError(&w, &e)
+				return
+			}
+
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to rechirp: %w, function: %s",
+					errRechirp,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		cfg.notify(r, notificationEvent{
+			Kind: notificationRechirp,
+			UserId: original.UserID,
+			ActorId: userId,
+			ChirpId: uuid.NullUUID{UUID: original.ID, Valid: true},
+		})
+
+		// the rechirp is already stored, its fan out goes on when the client
+		// goes away as it does for a new chirp
+		errFanOut := cfg.fanOutChirp(context.WithoutCancel(r.Context()), &rechirp)
+		if errFanOut != nil {
+			log.Printf("fan out of rechirp %s: %s", rechirp.ID, errFanOut.Message)
+		}
+
+		c, errDecorate := cfg.decorateChirp(r.Context(), userId, &rechirp)
+		if errDecorate != nil {
+			respondWithError(&w, errDecorate)
+			return
+		}
+
+		respSuccesfullChirpPost(&w, c)
+	}
+
+	return postRechirpHandler
+}
+
+func deleteRechirpHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	deleteRechirpHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		chirpUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		deletePars := database.DeleteRechirpParams{
+			UserID: userId,
+			RechirpOf: uuid.NullUUID{UUID: chirpUUID, Valid: true},
+		}
+
+		deleted, errDelete := cfg.DB.DeleteRechirp(r.Context(), deletePars)
+		if errDelete != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to delete rechirp: %w, function: %s",
+					errDelete,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if deleted == 0 {
+			e := customErrors.CodedError{
+				Message: "chirp not rechirped",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		respNoContent(&w)
+	}
+
+	return deleteRechirpHandler
+}
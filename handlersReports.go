@@ -0,0 +1,427 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+)
+
+
+var reportReasons = map[string]bool{
+	"spam": true,
+	"harassment": true,
+	"hate_speech": true,
+	"violence": true,
+	"sexual_content": true,
+	"misinformation": true,
+	"self_harm": true,
+	"other": true,
+}
+
+var reportStatuses = map[string]bool{
+	"pending": true,
+	"dismissed": true,
+	"chirp_deleted": true,
+	"author_suspended": true,
+}
+
+var reportActions = map[string]bool{
+	"dismiss": true,
+	"delete_chirp": true,
+	"suspend_author": true,
+}
+
+func postReportHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postReportHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		chirpUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		req := reportPostRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if !reportReasons[req.Reason] {
+			e := customErrors.CodedError{
+				Message: "invalid report reason",
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		chirp, errChirp := cfg.DB.GetChirp(r.Context(), chirpUUID)
+		if errChirp != nil || chirp.DeletedAt.Valid {
+			e := customErrors.CodedError{
+				Message: "chirp not found",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		readable, errRead := canRead(r.Context(), cfg.DB, userId, &chirp)
+		if errRead != nil {
+			respondWithError(&w, errRead)
+			return
+		}
+
+		if !readable {
+			e := customErrors.CodedError{
+				Message: "chirp not found",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if chirp.UserID == userId {
+			e := customErrors.CodedError{
+				Message: "cannot report your own chirp",
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		reportPars := database.CreateReportParams{
+			ChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			ChirpAuthorID: chirp.UserID,
+			ChirpBody: chirp.Body,
+			ReporterID: userId,
+			Reason: req.Reason,
+			Details: req.Details,
+		}
+
+		report, errReport := cfg.DB.CreateReport(r.Context(), reportPars)
+		if errReport != nil {
+			if isUniqueViolation(errReport) {
+				e := customErrors.CodedError{
+					Message: "chirp already reported",
+					StatusCode: http.StatusConflict,
+				}
+				respondWithError(&w, &e)
+				return
+			}
+
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to create report: %w, function: %s",
+					errReport,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		own := OwnReport{}
+		own.mapOwnReport(&report)
+
+		respSuccesfullReportPost(&w, &own)
+	}
+
+	return postReportHandler
+}
+
+func getOwnReportsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getOwnReportsHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		reports, errReports := cfg.DB.GetReportsByReporter(r.Context(), userId)
+		if errReports != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get reports: %w, function: %s",
+					errReports,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		ownArr := make([]OwnReport, len(reports))
+		for i, rep := range reports {
+			ownArr[i].mapOwnReport(&rep)
+		}
+
+		respSuccesfullOwnReportsGet(&w, ownArr)
+	}
+
+	return getOwnReportsHandler
+}
+
+func getReportsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getReportsHandler := func(w http.ResponseWriter, r *http.Request) {
+		status := r.URL.Query().Get("status")
+		if status == "" {
+			status = "pending"
+		}
+
+		if !reportStatuses[status] {
+			e := customErrors.CodedError{
+				Message: "invalid report status",
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		reports, errReports := cfg.DB.GetReportsByStatus(r.Context(), status)
+		if errReports != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get reports: %w, function: %s",
+					errReports,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		repArr := make([]Report, len(reports))
+		for i, rep := range reports {
+			repArr[i].mapReport(&rep)
+		}
+
+		respSuccesfullReportsGet(&w, repArr)
+	}
+
+	return getReportsHandler
+}
+
+func postResolveReportHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postResolveReportHandler := func(w http.ResponseWriter, r *http.Request) {
+		moderatorId := userIdFromContext(r.Context())
+
+		reportUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		req := reportResolveRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if !reportActions[req.Action] {
+			e := customErrors.CodedError{
+				Message: "action must be one of: dismiss, delete_chirp, suspend_author",
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		note := sql.NullString{String: req.Note, Valid: req.Note != ""}
+		resolvedBy := uuid.NullUUID{UUID: moderatorId, Valid: true}
+
+		// the report stays locked until it is resolved, a moderator acting
+		// on it at the same time waits and then finds it resolved
+		var report database.Report
+		errResolve := cfg.withTx(r.Context(), func(q *database.Queries) *customErrors.CodedError {
+			var errLock *customErrors.CodedError
+			report, errLock = lockPendingReport(r.Context(), q, reportUUID)
+			if errLock != nil {
+				return errLock
+			}
+
+			switch req.Action {
+			case "delete_chirp":
+				errRes := resolveReports(r, q, &report, "chirp_deleted", note, resolvedBy)
+				if errRes != nil || !report.ChirpID.Valid {
+					return errRes
+				}
+
+				chirp, errChirp := q.GetChirp(r.Context(), report.ChirpID.UUID)
+				if errChirp != nil {
+					e := customErrors.CodedError{
+						Message: fmt.Errorf("failed to get reported chirp: %w, function: %s",
+							errChirp,
+							customErrors.GetFunctionName()).Error(),
+						StatusCode: http.StatusInternalServerError,
+					}
+					return &e
+				}
+
+				return deleteChirp(r.Context(), q, &chirp)
+			case "suspend_author":
+				reason := req.Note
+				if reason == "" {
+					reason = "reported for " + report.Reason
+				}
+
+				author, errAuthor := q.FindUserById(r.Context(), report.ChirpAuthorID)
+				if errAuthor != nil {
+					e := customErrors.CodedError{
+						Message: "author not found",
+						StatusCode: http.StatusNotFound,
+					}
+					return &e
+				}
+
+				_, errSuspend := suspendUser(r.Context(), q, &author, moderatorId, reason, req.SuspendHours)
+				if errSuspend != nil {
+					return errSuspend
+				}
+
+				return resolveReports(r, q, &report, "author_suspended", note, resolvedBy)
+			default:
+				return resolveReports(r, q, &report, "dismissed", note, resolvedBy)
+			}
+		})
+		if errResolve != nil {
+			respondWithError(&w, errResolve)
+			return
+		}
+
+		resolved, errFind := cfg.DB.GetReport(r.Context(), report.ID)
+		if errFind != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to retrieve resolved report: %w, function: %s",
+					errFind,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		cfg.recordAudit(r, auditEvent{
+			Action: auditReportResolved,
+			ActorId: moderatorId,
+			TargetType: "report",
+			TargetId: report.ID.String(),
+			Metadata: map[string]any{"action": req.Action, "status": resolved.Status, "chirp_author_id": report.ChirpAuthorID},
+		})
+
+		rep := Report{}
+		rep.mapReport(&resolved)
+
+		respSuccesfullReportResolve(&w, &rep)
+	}
+
+	return postResolveReportHandler
+}
+
+// lockPendingReport locks the report id for the rest of the transaction,
+// answering 409 when it was already resolved. The other pending reports on
+// the same chirp are resolved with it, they are all locked in the order of
+// their ids so that two moderators resolving two of them can not deadlock
+func lockPendingReport(ctx context.Context, q *database.Queries, id uuid.UUID) (database.Report, *customErrors.CodedError) {
+	reports, errLock := q.LockPendingReports(ctx, id)
+	if errLock != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to lock report: %w, function: %s",
+				errLock,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return database.Report{}, &e
+	}
+
+	for _, report := range reports {
+		if report.ID == id {
+			return report, nil
+		}
+	}
+
+	_, errReport := q.GetReport(ctx, id)
+	if errReport != nil {
+		e := customErrors.CodedError{
+			Message: "report not found",
+			StatusCode: http.StatusNotFound,
+		}
+		return database.Report{}, &e
+	}
+
+	e := customErrors.CodedError{
+		Message: "report already resolved",
+		StatusCode: http.StatusConflict,
+	}
+	return database.Report{}, &e
+}
+
+// resolveReports closes the report, together with every other pending
+// report on the same chirp since they share the same outcome
+func resolveReports(r *http.Request, q *database.Queries, report *database.Report, status string, note sql.NullString, resolvedBy uuid.NullUUID) *customErrors.CodedError {
+	var errResolve error
+	if report.ChirpID.Valid {
+		resolvePars := database.ResolvePendingReportsForChirpParams{
+			ChirpID: report.ChirpID,
+			Status: status,
+			ResolutionNote: note,
+			ResolvedBy: resolvedBy,
+		}
+		errResolve = q.ResolvePendingReportsForChirp(r.Context(), resolvePars)
+	} else {
+		resolvePars := database.ResolveReportParams{
+			ID: report.ID,
+			Status: status,
+			ResolutionNote: note,
+			ResolvedBy: resolvedBy,
+		}
+		errResolve = q.ResolveReport(r.Context(), resolvePars)
+	}
+
+	if errResolve != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to resolve report: %w, function: %s",
+				errResolve,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	return nil
+}
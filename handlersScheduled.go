@@ -0,0 +1,601 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+	"github.com/niccolot/Chirpy/internal/spam"
+)
+
+
+const (
+	scheduledStatusDraft = "draft"
+	scheduledStatusScheduled = "scheduled"
+)
+
+const (
+	maxScheduledChirps = 100
+	maxScheduleAhead = 90 * 24 * time.Hour
+)
+
+const (
+	schedulerInterval = 30 * time.Second
+	// publications failing on an error of the server are retried after
+	// twice the wait of the previous attempt, then given up
+	maxScheduledAttempts = 5
+)
+
+func postScheduledChirpHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postScheduledChirpHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		req := scheduledChirpRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		count, errCount := cfg.DB.CountScheduledChirps(r.Context(), userId)
+		if errCount != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to count scheduled chirps: %w, function: %s",
+					errCount,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if count >= maxScheduledChirps {
+			e := customErrors.CodedError{
+				Message: fmt.Sprintf("at most %d drafts and scheduled chirps can be saved", maxScheduledChirps),
+				StatusCode: http.StatusForbidden,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		inReplyTo, quoteOf, publishAt, errValidate := cfg.validateScheduledChirp(r.Context(), userId, &req)
+		if errValidate != nil {
+			respondWithError(&w, errValidate)
+			return
+		}
+
+		createPars := database.CreateScheduledChirpParams{
+			UserID: userId,
+			Body: req.Body,
+			InReplyTo: inReplyTo,
+			QuoteOf: quoteOf,
+			PublishAt: publishAt,
+			Visibility: req.Visibility,
+			ContentWarning: req.ContentWarning,
+			Sensitive: req.Sensitive,
+		}
+
+		scheduled, errCreate := cfg.DB.CreateScheduledChirp(r.Context(), createPars)
+		if errCreate != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to save scheduled chirp: %w, function: %s",
+					errCreate,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		sc := ScheduledChirp{}
+		sc.mapScheduledChirp(&scheduled)
+
+		respSuccesfullScheduledChirpPost(&w, &sc)
+	}
+
+	return postScheduledChirpHandler
+}
+
+func getScheduledChirpsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getScheduledChirpsHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		status := r.URL.Query().Get("status")
+		if status != "" && status != scheduledStatusDraft && status != scheduledStatusScheduled {
+			e := customErrors.CodedError{
+				Message: "status must be draft or scheduled",
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		scheduled, errScheduled := cfg.DB.GetScheduledChirps(r.Context(), userId)
+		if errScheduled != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get scheduled chirps: %w, function: %s",
+					errScheduled,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		scArr := []ScheduledChirp{}
+		for _, s := range scheduled {
+			sc := ScheduledChirp{}
+			sc.mapScheduledChirp(&s)
+			if status == "" || sc.Status == status {
+				scArr = append(scArr, sc)
+			}
+		}
+
+		respSuccesfullScheduledChirpsGet(&w, scArr)
+	}
+
+	return getScheduledChirpsHandler
+}
+
+func getScheduledChirpHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getScheduledChirpHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		scheduledUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		scheduledPars := database.GetScheduledChirpParams{
+			ID: scheduledUUID,
+			UserID: userId,
+		}
+
+		scheduled, errScheduled := cfg.DB.GetScheduledChirp(r.Context(), scheduledPars)
+		if errScheduled != nil {
+			respondWithError(&w, scheduledChirpError(errScheduled))
+			return
+		}
+
+		sc := ScheduledChirp{}
+		sc.mapScheduledChirp(&scheduled)
+
+		respSuccesfullScheduledChirpGet(&w, &sc)
+	}
+
+	return getScheduledChirpHandler
+}
+
+func putScheduledChirpHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	putScheduledChirpHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		scheduledUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		req := scheduledChirpRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		inReplyTo, quoteOf, publishAt, errValidate := cfg.validateScheduledChirp(r.Context(), userId, &req)
+		if errValidate != nil {
+			respondWithError(&w, errValidate)
+			return
+		}
+
+		// a chirp being published holds the lock on its row, the update
+		// waits for it and then finds nothing to update
+		updatePars := database.UpdateScheduledChirpParams{
+			ID: scheduledUUID,
+			UserID: userId,
+			Body: req.Body,
+			InReplyTo: inReplyTo,
+			QuoteOf: quoteOf,
+			PublishAt: publishAt,
+			Visibility: req.Visibility,
+			ContentWarning: req.ContentWarning,
+			Sensitive: req.Sensitive,
+		}
+
+		scheduled, errUpdate := cfg.DB.UpdateScheduledChirp(r.Context(), updatePars)
+		if errUpdate != nil {
+			respondWithError(&w, scheduledChirpError(errUpdate))
+			return
+		}
+
+		sc := ScheduledChirp{}
+		sc.mapScheduledChirp(&scheduled)
+
+		respSuccesfullScheduledChirpPut(&w, &sc)
+	}
+
+	return putScheduledChirpHandler
+}
+
+func deleteScheduledChirpHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	deleteScheduledChirpHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		scheduledUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		deletePars := database.DeleteScheduledChirpParams{
+			ID: scheduledUUID,
+			UserID: userId,
+		}
+
+		deleted, errDelete := cfg.DB.DeleteScheduledChirp(r.Context(), deletePars)
+		if errDelete != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to delete scheduled chirp: %w, function: %s",
+					errDelete,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if deleted == 0 {
+			respondWithError(&w, scheduledChirpError(sql.ErrNoRows))
+			return
+		}
+
+		respNoContent(&w)
+	}
+
+	return deleteScheduledChirpHandler
+}
+
+// validateScheduledChirp runs the body through ValidateChirp and resolves
+// the chirps it replies to and quotes, they are checked again when the
+// chirp is published. A missing publish_at saves a draft
+func (cfg *apiConfig) validateScheduledChirp(ctx context.Context, userId uuid.UUID, req *scheduledChirpRequest) (uuid.NullUUID, uuid.NullUUID, sql.NullTime, *customErrors.CodedError) {
+	_, errChirpValidation := ValidateChirp(&req.Body, cfg.Moderation)
+	if errChirpValidation != nil {
+		return uuid.NullUUID{}, uuid.NullUUID{}, sql.NullTime{}, errChirpValidation
+	}
+
+	errVisibility := validateVisibility(&req.Visibility)
+	if errVisibility != nil {
+		return uuid.NullUUID{}, uuid.NullUUID{}, sql.NullTime{}, errVisibility
+	}
+
+	errWarning := validateContentWarning(&req.ContentWarning, cfg)
+	if errWarning != nil {
+		return uuid.NullUUID{}, uuid.NullUUID{}, sql.NullTime{}, errWarning
+	}
+
+	inReplyTo, _, errReply := cfg.replyTarget(ctx, userId, req.InReplyTo)
+	if errReply != nil {
+		return uuid.NullUUID{}, uuid.NullUUID{}, sql.NullTime{}, errReply
+	}
+
+	quoteOf, errQuote := cfg.quoteTarget(ctx, userId, req.QuoteOf)
+	if errQuote != nil {
+		return uuid.NullUUID{}, uuid.NullUUID{}, sql.NullTime{}, errQuote
+	}
+
+	if req.PublishAt == nil {
+		return inReplyTo, quoteOf, sql.NullTime{}, nil
+	}
+
+	ahead := time.Until(*req.PublishAt)
+	if ahead <= 0 || ahead > maxScheduleAhead {
+		e := customErrors.CodedError{
+			Message: "publish_at must be in the next 90 days",
+			StatusCode: http.StatusBadRequest,
+		}
+		return uuid.NullUUID{}, uuid.NullUUID{}, sql.NullTime{}, &e
+	}
+
+	return inReplyTo, quoteOf, sql.NullTime{Time: req.PublishAt.UTC(), Valid: true}, nil
+}
+
+func scheduledChirpError(err error) *customErrors.CodedError {
+	if err == sql.ErrNoRows {
+		e := customErrors.CodedError{
+			Message: "scheduled chirp not found",
+			StatusCode: http.StatusNotFound,
+		}
+		return &e
+	}
+
+	e := customErrors.CodedError{
+		Message: fmt.Errorf("failed to get scheduled chirp: %w, function: %s",
+			err,
+			customErrors.GetFunctionName()).Error(),
+		StatusCode: http.StatusInternalServerError,
+	}
+	return &e
+}
+
+// publishScheduledChirp publishes the next due scheduled chirp, if any,
+// reporting whether there was one. The row is claimed with FOR UPDATE
+// SKIP LOCKED and deleted in the transaction creating the chirp, so every
+// scheduled chirp is published once whatever the number of instances.
+// A chirp that fails the checks it would fail when posted is turned back
+// into a draft with the reason, one posted too fast is retried later.
+// The publication runs after a savepoint, so that an error of the server
+// is rolled back and the row retried later instead of being claimed again
+// ahead of the other due chirps
+func (cfg *apiConfig) publishScheduledChirp(ctx context.Context) (bool, *customErrors.CodedError) {
+	claimed := false
+	var chirp database.Chirp
+	var mentioned []uuid.UUID
+	errPublish := cfg.withTx(ctx, func(q *database.Queries) *customErrors.CodedError {
+		scheduled, errClaim := q.ClaimDueScheduledChirp(ctx)
+		if errClaim == sql.ErrNoRows {
+			return nil
+		}
+		if errClaim != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to claim scheduled chirp: %w, function: %s",
+					errClaim,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+		claimed = true
+
+		errSavepoint := q.SavepointScheduledChirp(ctx)
+		if errSavepoint != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to create savepoint: %w, function: %s",
+					errSavepoint,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+
+		var errClaimed *customErrors.CodedError
+		chirp, mentioned, errClaimed = cfg.publishClaimedChirp(ctx, q, &scheduled)
+		if errClaimed == nil {
+			return nil
+		}
+
+		log.Printf("scheduler: scheduled chirp %s: %s", scheduled.ID, errClaimed.Message)
+		chirp, mentioned = database.Chirp{}, nil
+
+		errRollback := q.RollbackScheduledChirp(ctx)
+		if errRollback != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to roll back to savepoint: %w, function: %s",
+					errRollback,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+
+		return retryScheduledChirp(ctx, q, &scheduled)
+	})
+	if errPublish != nil {
+		return false, errPublish
+	}
+
+	if chirp.ID != uuid.Nil {
+		cfg.announceChirp(ctx, &chirp, mentioned)
+	}
+
+	return claimed, nil
+}
+
+// publishClaimedChirp creates the chirp of a claimed scheduled chirp and
+// deletes the row, the errors returned are errors of the server
+func (cfg *apiConfig) publishClaimedChirp(ctx context.Context, q *database.Queries, scheduled *database.ScheduledChirp) (database.Chirp, []uuid.UUID, *customErrors.CodedError) {
+	req := chirpPostRequest{
+		Body: scheduled.Body,
+		Visibility: scheduled.Visibility,
+		ContentWarning: scheduled.ContentWarning,
+		Sensitive: scheduled.Sensitive,
+	}
+	if scheduled.InReplyTo.Valid {
+		req.InReplyTo = &scheduled.InReplyTo.UUID
+	}
+	if scheduled.QuoteOf.Valid {
+		req.QuoteOf = &scheduled.QuoteOf.UUID
+	}
+
+	errCheck := cfg.checkSuspension(ctx, scheduled.UserID)
+	var chirpPars database.CreateChirpParams
+	if errCheck == nil {
+		var spamResult spam.Result
+		chirpPars, spamResult, errCheck = cfg.prepareChirp(ctx, scheduled.UserID, &req)
+		if errCheck != nil && errCheck.StatusCode == http.StatusTooManyRequests {
+			return database.Chirp{}, nil, postponeScheduledChirp(ctx, q, scheduled, spamResult.RetryAfter)
+		}
+	}
+
+	if errCheck != nil && errCheck.StatusCode >= http.StatusInternalServerError {
+		return database.Chirp{}, nil, errCheck
+	}
+
+	if errCheck != nil {
+		return database.Chirp{}, nil, failScheduledChirp(ctx, q, scheduled, errCheck.Message)
+	}
+
+	chirp, mentioned, errInsert := insertChirp(ctx, q, &chirpPars, &req)
+	if errInsert != nil {
+		return database.Chirp{}, nil, errInsert
+	}
+
+	errDelete := q.DeletePublishedScheduledChirp(ctx, scheduled.ID)
+	if errDelete != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to delete published scheduled chirp: %w, function: %s",
+				errDelete,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return database.Chirp{}, nil, &e
+	}
+
+	return chirp, mentioned, nil
+}
+
+// retryScheduledChirp postpones a scheduled chirp whose publication failed
+// on an error of the server, waiting twice as long after each attempt, and
+// turns it back into a draft after maxScheduledAttempts
+func retryScheduledChirp(ctx context.Context, q *database.Queries, scheduled *database.ScheduledChirp) *customErrors.CodedError {
+	attempts := scheduled.Attempts + 1
+	if attempts >= maxScheduledAttempts {
+		return failScheduledChirp(ctx, q, scheduled, "the chirp could not be published, schedule it again to retry")
+	}
+
+	retryPars := database.RetryScheduledChirpParams{
+		ID: scheduled.ID,
+		PublishAt: sql.NullTime{Time: time.Now().Add(schedulerInterval << attempts).UTC(), Valid: true},
+	}
+
+	errRetry := q.RetryScheduledChirp(ctx, retryPars)
+	if errRetry != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to retry scheduled chirp: %w, function: %s",
+				errRetry,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	return nil
+}
+
+func postponeScheduledChirp(ctx context.Context, q *database.Queries, scheduled *database.ScheduledChirp, wait time.Duration) *customErrors.CodedError {
+	postponePars := database.PostponeScheduledChirpParams{
+		ID: scheduled.ID,
+		PublishAt: sql.NullTime{Time: time.Now().Add(wait).UTC(), Valid: true},
+	}
+
+	errPostpone := q.PostponeScheduledChirp(ctx, postponePars)
+	if errPostpone != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to postpone scheduled chirp: %w, function: %s",
+				errPostpone,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	return nil
+}
+
+func failScheduledChirp(ctx context.Context, q *database.Queries, scheduled *database.ScheduledChirp, reason string) *customErrors.CodedError {
+	failPars := database.FailScheduledChirpParams{
+		ID: scheduled.ID,
+		LastError: sql.NullString{String: reason, Valid: true},
+	}
+
+	errFail := q.FailScheduledChirp(ctx, failPars)
+	if errFail != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to mark scheduled chirp as failed: %w, function: %s",
+				errFail,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	return nil
+}
+
+// runScheduler publishes the due scheduled chirps every schedulerInterval
+// until ctx is done
+func (cfg *apiConfig) runScheduler(ctx context.Context) {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			published, errPublish := cfg.publishScheduledChirp(ctx)
+			if errPublish != nil {
+				log.Printf("scheduler: %s", errPublish.Message)
+			}
+			// a chirp failing to publish is retried later, an error here
+			// means the database is unreachable and waits for the next tick
+			if errPublish != nil || !published {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+	"github.com/niccolot/Chirpy/internal/spam"
+)
+
+
+func getBlocklistHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getBlocklistHandler := func(w http.ResponseWriter, r *http.Request) {
+		domains, errDomains := cfg.DB.GetBlockedDomains(r.Context())
+		if errDomains != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get blocklist: %w, function: %s",
+					errDomains,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		bArr := make([]BlockedDomain, len(domains))
+		for i, d := range domains {
+			bArr[i].mapBlockedDomain(&d)
+		}
+
+		respSuccesfullBlocklistGet(&w, bArr)
+	}
+
+	return getBlocklistHandler
+}
+
+func postBlocklistHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postBlocklistHandler := func(w http.ResponseWriter, r *http.Request) {
+		adminId := userIdFromContext(r.Context())
+
+		decoder := json.NewDecoder(r.Body)
+		req := blocklistPostRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		for _, domain := range req.Domains {
+			domain = spam.NormalizeDomain(domain)
+			if domain == "" || !strings.Contains(domain, ".") {
+				e := customErrors.CodedError{
+					Message: fmt.Sprintf("invalid domain: %q", domain),
+					StatusCode: http.StatusBadRequest,
+				}
+				respondWithError(&w, &e)
+				return
+			}
+
+			domainPars := database.AddBlockedDomainParams{
+				Domain: domain,
+				CreatedBy: uuid.NullUUID{UUID: adminId, Valid: true},
+			}
+
+			errAdd := cfg.DB.AddBlockedDomain(r.Context(), domainPars)
+			if errAdd != nil {
+				e := customErrors.CodedError{
+					Message: fmt.Errorf("failed to add domain %s: %w, function: %s",
+						domain,
+						errAdd,
+						customErrors.GetFunctionName()).Error(),
+					StatusCode: http.StatusInternalServerError,
+				}
+				respondWithError(&w, &e)
+				return
+			}
+		}
+
+		errReload := cfg.reloadBlocklist(r.Context())
+		if errReload != nil {
+			respondWithError(&w, errReload)
+			return
+		}
+
+		cfg.recordAudit(r, auditEvent{
+			Action: auditBlocklistAdded,
+			ActorId: adminId,
+			TargetType: "blocklist",
+			Metadata: map[string]any{"domains": req.Domains},
+		})
+
+		respNoContent(&w)
+	}
+
+	return postBlocklistHandler
+}
+
+func deleteBlocklistHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	deleteBlocklistHandler := func(w http.ResponseWriter, r *http.Request) {
+		domain := spam.NormalizeDomain(r.PathValue("domain"))
+
+		errDelete := cfg.DB.DeleteBlockedDomain(r.Context(), domain)
+		if errDelete != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to delete domain %s: %w, function: %s",
+					domain,
+					errDelete,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		errReload := cfg.reloadBlocklist(r.Context())
+		if errReload != nil {
+			respondWithError(&w, errReload)
+			return
+		}
+
+		cfg.recordAudit(r, auditEvent{
+			Action: auditBlocklistRemoved,
+			ActorId: userIdFromContext(r.Context()),
+			TargetType: "blocklist",
+			TargetId: domain,
+		})
+
+		respNoContent(&w)
+	}
+
+	return deleteBlocklistHandler
+}
+
+func (cfg *apiConfig) reloadBlocklist(ctx context.Context) *customErrors.CodedError {
+	domains, errDomains := cfg.DB.GetBlockedDomains(ctx)
+	if errDomains != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to load url blocklist: %w, function: %s",
+				errDomains,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	blocklist := make([]string, len(domains))
+	for i, d := range domains {
+		blocklist[i] = d.Domain
+	}
+
+	cfg.Spam.SetBlocklist(blocklist)
+
+	return nil
+}
+
+// checkSpam scores a new chirp against the recent chirps of its author.
+// Rejected and throttled chirps come back as errors, the latter with
+// the seconds to wait in RetryAfter, the rest is left to the caller
+func (cfg *apiConfig) checkSpam(ctx context.Context, userId uuid.UUID, body string) (spam.Result, *customErrors.CodedError) {
+	now := time.Now().UTC()
+	fingerprintsPars := database.GetRecentChirpFingerprintsParams{
+		UserID: userId,
+		CreatedAt: now.Add(-cfg.Spam.Config().DuplicateWindow),
+	}
+
+	rows, errRows := cfg.DB.GetRecentChirpFingerprints(ctx, fingerprintsPars)
+	if errRows != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to get recent chirps: %w, function: %s",
+				errRows,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return spam.Result{}, &e
+	}
+
+	history := make([]spam.Fingerprint, len(rows))
+	for i, row := range rows {
+		history[i] = spam.Fingerprint{
+			Hash: row.BodyHash,
+			Simhash: uint64(row.Simhash),
+			CreatedAt: row.CreatedAt,
+		}
+	}
+
+	return cfg.spamVerdict("chirp", body, history, now)
+}
+
+// checkMessageSpam does what checkSpam does for a direct message, against
+// the recent messages of the sender in every conversation
+func (cfg *apiConfig) checkMessageSpam(ctx context.Context, userId uuid.UUID, body string) (spam.Result, *customErrors.CodedError) {
+	now := time.Now().UTC()
+	fingerprintsPars := database.GetRecentMessageFingerprintsParams{
+		SenderID: userId,
+		CreatedAt: now.Add(-cfg.Spam.Config().DuplicateWindow),
+	}
+
+	rows, errRows := cfg.DB.GetRecentMessageFingerprints(ctx, fingerprintsPars)
+	if errRows != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to get recent messages: %w, function: %s",
+				errRows,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return spam.Result{}, &e
+	}
+
+	history := make([]spam.Fingerprint, len(rows))
+	for i, row := range rows {
+		history[i] = spam.Fingerprint{
+			Hash: row.BodyHash,
+			Simhash: uint64(row.Simhash),
+			CreatedAt: row.CreatedAt,
+		}
+	}
+
+	return cfg.spamVerdict("message", body, history, now)
+}
+
+func (cfg *apiConfig) spamVerdict(kind string, body string, history []spam.Fingerprint, now time.Time) (spam.Result, *customErrors.CodedError) {
+	res := cfg.Spam.Evaluate(body, history, now)
+
+	switch res.Verdict {
+	case spam.VerdictReject:
+		e := customErrors.CodedError{
+			Message: fmt.Sprintf("%s rejected as spam: %s", kind, strings.Join(res.Reasons, ", ")),
+			StatusCode: http.StatusUnprocessableEntity,
+		}
+		return res, &e
+	case spam.VerdictThrottle:
+		e := customErrors.CodedError{
+			Message: "posting too fast, try again later",
+			StatusCode: http.StatusTooManyRequests,
+		}
+		return res, &e
+	}
+
+	return res, nil
+}
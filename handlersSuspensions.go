@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/auth"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+)
+
+
+func postSuspensionHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	postSuspensionHandler := func(w http.ResponseWriter, r *http.Request) {
+		moderatorId := userIdFromContext(r.Context())
+
+		userUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		decoder := json.NewDecoder(r.Body)
+		req := suspensionPostRequest{}
+		errDecode := decoder.Decode(&req)
+		if errDecode != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to decode request: %w, function: %s",
+					errDecode,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if strings.TrimSpace(req.Reason) == "" || req.DurationHours < 0 {
+			e := customErrors.CodedError{
+				Message: "a suspension needs a reason and a non negative duration",
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		user, errUser := cfg.DB.FindUserById(r.Context(), userUUID)
+		if errUser != nil {
+			e := customErrors.CodedError{
+				Message: "user not found",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		var suspension database.Suspension
+		errSuspend := cfg.withTx(r.Context(), func(q *database.Queries) *customErrors.CodedError {
+			var errS *customErrors.CodedError
+			suspension, errS = suspendUser(r.Context(), q, &user, moderatorId, req.Reason, req.DurationHours)
+			return errS
+		})
+		if errSuspend != nil {
+			respondWithError(&w, errSuspend)
+			return
+		}
+
+		cfg.recordAudit(r, auditEvent{
+			Action: auditUserSuspended,
+			ActorId: moderatorId,
+			TargetType: "user",
+			TargetId: userUUID.String(),
+			Metadata: map[string]any{"reason": req.Reason, "duration_hours": req.DurationHours},
+		})
+
+		s := Suspension{}
+		s.mapSuspension(&suspension)
+
+		respSuccesfullSuspensionPost(&w, &s)
+	}
+
+	return postSuspensionHandler
+}
+
+func getSuspensionsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getSuspensionsHandler := func(w http.ResponseWriter, r *http.Request) {
+		userUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		suspensions, errSuspensions := cfg.DB.GetSuspensionsForUser(r.Context(), userUUID)
+		if errSuspensions != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get suspensions: %w, function: %s",
+					errSuspensions,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		sArr := make([]Suspension, len(suspensions))
+		for i, s := range suspensions {
+			sArr[i].mapSuspension(&s)
+		}
+
+		respSuccesfullSuspensionsGet(&w, sArr)
+	}
+
+	return getSuspensionsHandler
+}
+
+func deleteSuspensionHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	deleteSuspensionHandler := func(w http.ResponseWriter, r *http.Request) {
+		moderatorId := userIdFromContext(r.Context())
+
+		userUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		liftPars := database.LiftActiveSuspensionsParams{
+			UserID: userUUID,
+			LiftedBy: uuid.NullUUID{UUID: moderatorId, Valid: true},
+		}
+
+		lifted, errLift := cfg.DB.LiftActiveSuspensions(r.Context(), liftPars)
+		if errLift != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to lift suspension: %w, function: %s",
+					errLift,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if lifted == 0 {
+			e := customErrors.CodedError{
+				Message: "user is not suspended",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		cfg.recordAudit(r, auditEvent{
+			Action: auditSuspensionLifted,
+			ActorId: moderatorId,
+			TargetType: "user",
+			TargetId: userUUID.String(),
+		})
+
+		respNoContent(&w)
+	}
+
+	return deleteSuspensionHandler
+}
+
+// suspendUser records the suspension and revokes every refresh token of
+// the user, the JWTs still around are refused by cfg.authenticate.
+// A duration of zero hours makes the suspension permanent
+func suspendUser(ctx context.Context, q *database.Queries, user *database.User, moderatorId uuid.UUID, reason string, durationHours int) (database.Suspension, *customErrors.CodedError) {
+	if auth.Role(user.Role) == auth.RoleAdmin {
+		e := customErrors.CodedError{
+			Message: "admins can not be suspended",
+			StatusCode: http.StatusForbidden,
+		}
+		return database.Suspension{}, &e
+	}
+
+	expiresAt := sql.NullTime{}
+	if durationHours > 0 {
+		expiresAt = sql.NullTime{
+			Time: time.Now().UTC().Add(time.Duration(durationHours) * time.Hour),
+			Valid: true,
+		}
+	}
+
+	suspensionPars := database.CreateSuspensionParams{
+		UserID: user.ID,
+		ModeratorID: uuid.NullUUID{UUID: moderatorId, Valid: true},
+		Reason: reason,
+		ExpiresAt: expiresAt,
+	}
+
+	suspension, errSuspend := q.CreateSuspension(ctx, suspensionPars)
+	if errSuspend != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to suspend user: %w, function: %s",
+				errSuspend,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return database.Suspension{}, &e
+	}
+
+	errRevoke := q.RevokeAllUserTokens(ctx, user.ID)
+	if errRevoke != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to revoke refresh tokens: %w, function: %s",
+				errRevoke,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return database.Suspension{}, &e
+	}
+
+	return suspension, nil
+}
+
+func (cfg *apiConfig) checkSuspension(ctx context.Context, userId uuid.UUID) *customErrors.CodedError {
+	suspension, errSuspension := cfg.DB.GetActiveSuspension(ctx, userId)
+	if errSuspension == sql.ErrNoRows {
+		return nil
+	}
+
+	if errSuspension != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to check suspension: %w, function: %s",
+				errSuspension,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	message := "account suspended permanently"
+	if suspension.ExpiresAt.Valid {
+		message = fmt.Sprintf("account suspended until %s", suspension.ExpiresAt.Time.UTC().Format(time.RFC3339))
+	}
+
+	e := customErrors.CodedError{
+		Message: message,
+		StatusCode: http.StatusForbidden,
+	}
+	return &e
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+	"github.com/niccolot/Chirpy/internal/tags"
+)
+
+
+const (
+	trendsInterval = 5 * time.Minute
+	trendsWindow = 24 * time.Hour
+	trendsHalfLife = 6 * time.Hour
+	maxTrends = 20
+)
+
+func getTagChirpsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getTagChirpsHandler := func(w http.ResponseWriter, r *http.Request) {
+		tag, ok := tags.Normalize(r.PathValue("tag"))
+		if !ok {
+			e := customErrors.CodedError{
+				Message: "invalid tag",
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		p, errPage := pageFromQuery(r)
+		if errPage != nil {
+			respondWithError(&w, errPage)
+			return
+		}
+
+		tagPars := database.GetTagChirpsParams{
+			Tag: tag,
+			BeforeTime: p.CursorTime,
+			BeforeID: p.CursorId,
+			MaxEntries: p.Limit,
+		}
+
+		chirps, errChirps := cfg.DB.GetTagChirps(r.Context(), tagPars)
+		if errChirps != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get tag chirps: %w, function: %s",
+					errChirps,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		cArr, errDecorate := cfg.decorateChirps(r.Context(), cfg.viewer(r), chirps)
+		if errDecorate != nil {
+			respondWithError(&w, errDecorate)
+			return
+		}
+
+		respSuccesfullTagChirpsGet(&w, chirpPage(&p, chirps, cArr))
+	}
+
+	return getTagChirpsHandler
+}
+
+func getTrendsHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getTrendsHandler := func(w http.ResponseWriter, r *http.Request) {
+		trending, errTrending := cfg.DB.GetTrendingTags(r.Context())
+		if errTrending != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get trends: %w, function: %s",
+					errTrending,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		trends := Trends{
+			Trends: make([]Trend, len(trending)),
+		}
+		for i, t := range trending {
+			trends.Trends[i].mapTrend(&t)
+			trends.ComputedAt = &t.ComputedAt
+		}
+
+		respSuccesfullTrendsGet(&w, &trends)
+	}
+
+	return getTrendsHandler
+}
+
+// tagChirp replaces the tags indexed for a chirp with the ones in body,
+// tags keep the creation time of the chirp even when it is edited
+func tagChirp(ctx context.Context, q *database.Queries, chirp *database.Chirp, body string) *customErrors.CodedError {
+	errDelete := q.DeleteChirpTags(ctx, chirp.ID)
+	if errDelete != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to delete chirp tags: %w, function: %s",
+				errDelete,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	found := tags.Extract(body)
+	if len(found) == 0 {
+		return nil
+	}
+
+	tagsPars := database.AddChirpTagsParams{
+		ChirpID: chirp.ID,
+		CreatedAt: chirp.CreatedAt,
+		Tags: found,
+	}
+
+	errAdd := q.AddChirpTags(ctx, tagsPars)
+	if errAdd != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to add chirp tags: %w, function: %s",
+				errAdd,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	return nil
+}
+
+// computeTrends rewrites the trending tags from the tags used in the last
+// trendsWindow, every chirp adds to the score of its tags a weight that
+// halves every trendsHalfLife so that recent activity counts more.
+// The lock keeps several instances of the server from racing on the table
+func (cfg *apiConfig) computeTrends(ctx context.Context) *customErrors.CodedError {
+	return cfg.withTx(ctx, func(q *database.Queries) *customErrors.CodedError {
+		errLock := q.LockTrendingTags(ctx)
+		if errLock != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to lock trending tags: %w, function: %s",
+					errLock,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+
+		errClear := q.ClearTrendingTags(ctx)
+		if errClear != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to clear trending tags: %w, function: %s",
+					errClear,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+
+		computePars := database.ComputeTrendingTagsParams{
+			HalfLifeSeconds: trendsHalfLife.Seconds(),
+			WindowStart: time.Now().UTC().Add(-trendsWindow),
+			MaxEntries: maxTrends,
+		}
+
+		errCompute := q.ComputeTrendingTags(ctx, computePars)
+		if errCompute != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to compute trending tags: %w, function: %s",
+					errCompute,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+
+		return nil
+	})
+}
+
+// runTrendsWorker recomputes the trends every trendsInterval until ctx is done
+func (cfg *apiConfig) runTrendsWorker(ctx context.Context) {
+	ticker := time.NewTicker(trendsInterval)
+	defer ticker.Stop()
+
+	for {
+		errTrends := cfg.computeTrends(ctx)
+		if errTrends != nil {
+			log.Printf("trends worker: %s", errTrends.Message)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+)
+
+
+// levels of replies returned below the chirp of a thread, deeper replies
+// are fetched asking for the thread of the chirp they reply to
+const threadMaxDepth = 5
+
+// replies returned below the replies of the page, when a thread has more
+// of them it is marked as truncated
+const threadMaxDescendants = 1000
+
+func getThreadHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getThreadHandler := func(w http.ResponseWriter, r *http.Request) {
+		chirpUUID, errUUID := uuid.Parse(r.PathValue("id"))
+		if errUUID != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("error parsing uuid: %w, function: %s",
+					errUUID,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusBadRequest,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		p, errPage := pageFromQuery(r)
+		if errPage != nil {
+			respondWithError(&w, errPage)
+			return
+		}
+
+		chirp, errChirp := cfg.DB.GetChirp(r.Context(), chirpUUID)
+		if errChirp == sql.ErrNoRows {
+			e := customErrors.CodedError{
+				Message: "chirp not found",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		if errChirp != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get chirp: %w, function: %s",
+					errChirp,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		blocked, errBlocked := blockedAmong(r.Context(), cfg.DB, cfg.viewer(r), []uuid.UUID{chirp.UserID})
+		if errBlocked != nil {
+			respondWithError(&w, errBlocked)
+			return
+		}
+
+		readable, errRead := canRead(r.Context(), cfg.DB, cfg.viewer(r), &chirp)
+		if errRead != nil {
+			respondWithError(&w, errRead)
+			return
+		}
+
+		if blocked[chirp.UserID] || !readable {
+			e := customErrors.CodedError{
+				Message: "chirp not found",
+				StatusCode: http.StatusNotFound,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		ancestors, errAncestors := cfg.DB.GetChirpAncestors(r.Context(), chirp.ID)
+		if errAncestors != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get thread ancestors: %w, function: %s",
+					errAncestors,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		repliesPars := database.GetChirpRepliesParams{
+			ID: chirp.ID,
+			AfterTime: p.CursorTime,
+			AfterID: p.CursorId,
+			MaxEntries: p.Limit,
+		}
+
+		replies, errReplies := cfg.DB.GetChirpReplies(r.Context(), repliesPars)
+		if errReplies != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get replies: %w, function: %s",
+					errReplies,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		replyIds := make([]uuid.UUID, len(replies))
+		for i, reply := range replies {
+			replyIds[i] = reply.ID
+		}
+
+		descendantsPars := database.GetChirpDescendantsParams{
+			ParentIds: replyIds,
+			MaxDepth: threadMaxDepth - 1,
+			MaxEntries: threadMaxDescendants + 1,
+		}
+
+		descendants, errDescendants := cfg.DB.GetChirpDescendants(r.Context(), descendantsPars)
+		if errDescendants != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get replies: %w, function: %s",
+					errDescendants,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		// replies come after the chirps they reply to, the ones cut out do
+		// not leave holes in the tree
+		truncated := len(descendants) > threadMaxDescendants
+		if truncated {
+			descendants = descendants[:threadMaxDescendants]
+		}
+
+		all := make([]database.Chirp, 0, len(ancestors) + 1 + len(replies) + len(descendants))
+		all = append(all, ancestors...)
+		all = append(all, chirp)
+		all = append(all, replies...)
+		all = append(all, descendants...)
+
+		nodes, errNodes := cfg.threadNodes(r, all)
+		if errNodes != nil {
+			respondWithError(&w, errNodes)
+			return
+		}
+
+		thread := Thread{
+			Ancestors: make([]ThreadNode, len(ancestors)),
+			Truncated: truncated,
+		}
+		for i, a := range ancestors {
+			thread.Ancestors[i] = *nodes[a.ID]
+		}
+
+		children := map[uuid.UUID][]uuid.UUID{}
+		for _, c := range append(replies, descendants...) {
+			children[c.InReplyTo.UUID] = append(children[c.InReplyTo.UUID], c.ID)
+		}
+		thread.Chirp = buildThreadTree(chirp.ID, nodes, children)
+
+		if len(replies) > 0 {
+			last := replies[len(replies)-1]
+			thread.NextCursor = nextCursor(&p, len(replies), cursor{Time: last.CreatedAt, Id: last.ID})
+		}
+
+		respSuccesfullThreadGet(&w, &thread)
+	}
+
+	return getThreadHandler
+}
+
+// threadNodes decorates the chirps of a thread, turning the deleted ones,
+// the ones of suspended users and the ones hidden by a block into tombstones
+func (cfg *apiConfig) threadNodes(r *http.Request, chirps []database.Chirp) (map[uuid.UUID]*ThreadNode, *customErrors.CodedError) {
+	authorIds := make([]uuid.UUID, len(chirps))
+	for i, c := range chirps {
+		authorIds[i] = c.UserID
+	}
+
+	suspendedIds, errSuspended := cfg.DB.GetSuspendedUsers(r.Context(), authorIds)
+	if errSuspended != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to check suspended authors: %w, function: %s",
+				errSuspended,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	suspended := make(map[uuid.UUID]bool, len(suspendedIds))
+	for _, id := range suspendedIds {
+		suspended[id] = true
+	}
+
+	decorated, errDecorate := cfg.decorateChirps(r.Context(), cfg.viewer(r), chirps)
+	if errDecorate != nil {
+		return nil, errDecorate
+	}
+
+	byId := make(map[uuid.UUID]*Chirp, len(decorated))
+	for i := range decorated {
+		byId[decorated[i].Id] = &decorated[i]
+	}
+
+	nodes := make(map[uuid.UUID]*ThreadNode, len(chirps))
+	for _, c := range chirps {
+		node := ThreadNode{
+			Id: c.ID,
+		}
+
+		decoratedChirp, visible := byId[c.ID]
+		if c.DeletedAt.Valid || suspended[c.UserID] || !visible {
+			node.Tombstone = true
+		} else {
+			node.Chirp = decoratedChirp
+		}
+
+		nodes[c.ID] = &node
+	}
+
+	return nodes, nil
+}
+
+func buildThreadTree(id uuid.UUID, nodes map[uuid.UUID]*ThreadNode, children map[uuid.UUID][]uuid.UUID) ThreadNode {
+	node := *nodes[id]
+	node.Replies = make([]ThreadNode, 0, len(children[id]))
+	for _, childId := range children[id] {
+		node.Replies = append(node.Replies, buildThreadTree(childId, nodes, children))
+	}
+
+	return node
+}
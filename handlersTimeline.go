@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+)
+
+
+// authors with more followers than this are not fanned out on write,
+// their chirps are merged in the home timelines when they are read
+const defaultFanOutLimit = 10000
+
+func getHomeTimelineHandlerWrapped(cfg *apiConfig) func(w http.ResponseWriter, r *http.Request) {
+	getHomeTimelineHandler := func(w http.ResponseWriter, r *http.Request) {
+		userId, errAuth := cfg.authenticate(r)
+		if errAuth != nil {
+			respondWithError(&w, errAuth)
+			return
+		}
+
+		p, errPage := pageFromQuery(r)
+		if errPage != nil {
+			respondWithError(&w, errPage)
+			return
+		}
+
+		chirps, errChirps := readHomeTimeline(r.Context(), &p,
+			fannedOutTimeline(cfg.DB, userId),
+			unfannedTimeline(cfg.DB, userId))
+		if errChirps != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to get home timeline: %w, function: %s",
+					errChirps,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			respondWithError(&w, &e)
+			return
+		}
+
+		visible, errMuted := cfg.withoutMuted(r.Context(), userId, chirps)
+		if errMuted != nil {
+			respondWithError(&w, errMuted)
+			return
+		}
+
+		cArr, errDecorate := cfg.decorateChirpsFor(r.Context(), userId, visible, mutedWordHome)
+		if errDecorate != nil {
+			respondWithError(&w, errDecorate)
+			return
+		}
+
+		respSuccesfullTimelineGet(&w, chirpPage(&p, chirps, cArr))
+	}
+
+	return getHomeTimelineHandler
+}
+
+// timelineSource reads a page of a home timeline, ordered by time and id like
+// the timeline itself
+type timelineSource func(ctx context.Context, p *page) ([]database.Chirp, error)
+
+func fannedOutTimeline(q *database.Queries, userId uuid.UUID) timelineSource {
+	return func(ctx context.Context, p *page) ([]database.Chirp, error) {
+		return q.GetFannedOutTimeline(ctx, database.GetFannedOutTimelineParams{
+			UserID: userId,
+			BeforeTime: p.CursorTime,
+			BeforeID: p.CursorId,
+			MaxEntries: p.Limit,
+		})
+	}
+}
+
+func unfannedTimeline(q *database.Queries, userId uuid.UUID) timelineSource {
+	return func(ctx context.Context, p *page) ([]database.Chirp, error) {
+		return q.GetUnfannedTimeline(ctx, database.GetUnfannedTimelineParams{
+			UserID: userId,
+			BeforeTime: p.CursorTime,
+			BeforeID: p.CursorId,
+			MaxEntries: p.Limit,
+		})
+	}
+}
+
+// readHomeTimeline merges the pages read from each source with the same
+// cursor and limit. Every source returns its first p.Limit chirps after the
+// cursor, so the first p.Limit chirps of the merge are the page of the whole
+// timeline, whether the chirps were fanned out on write or are read
+func readHomeTimeline(ctx context.Context, p *page, sources ...timelineSource) ([]database.Chirp, error) {
+	merged := []database.Chirp{}
+	seen := map[uuid.UUID]bool{}
+	for _, source := range sources {
+		chirps, errSource := source(ctx, p)
+		if errSource != nil {
+			return nil, errSource
+		}
+
+		for _, c := range chirps {
+			if seen[c.ID] {
+				continue
+			}
+			seen[c.ID] = true
+			merged = append(merged, c)
+		}
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		if !merged[i].CreatedAt.Equal(merged[j].CreatedAt) {
+			return merged[i].CreatedAt.After(merged[j].CreatedAt)
+		}
+		return bytes.Compare(merged[i].ID[:], merged[j].ID[:]) > 0
+	})
+
+	if len(merged) > int(p.Limit) {
+		merged = merged[:p.Limit]
+	}
+
+	return merged, nil
+}
+
+// chirpPage builds the page of the decorated chirps, the cursor comes from the
+// chirps read from the database since some of them can be hidden to the viewer
+func chirpPage(p *page, chirps []database.Chirp, decorated []Chirp) *ChirpPage {
+	cp := ChirpPage{
+		Chirps: decorated,
+	}
+
+	if len(chirps) > 0 {
+		last := chirps[len(chirps)-1]
+		cp.NextCursor = nextCursor(p, len(chirps), cursor{Time: last.CreatedAt, Id: last.ID})
+	}
+
+	return &cp
+}
+
+// fanOutChirp copies a new chirp in the home timelines of the followers of its
+// author and of the author, unless the author has more than cfg.FanOutLimit
+// followers. The author is locked so that a concurrent follow either sees
+// the chirp as fanned out and backfills it, or is among the followers
+func (cfg *apiConfig) fanOutChirp(ctx context.Context, chirp *database.Chirp) *customErrors.CodedError {
+	return cfg.withTx(ctx, func(q *database.Queries) *customErrors.CodedError {
+		errLock := q.LockUserFollows(ctx, chirp.UserID)
+		if errLock != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to lock author: %w, function: %s",
+					errLock,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+
+		counts, errCounts := q.GetFollowCounts(ctx, chirp.UserID)
+		if errCounts != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to count followers: %w, function: %s",
+					errCounts,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+
+		if counts.FollowersCount > cfg.FanOutLimit {
+			return nil
+		}
+
+		errFanOut := q.FanOutChirp(ctx, chirp.ID)
+		if errFanOut != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to fan out chirp: %w, function: %s",
+					errFanOut,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+
+		errMark := q.MarkChirpFannedOut(ctx, chirp.ID)
+		if errMark != nil {
+			e := customErrors.CodedError{
+				Message: fmt.Errorf("failed to mark chirp as fanned out: %w, function: %s",
+					errMark,
+					customErrors.GetFunctionName()).Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+			return &e
+		}
+
+		return nil
+	})
+}
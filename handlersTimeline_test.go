@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/database"
+)
+
+// memoryTimeline answers like the timeline queries, the chirps after the
+// cursor ordered by time and id, at most p.Limit of them
+func memoryTimeline(chirps []database.Chirp) timelineSource {
+	return func(ctx context.Context, p *page) ([]database.Chirp, error) {
+		after := []database.Chirp{}
+		for _, c := range chirps {
+			if p.CursorTime.Valid && !chirpBefore(&c, p.CursorTime.Time, p.CursorId.UUID) {
+				continue
+			}
+			after = append(after, c)
+		}
+
+		// the text form of a uuid sorts like its bytes, as postgres does
+		sort.Slice(after, func(i, j int) bool {
+			if !after[i].CreatedAt.Equal(after[j].CreatedAt) {
+				return after[i].CreatedAt.After(after[j].CreatedAt)
+			}
+			return after[i].ID.String() > after[j].ID.String()
+		})
+
+		if len(after) > int(p.Limit) {
+			after = after[:p.Limit]
+		}
+
+		return after, nil
+	}
+}
+
+func chirpBefore(c *database.Chirp, t time.Time, id uuid.UUID) bool {
+	if !c.CreatedAt.Equal(t) {
+		return c.CreatedAt.Before(t)
+	}
+
+	return c.ID.String() < id.String()
+}
+
+// walkTimeline reads every page of the timeline following the next cursors
+// the way a client does, and returns the ids of each page
+func walkTimeline(t *testing.T, limit int32, sources ...timelineSource) [][]uuid.UUID {
+	t.Helper()
+
+	pages := [][]uuid.UUID{}
+	p := page{Limit: limit}
+	for {
+		chirps, err := readHomeTimeline(context.Background(), &p, sources...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		ids := make([]uuid.UUID, len(chirps))
+		for i, c := range chirps {
+			ids[i] = c.ID
+		}
+		pages = append(pages, ids)
+
+		cp := chirpPage(&p, chirps, nil)
+		if cp.NextCursor == "" {
+			return pages
+		}
+
+		next, errCursor := decodeCursor(cp.NextCursor)
+		if errCursor != nil {
+			t.Fatalf("invalid next cursor: %s", errCursor.Message)
+		}
+		p.CursorTime = sql.NullTime{Time: next.Time, Valid: true}
+		p.CursorId = uuid.NullUUID{UUID: next.Id, Valid: true}
+	}
+}
+
+// pagesOf splits ids in pages of limit, with the empty page a client reads
+// last when the final page is full
+func pagesOf(ids []uuid.UUID, limit int32) [][]uuid.UUID {
+	pages := [][]uuid.UUID{}
+	for len(ids) >= int(limit) {
+		pages = append(pages, ids[:limit])
+		ids = ids[limit:]
+	}
+
+	return append(pages, ids)
+}
+
+func TestHomeTimelineMergesSources(t *testing.T) {
+	id := func(n byte) uuid.UUID {
+		return uuid.UUID{15: n}
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	chirp := func(n byte, second int) database.Chirp {
+		return database.Chirp{ID: id(n), CreatedAt: start.Add(time.Duration(second) * time.Second)}
+	}
+
+	fannedOut := []database.Chirp{chirp(1, 0), chirp(3, 1), chirp(5, 2), chirp(7, 1), chirp(6, 3)}
+	// chirp 6 is in both, its author was followed after it was fanned out
+	unfanned := []database.Chirp{chirp(6, 3), chirp(4, 2), chirp(2, 0)}
+
+	// newest first, chirps of the same second by decreasing id:
+	// 6 at 3s, 5 and 4 at 2s, 7 and 3 at 1s, 2 and 1 at 0s
+	tests := []struct {
+		limit int32
+		want [][]uuid.UUID
+	}{
+		{1, [][]uuid.UUID{{id(6)}, {id(5)}, {id(4)}, {id(7)}, {id(3)}, {id(2)}, {id(1)}, {}}},
+		{2, [][]uuid.UUID{{id(6), id(5)}, {id(4), id(7)}, {id(3), id(2)}, {id(1)}}},
+		{3, [][]uuid.UUID{{id(6), id(5), id(4)}, {id(7), id(3), id(2)}, {id(1)}}},
+		{7, [][]uuid.UUID{{id(6), id(5), id(4), id(7), id(3), id(2), id(1)}, {}}},
+		{20, [][]uuid.UUID{{id(6), id(5), id(4), id(7), id(3), id(2), id(1)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("limit %d", tt.limit), func(t *testing.T) {
+			got := walkTimeline(t, tt.limit, memoryTimeline(fannedOut), memoryTimeline(unfanned))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got pages\n%v\nexpected\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHomeTimelineFanOutOnWriteAndReadGiveSamePages(t *testing.T) {
+	authors := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	chirps := []database.Chirp{}
+	for i := 0; i < 47; i++ {
+		chirps = append(chirps, database.Chirp{
+			ID: uuid.New(),
+			// chirps created in the same instant are ordered by id
+			CreatedAt: start.Add(time.Duration(i/3) * time.Second),
+			UserID: authors[i%len(authors)],
+		})
+	}
+
+	// the last author has too many followers to be fanned out
+	fannedOut, unfanned := []database.Chirp{}, []database.Chirp{}
+	for _, c := range chirps {
+		if c.UserID == authors[2] {
+			unfanned = append(unfanned, c)
+		} else {
+			fannedOut = append(fannedOut, c)
+		}
+	}
+
+	newestFirst := []uuid.UUID{}
+	for second := 46 / 3; second >= 0; second-- {
+		ids := []string{}
+		for i := 3 * second; i < 3*second+3 && i < len(chirps); i++ {
+			ids = append(ids, chirps[i].ID.String())
+		}
+		sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+		for _, id := range ids {
+			newestFirst = append(newestFirst, uuid.MustParse(id))
+		}
+	}
+
+	for _, limit := range []int32{1, 5, 16, 47, 100} {
+		t.Run(fmt.Sprintf("limit %d", limit), func(t *testing.T) {
+			onWrite := walkTimeline(t, limit, memoryTimeline(chirps), memoryTimeline(nil))
+			onRead := walkTimeline(t, limit, memoryTimeline(nil), memoryTimeline(chirps))
+			mixed := walkTimeline(t, limit, memoryTimeline(fannedOut), memoryTimeline(unfanned))
+
+			if !reflect.DeepEqual(onWrite, onRead) {
+				t.Errorf("fan out on write and on read give different pages:\n%v\n%v", onWrite, onRead)
+			}
+			if !reflect.DeepEqual(onWrite, mixed) {
+				t.Errorf("fan out on write and mixed give different pages:\n%v\n%v", onWrite, mixed)
+			}
+
+			if want := pagesOf(newestFirst, limit); !reflect.DeepEqual(onWrite, want) {
+				t.Errorf("got pages\n%v\nexpected\n%v", onWrite, want)
+			}
+		})
+	}
+}
@@ -2,13 +2,15 @@ package main
 
 import (
 	"net/http"
+
+	"github.com/niccolot/Chirpy/internal/auth"
 )
 
 
 func initMultiplexer(mux *http.ServeMux, cfg *apiConfig) {
 	mux.Handle("/app/*", cfg.middlewareMetricsInc(http.StripPrefix("/app/", http.FileServer(http.Dir(".")))))
-	mux.HandleFunc("GET /admin/metrics", metricshandlerWrapped(cfg))
-	mux.HandleFunc("POST /admin/reset", resetMetricshandlerWrapperd(cfg))
+	mux.HandleFunc("GET /admin/metrics", cfg.middlewareRequirePermission(auth.PermViewMetrics, metricshandlerWrapped(cfg)))
+	mux.HandleFunc("POST /admin/reset", cfg.middlewareRequirePermission(auth.PermResetDatabase, resetMetricshandlerWrapperd(cfg)))
 	mux.HandleFunc("GET /api/healthz", healthzHandler)
 	mux.HandleFunc("POST /api/users", postUsersHandlerWrapped(cfg))
 	mux.HandleFunc("POST /api/chirps", postChirphandlerWrapped(cfg))
@@ -22,4 +24,91 @@ func initMultiplexer(mux *http.ServeMux, cfg *apiConfig) {
 	mux.HandleFunc("POST /api/polka/webhooks", postPolkaWebhookHandlerWrapped(cfg))
 	mux.HandleFunc("DELETE /api/users/{id}", deleteUsersHandlerWrapped(cfg))
 	mux.HandleFunc("PUT /api/chirps/{id}", putChirpsHandlerWrapped(cfg))
+	mux.HandleFunc("GET /admin/wordlists", cfg.middlewareRequirePermission(auth.PermManageWordLists, getWordListsHandlerWrapped(cfg)))
+	mux.HandleFunc("POST /admin/wordlists", cfg.middlewareRequirePermission(auth.PermManageWordLists, postWordListHandlerWrapped(cfg)))
+	mux.HandleFunc("PUT /admin/wordlists/{id}", cfg.middlewareRequirePermission(auth.PermManageWordLists, putWordListHandlerWrapped(cfg)))
+	mux.HandleFunc("DELETE /admin/wordlists/{id}", cfg.middlewareRequirePermission(auth.PermManageWordLists, deleteWordListHandlerWrapped(cfg)))
+	mux.HandleFunc("POST /admin/wordlists/{id}/words", cfg.middlewareRequirePermission(auth.PermManageWordLists, postWordListWordsHandlerWrapped(cfg)))
+	mux.HandleFunc("DELETE /admin/wordlists/{id}/words/{word}", cfg.middlewareRequirePermission(auth.PermManageWordLists, deleteWordListWordHandlerWrapped(cfg)))
+	mux.HandleFunc("GET /admin/chirps/flagged", cfg.middlewareRequirePermission(auth.PermModerateContent, getFlaggedChirpsHandlerWrapped(cfg)))
+	mux.HandleFunc("POST /admin/chirps/{id}/approve", cfg.middlewareRequirePermission(auth.PermModerateContent, postApproveChirpHandlerWrapped(cfg)))
+	mux.HandleFunc("POST /api/chirps/{id}/report", postReportHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/reports", getOwnReportsHandlerWrapped(cfg))
+	mux.HandleFunc("GET /admin/reports", cfg.middlewareRequirePermission(auth.PermModerateContent, getReportsHandlerWrapped(cfg)))
+	mux.HandleFunc("POST /admin/reports/{id}/resolve", cfg.middlewareRequirePermission(auth.PermModerateContent, postResolveReportHandlerWrapped(cfg)))
+	mux.HandleFunc("PUT /admin/users/{id}/role", cfg.middlewareRequirePermission(auth.PermManageRoles, putUserRoleHandlerWrapped(cfg)))
+	mux.HandleFunc("POST /admin/users/{id}/suspensions", cfg.middlewareRequirePermission(auth.PermModerateContent, postSuspensionHandlerWrapped(cfg)))
+	mux.HandleFunc("GET /admin/users/{id}/suspensions", cfg.middlewareRequirePermission(auth.PermModerateContent, getSuspensionsHandlerWrapped(cfg)))
+	mux.HandleFunc("DELETE /admin/users/{id}/suspensions", cfg.middlewareRequirePermission(auth.PermModerateContent, deleteSuspensionHandlerWrapped(cfg)))
+	mux.HandleFunc("GET /admin/blocklist", cfg.middlewareRequirePermission(auth.PermManageBlocklist, getBlocklistHandlerWrapped(cfg)))
+	mux.HandleFunc("POST /admin/blocklist", cfg.middlewareRequirePermission(auth.PermManageBlocklist, postBlocklistHandlerWrapped(cfg)))
+	mux.HandleFunc("DELETE /admin/blocklist/{domain}", cfg.middlewareRequirePermission(auth.PermManageBlocklist, deleteBlocklistHandlerWrapped(cfg)))
+	mux.HandleFunc("GET /admin/audit", cfg.middlewareRequirePermission(auth.PermViewAudit, getAuditHandlerWrapped(cfg)))
+	mux.HandleFunc("GET /admin/audit/export", cfg.middlewareRequirePermission(auth.PermViewAudit, getAuditExportHandlerWrapped(cfg)))
+	mux.HandleFunc("GET /admin/audit/verify", cfg.middlewareRequirePermission(auth.PermViewAudit, getAuditVerifyHandlerWrapped(cfg)))
+	mux.HandleFunc("POST /api/users/{id}/follow", postFollowHandlerWrapped(cfg))
+	mux.HandleFunc("DELETE /api/users/{id}/follow", deleteFollowHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/users/{id}/followers", getFollowersHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/users/{id}/following", getFollowingHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/timeline/home", getHomeTimelineHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/chirps/{id}/thread", getThreadHandlerWrapped(cfg))
+	mux.HandleFunc("POST /api/chirps/{id}/like", postLikeHandlerWrapped(cfg))
+	mux.HandleFunc("DELETE /api/chirps/{id}/like", deleteLikeHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/users/{id}/likes", getUserLikesHandlerWrapped(cfg))
+	mux.HandleFunc("POST /api/chirps/{id}/rechirp", postRechirpHandlerWrapped(cfg))
+	mux.HandleFunc("DELETE /api/chirps/{id}/rechirp", deleteRechirpHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/tags/{tag}/chirps", getTagChirpsHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/trends", getTrendsHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/notifications", getNotificationsHandlerWrapped(cfg))
+	mux.HandleFunc("POST /api/notifications/read", postNotificationsReadHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/users/{handle}", getProfileHandlerWrapped(cfg))
+	mux.HandleFunc("PATCH /api/users/me", patchProfileHandlerWrapped(cfg))
+	mux.HandleFunc("POST /api/conversations", postConversationHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/conversations", getConversationsHandlerWrapped(cfg))
+	mux.HandleFunc("POST /api/conversations/{id}/messages", postMessageHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/conversations/{id}/messages", getMessagesHandlerWrapped(cfg))
+	mux.HandleFunc("POST /api/conversations/{id}/read", postConversationReadHandlerWrapped(cfg))
+	mux.HandleFunc("POST /api/users/{id}/block", postBlockHandlerWrapped(cfg))
+	mux.HandleFunc("DELETE /api/users/{id}/block", deleteBlockHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/blocks", getBlocksHandlerWrapped(cfg))
+	mux.HandleFunc("POST /api/users/{id}/mute", postMuteHandlerWrapped(cfg))
+	mux.HandleFunc("DELETE /api/users/{id}/mute", deleteMuteHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/mutes", getMutesHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/muted-words", getMutedWordsHandlerWrapped(cfg))
+	mux.HandleFunc("POST /api/muted-words", postMutedWordHandlerWrapped(cfg))
+	mux.HandleFunc("DELETE /api/muted-words/{id}", deleteMutedWordHandlerWrapped(cfg))
+	mux.HandleFunc("POST /api/chirps/{id}/bookmark", postBookmarkHandlerWrapped(cfg))
+	mux.HandleFunc("DELETE /api/chirps/{id}/bookmark", deleteBookmarkHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/bookmarks", getBookmarksHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/bookmarks/folders", getBookmarkFoldersHandlerWrapped(cfg))
+	mux.HandleFunc("POST /api/bookmarks/folders", postBookmarkFolderHandlerWrapped(cfg))
+	mux.HandleFunc("PUT /api/bookmarks/folders/{id}", putBookmarkFolderHandlerWrapped(cfg))
+	mux.HandleFunc("DELETE /api/bookmarks/folders/{id}", deleteBookmarkFolderHandlerWrapped(cfg))
+	mux.HandleFunc("POST /api/lists", postListHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/lists", getListsHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/lists/{id}", getListHandlerWrapped(cfg))
+	mux.HandleFunc("PUT /api/lists/{id}", putListHandlerWrapped(cfg))
+	mux.HandleFunc("DELETE /api/lists/{id}", deleteListHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/lists/{id}/members", getListMembersHandlerWrapped(cfg))
+	mux.HandleFunc("POST /api/lists/{id}/members/{userId}", postListMemberHandlerWrapped(cfg))
+	mux.HandleFunc("DELETE /api/lists/{id}/members/{userId}", deleteListMemberHandlerWrapped(cfg))
+	mux.HandleFunc("POST /api/lists/{id}/subscribe", postListSubscriptionHandlerWrapped(cfg))
+	mux.HandleFunc("DELETE /api/lists/{id}/subscribe", deleteListSubscriptionHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/lists/{id}/timeline", getListTimelineHandlerWrapped(cfg))
+	mux.HandleFunc("POST /api/chirps/{id}/poll/votes", postPollVoteHandlerWrapped(cfg))
+	mux.HandleFunc("POST /api/scheduled-chirps", postScheduledChirpHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/scheduled-chirps", getScheduledChirpsHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/scheduled-chirps/{id}", getScheduledChirpHandlerWrapped(cfg))
+	mux.HandleFunc("PUT /api/scheduled-chirps/{id}", putScheduledChirpHandlerWrapped(cfg))
+	mux.HandleFunc("DELETE /api/scheduled-chirps/{id}", deleteScheduledChirpHandlerWrapped(cfg))
+	mux.HandleFunc("POST /api/media", postMediaHandlerWrapped(cfg))
+	mux.HandleFunc("PUT /api/media/{id}", putMediaHandlerWrapped(cfg))
+	mux.HandleFunc("GET /api/media/files/{key...}", getMediaFileHandlerWrapped(cfg))
+	mux.HandleFunc("PUT /api/users/me/avatar", putAvatarHandlerWrapped(cfg))
+	mux.HandleFunc("DELETE /api/users/me/avatar", deleteAvatarHandlerWrapped(cfg))
+	mux.HandleFunc("PUT /api/users/me/banner", putBannerHandlerWrapped(cfg))
+	mux.HandleFunc("DELETE /api/users/me/banner", deleteBannerHandlerWrapped(cfg))
+	mux.HandleFunc("PUT /admin/chirps/{id}/content-warning", cfg.middlewareRequirePermission(auth.PermModerateContent, putChirpWarningHandlerWrapped(cfg)))
+	mux.HandleFunc("GET /api/users/me/preferences", getPreferencesHandlerWrapped(cfg))
+	mux.HandleFunc("PUT /api/users/me/preferences", putPreferencesHandlerWrapped(cfg))
 }
\ No newline at end of file
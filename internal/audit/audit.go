@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+
+// Genesis is the previous hash of the first entry of the log
+const Genesis = ""
+
+type Entry struct {
+	CreatedAt time.Time
+	Action string
+	ActorID string // empty for anonymous actions, e.g. failed logins and webhooks
+	TargetType string
+	TargetID string
+	IP string
+	UserAgent string
+	RequestID string
+	Metadata string
+}
+
+// Hash chains an entry to the one before it, so that editing or removing
+// an entry breaks the hash of every entry that follows.
+// The fields are encoded as a json array to keep them unambiguous
+func Hash(prevHash string, e *Entry) string {
+	fields, _ := json.Marshal([]string{
+		prevHash,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		e.Action,
+		e.ActorID,
+		e.TargetType,
+		e.TargetID,
+		e.IP,
+		e.UserAgent,
+		e.RequestID,
+		e.Metadata,
+	})
+
+	sum := sha256.Sum256(fields)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Verifier walks the log in insertion order checking every link of the chain,
+// it can be fed one page of entries at a time
+type Verifier struct {
+	prev string
+	Checked int64
+}
+
+func NewVerifier() *Verifier {
+	return &Verifier{
+		prev: Genesis,
+	}
+}
+
+// Check reports whether the entry follows the previous one and its hash
+// matches its content
+func (v *Verifier) Check(prevHash string, hash string, e *Entry) bool {
+	if prevHash != v.prev || Hash(prevHash, e) != hash {
+		return false
+	}
+
+	v.prev = hash
+	v.Checked++
+
+	return true
+}
@@ -43,16 +43,26 @@ func CheckPasswordHash(password string, hash string) *customErrors.CodedError {
 	return nil
 }
 
-func MakeJWT(userID uuid.UUID, tokenSecret string) (string, string, *customErrors.CodedError) {
+// Claims are the registered jwt claims plus the role of the user,
+// so that permissions can be checked without a database lookup
+type Claims struct {
+	Role Role `json:"role"`
+	jwt.RegisteredClaims
+}
+
+func MakeJWT(userID uuid.UUID, role Role, tokenSecret string) (string, string, *customErrors.CodedError) {
 	currTime := time.Now().UTC()
 	expiresIn := 60*60 // 1 hour jwt duration
 	token := jwt.NewWithClaims(
 		jwt.SigningMethodHS256,
-		jwt.RegisteredClaims{
-			Issuer: "chirpy",
-			IssuedAt: jwt.NewNumericDate(currTime),
-			ExpiresAt: jwt.NewNumericDate(currTime.Add(time.Duration(expiresIn)*time.Second)),
-			Subject: string(userID.String()),
+		Claims{
+			Role: role,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer: "chirpy",
+				IssuedAt: jwt.NewNumericDate(currTime),
+				ExpiresAt: jwt.NewNumericDate(currTime.Add(time.Duration(expiresIn)*time.Second)),
+				Subject: string(userID.String()),
+			},
 		},
 	)
 
@@ -84,8 +94,19 @@ func MakeJWT(userID uuid.UUID, tokenSecret string) (string, string, *customError
 }
 
 func ValidateJWT(tokenString string, tokenSecret string) (uuid.UUID, *customErrors.CodedError) {
+	id, _, errParse := ParseJWT(tokenString, tokenSecret)
+	if errParse != nil {
+		return uuid.UUID{}, errParse
+	}
+
+	return id, nil
+}
+
+// ParseJWT validates the token and returns the user id and role it carries,
+// tokens issued before roles existed are treated as plain users
+func ParseJWT(tokenString string, tokenSecret string) (uuid.UUID, Role, *customErrors.CodedError) {
 	token, errParseToken := jwt.ParseWithClaims(tokenString, 
-		&jwt.RegisteredClaims{}, 
+		&Claims{}, 
 		func(token *jwt.Token) (interface{}, error) {
 			_, ok := token.Method.(*jwt.SigningMethodHMAC)
 			if !ok {
@@ -109,7 +130,7 @@ func ValidateJWT(tokenString string, tokenSecret string) (uuid.UUID, *customErro
 				customErrors.GetFunctionName()).Error(),
 			StatusCode: http.StatusUnauthorized,
 		}
-		return uuid.UUID{}, &e
+		return uuid.UUID{}, "", &e
 	}
 
 	errValid := token.Claims.Valid()
@@ -121,10 +142,11 @@ func ValidateJWT(tokenString string, tokenSecret string) (uuid.UUID, *customErro
 			StatusCode: http.StatusUnauthorized,
 		}
 
-		return uuid.UUID{}, &e
+		return uuid.UUID{}, "", &e
 	}
 
-	id, errParseUUID := uuid.Parse(token.Claims.(*jwt.RegisteredClaims).Subject)
+	claims := token.Claims.(*Claims)
+	id, errParseUUID := uuid.Parse(claims.Subject)
     if errParseUUID != nil {
         e := customErrors.CodedError{
 			Message: fmt.Errorf("failed to parse string into UUID: %w, function: %s", 
@@ -133,10 +155,15 @@ func ValidateJWT(tokenString string, tokenSecret string) (uuid.UUID, *customErro
 			StatusCode: http.StatusInternalServerError,
 		}
 
-		return uuid.UUID{}, &e
+		return uuid.UUID{}, "", &e
     }
 
-	 return  id, nil	
+	role := claims.Role
+	if role == "" {
+		role = RoleUser
+	}
+
+	 return  id, role, nil	
 }
 
 func GetBearerToken(headers http.Header) (string, *customErrors.CodedError) {
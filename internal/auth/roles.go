@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/niccolot/Chirpy/internal/customErrors"
+)
+
+
+type Role string
+
+const (
+	RoleUser Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin Role = "admin"
+)
+
+type Permission string
+
+const (
+	PermViewMetrics Permission = "metrics:view"
+	PermResetDatabase Permission = "database:reset"
+	PermManageWordLists Permission = "wordlists:manage"
+	PermModerateContent Permission = "content:moderate"
+	PermManageRoles Permission = "roles:manage"
+	PermManageBlocklist Permission = "blocklist:manage"
+	PermViewAudit Permission = "audit:view"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleUser: {},
+	RoleModerator: {
+		PermModerateContent,
+	},
+	RoleAdmin: {
+		PermViewMetrics,
+		PermResetDatabase,
+		PermManageWordLists,
+		PermModerateContent,
+		PermManageRoles,
+		PermManageBlocklist,
+		PermViewAudit,
+	},
+}
+
+func ParseRole(role string) (Role, *customErrors.CodedError) {
+	r := Role(role)
+	_, ok := rolePermissions[r]
+	if !ok {
+		e := customErrors.CodedError{
+			Message: "role must be one of: user, moderator, admin",
+			StatusCode: http.StatusBadRequest,
+		}
+		return "", &e
+	}
+
+	return r, nil
+}
+
+func (r Role) Can(perm Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == perm {
+			return true
+		}
+	}
+
+	return false
+}
+
+func CheckPermission(role Role, perm Permission) *customErrors.CodedError {
+	if !role.Can(perm) {
+		e := customErrors.CodedError{
+			Message: "insufficient permissions",
+			StatusCode: http.StatusForbidden,
+		}
+		return &e
+	}
+
+	return nil
+}
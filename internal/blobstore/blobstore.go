@@ -0,0 +1,45 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore keeps the uploaded files. Keys are slash separated paths made
+// of letters, digits, dots, dashes and underscores, they never contain ".."
+type BlobStore interface {
+	Put(ctx context.Context, key string, contentType string, data []byte) error
+	// Get returns ErrNotFound for a missing key, the caller closes the reader
+	Get(ctx context.Context, key string) (io.ReadCloser, string, error)
+	// Delete succeeds for a missing key too
+	Delete(ctx context.Context, key string) error
+}
+
+// ValidKey tells if key can be used with every store
+func ValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+		return false
+	}
+
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+
+	for _, c := range key {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '/' || c == '.' || c == '-' || c == '_':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,105 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+)
+
+
+// Local keeps the blobs in a directory of the server, the content type is
+// guessed back from the extension of the key
+type Local struct {
+	dir string
+}
+
+func NewLocal(dir string) (*Local, error) {
+	errDir := os.MkdirAll(dir, 0o755)
+	if errDir != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", errDir)
+	}
+
+	return &Local{dir: dir}, nil
+}
+
+func (l *Local) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+
+	return filepath.Join(l.dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temporary file renamed in place, so that readers never
+// see a partial blob
+func (l *Local) Put(ctx context.Context, key string, contentType string, data []byte) error {
+	path, errPath := l.path(key)
+	if errPath != nil {
+		return errPath
+	}
+
+	errDir := os.MkdirAll(filepath.Dir(path), 0o755)
+	if errDir != nil {
+		return fmt.Errorf("failed to create blob directory: %w", errDir)
+	}
+
+	tmp, errTmp := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if errTmp != nil {
+		return fmt.Errorf("failed to create blob: %w", errTmp)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, errWrite := tmp.Write(data)
+	errClose := tmp.Close()
+	if errWrite != nil || errClose != nil {
+		return fmt.Errorf("failed to write blob: %w", errors.Join(errWrite, errClose))
+	}
+
+	errRename := os.Rename(tmp.Name(), path)
+	if errRename != nil {
+		return fmt.Errorf("failed to write blob: %w", errRename)
+	}
+
+	return nil
+}
+
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, string, error) {
+	path, errPath := l.path(key)
+	if errPath != nil {
+		return nil, "", ErrNotFound
+	}
+
+	f, errOpen := os.Open(path)
+	if errors.Is(errOpen, fs.ErrNotExist) {
+		return nil, "", ErrNotFound
+	}
+	if errOpen != nil {
+		return nil, "", fmt.Errorf("failed to open blob: %w", errOpen)
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return f, contentType, nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	path, errPath := l.path(key)
+	if errPath != nil {
+		return errPath
+	}
+
+	errRemove := os.Remove(path)
+	if errRemove != nil && !errors.Is(errRemove, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete blob: %w", errRemove)
+	}
+
+	return nil
+}
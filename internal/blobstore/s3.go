@@ -0,0 +1,192 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+
+// S3Config points to a bucket of any S3 compatible service, Endpoint is
+// the base url of the service, e.g. https://s3.eu-west-1.amazonaws.com or
+// http://localhost:9000 for a local MinIO. Buckets are addressed in the
+// path, which every compatible service supports
+type S3Config struct {
+	Endpoint string
+	Region string
+	Bucket string
+	AccessKey string
+	SecretKey string
+}
+
+// S3 signs its requests with AWS signature version 4
+type S3 struct {
+	config S3Config
+	client *http.Client
+}
+
+func NewS3(config S3Config) (*S3, error) {
+	if config.Endpoint == "" || config.Bucket == "" || config.AccessKey == "" || config.SecretKey == "" {
+		return nil, fmt.Errorf("s3 store needs an endpoint, a bucket and credentials")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+
+	_, errURL := url.Parse(config.Endpoint)
+	if errURL != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", errURL)
+	}
+
+	return &S3{
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, contentType string, data []byte) error {
+	res, errDo := s.do(ctx, http.MethodPut, key, contentType, data)
+	if errDo != nil {
+		return errDo
+	}
+	defer res.Body.Close()
+
+	// compatible services answer a put with 200 or 204
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return s.statusError("put", key, res)
+	}
+
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, string, error) {
+	res, errDo := s.do(ctx, http.MethodGet, key, "", nil)
+	if errDo != nil {
+		return nil, "", errDo
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil, "", ErrNotFound
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, "", s.statusError("get", key, res)
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return res.Body, contentType, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	res, errDo := s.do(ctx, http.MethodDelete, key, "", nil)
+	if errDo != nil {
+		return errDo
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+		return s.statusError("delete", key, res)
+	}
+
+	return nil
+}
+
+func (s *S3) statusError(op string, key string, res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+
+	return fmt.Errorf("s3 %s %s: status %d: %s", op, key, res.StatusCode, strings.TrimSpace(string(body)))
+}
+
+func (s *S3) do(ctx context.Context, method string, key string, contentType string, data []byte) (*http.Response, error) {
+	if !ValidKey(key) {
+		return nil, fmt.Errorf("invalid blob key %q", key)
+	}
+
+	path := "/" + s.config.Bucket + "/" + key
+	req, errReq := http.NewRequestWithContext(ctx, method, s.config.Endpoint+path, bytes.NewReader(data))
+	if errReq != nil {
+		return nil, fmt.Errorf("failed to build s3 request: %w", errReq)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	s.sign(req, data, time.Now().UTC())
+
+	res, errDo := s.client.Do(req)
+	if errDo != nil {
+		return nil, fmt.Errorf("s3 %s %s: %w", strings.ToLower(method), key, errDo)
+	}
+
+	return res, nil
+}
+
+// sign adds the headers of signature version 4, the signed headers are
+// the minimum S3 asks for
+func (s *S3) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
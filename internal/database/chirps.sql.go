@@ -7,29 +7,75 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const chirpHasReplies = `-- name: ChirpHasReplies :one
+SELECT EXISTS (
+    SELECT 1 FROM chirps
+    WHERE in_reply_to = $1
+)
+`
+
+func (q *Queries) ChirpHasReplies(ctx context.Context, inReplyTo uuid.NullUUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, chirpHasReplies, inReplyTo)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const clearChirpReview = `-- name: ClearChirpReview :exec
+UPDATE chirps
+SET needs_review = false
+WHERE id = $1
+`
+
+func (q *Queries) ClearChirpReview(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, clearChirpReview, id)
+	return err
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, needs_review, body_hash, simhash, in_reply_to, root_id, quote_of, visibility, content_warning, sensitive)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, needs_review, body_hash, simhash, fanned_out, in_reply_to, root_id, deleted_at, rechirp_of, quote_of, visibility, content_warning, sensitive, forced_content_warning, forced_sensitive
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body           string
+	UserID         uuid.UUID
+	NeedsReview    bool
+	BodyHash       string
+	Simhash        int64
+	InReplyTo      uuid.NullUUID
+	RootID         uuid.NullUUID
+	QuoteOf        uuid.NullUUID
+	Visibility     string
+	ContentWarning string
+	Sensitive      bool
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID, arg.NeedsReview, arg.BodyHash, arg.Simhash, arg.InReplyTo, arg.RootID, arg.QuoteOf, arg.Visibility, arg.ContentWarning, arg.Sensitive)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,27 +83,66 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.NeedsReview,
+		&i.BodyHash,
+		&i.Simhash,
+		&i.FannedOut,
+		&i.InReplyTo,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ForcedContentWarning,
+		&i.ForcedSensitive,
 	)
 	return i, err
 }
 
-const deleteChirp = `-- name: DeleteChirp :exec
-DELETE from chirps
-WHERE id = $1 AND user_id = $2
+const deleteChirpById = `-- name: DeleteChirpById :exec
+DELETE FROM chirps
+WHERE id = $1
 `
 
-type DeleteChirpParams struct {
-	ID     uuid.UUID
-	UserID uuid.UUID
+func (q *Queries) DeleteChirpById(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteChirpById, id)
+	return err
 }
 
-func (q *Queries) DeleteChirp(ctx context.Context, arg DeleteChirpParams) error {
-	_, err := q.db.ExecContext(ctx, deleteChirp, arg.ID, arg.UserID)
-	return err
+const forceChirpWarning = `-- name: ForceChirpWarning :execrows
+UPDATE chirps
+SET forced_content_warning = $1,
+    forced_sensitive = $2
+WHERE id = $3
+  AND deleted_at IS null
+  AND rechirp_of IS null
+`
+
+type ForceChirpWarningParams struct {
+	ForcedContentWarning string
+	ForcedSensitive      bool
+	ID                   uuid.UUID
+}
+
+func (q *Queries) ForceChirpWarning(ctx context.Context, arg ForceChirpWarningParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, forceChirpWarning, arg.ForcedContentWarning, arg.ForcedSensitive, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getAllChirpsAsc = `-- name: GetAllChirpsAsc :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, needs_review, body_hash, simhash, fanned_out, in_reply_to, root_id, deleted_at, rechirp_of, quote_of, visibility, content_warning, sensitive, forced_content_warning, forced_sensitive FROM chirps
+WHERE deleted_at IS null
+  AND NOT EXISTS (
+    SELECT 1 FROM suspensions
+    WHERE suspensions.user_id = chirps.user_id
+      AND suspensions.lifted_at IS null
+      AND (suspensions.expires_at IS null OR suspensions.expires_at > NOW())
+)
 ORDER BY created_at ASC
 `
 
@@ -76,6 +161,20 @@ func (q *Queries) GetAllChirpsAsc(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.NeedsReview,
+			&i.BodyHash,
+			&i.Simhash,
+			&i.FannedOut,
+			&i.InReplyTo,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ForcedContentWarning,
+			&i.ForcedSensitive,
 		); err != nil {
 			return nil, err
 		}
@@ -91,7 +190,14 @@ func (q *Queries) GetAllChirpsAsc(ctx context.Context) ([]Chirp, error) {
 }
 
 const getAllChirpsDesc = `-- name: GetAllChirpsDesc :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, needs_review, body_hash, simhash, fanned_out, in_reply_to, root_id, deleted_at, rechirp_of, quote_of, visibility, content_warning, sensitive, forced_content_warning, forced_sensitive FROM chirps
+WHERE deleted_at IS null
+  AND NOT EXISTS (
+    SELECT 1 FROM suspensions
+    WHERE suspensions.user_id = chirps.user_id
+      AND suspensions.lifted_at IS null
+      AND (suspensions.expires_at IS null OR suspensions.expires_at > NOW())
+)
 ORDER BY created_at DESC
 `
 
@@ -110,6 +216,20 @@ func (q *Queries) GetAllChirpsDesc(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.NeedsReview,
+			&i.BodyHash,
+			&i.Simhash,
+			&i.FannedOut,
+			&i.InReplyTo,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ForcedContentWarning,
+			&i.ForcedSensitive,
 		); err != nil {
 			return nil, err
 		}
@@ -125,7 +245,7 @@ func (q *Queries) GetAllChirpsDesc(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirp = `-- name: GetChirp :one
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, needs_review, body_hash, simhash, fanned_out, in_reply_to, root_id, deleted_at, rechirp_of, quote_of, visibility, content_warning, sensitive, forced_content_warning, forced_sensitive
 FROM chirps
 WHERE id = $1
 `
@@ -139,13 +259,218 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.NeedsReview,
+		&i.BodyHash,
+		&i.Simhash,
+		&i.FannedOut,
+		&i.InReplyTo,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ForcedContentWarning,
+		&i.ForcedSensitive,
 	)
 	return i, err
 }
 
+const getChirpAncestors = `-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT parent.id, parent.in_reply_to, 1 AS depth
+    FROM chirps AS child
+    JOIN chirps AS parent ON parent.id = child.in_reply_to
+    WHERE child.id = $1
+    UNION ALL
+    SELECT parent.id, parent.in_reply_to, ancestors.depth + 1
+    FROM ancestors
+    JOIN chirps AS parent ON parent.id = ancestors.in_reply_to
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.needs_review, chirps.body_hash, chirps.simhash, chirps.fanned_out, chirps.in_reply_to, chirps.root_id, chirps.deleted_at, chirps.rechirp_of, chirps.quote_of, chirps.visibility, chirps.content_warning, chirps.sensitive, chirps.forced_content_warning, chirps.forced_sensitive FROM chirps
+JOIN ancestors ON ancestors.id = chirps.id
+ORDER BY ancestors.depth DESC
+`
+
+func (q *Queries) GetChirpAncestors(ctx context.Context, id uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpAncestors, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.NeedsReview,
+			&i.BodyHash,
+			&i.Simhash,
+			&i.FannedOut,
+			&i.InReplyTo,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ForcedContentWarning,
+			&i.ForcedSensitive,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpDescendants = `-- name: GetChirpDescendants :many
+WITH RECURSIVE descendants AS (
+    SELECT chirps.id, 1 AS depth
+    FROM chirps
+    WHERE chirps.in_reply_to = ANY($1::uuid[])
+    UNION ALL
+    SELECT chirps.id, descendants.depth + 1
+    FROM descendants
+    JOIN chirps ON chirps.in_reply_to = descendants.id
+    WHERE descendants.depth < $2::int
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.needs_review, chirps.body_hash, chirps.simhash, chirps.fanned_out, chirps.in_reply_to, chirps.root_id, chirps.deleted_at, chirps.rechirp_of, chirps.quote_of, chirps.visibility, chirps.content_warning, chirps.sensitive, chirps.forced_content_warning, chirps.forced_sensitive FROM chirps
+JOIN descendants ON descendants.id = chirps.id
+ORDER BY chirps.created_at ASC, chirps.id ASC
+LIMIT $3
+`
+
+type GetChirpDescendantsParams struct {
+	ParentIds  []uuid.UUID
+	MaxDepth   int32
+	MaxEntries int32
+}
+
+func (q *Queries) GetChirpDescendants(ctx context.Context, arg GetChirpDescendantsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpDescendants, pq.Array(arg.ParentIds), arg.MaxDepth, arg.MaxEntries)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.NeedsReview,
+			&i.BodyHash,
+			&i.Simhash,
+			&i.FannedOut,
+			&i.InReplyTo,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ForcedContentWarning,
+			&i.ForcedSensitive,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpReplies = `-- name: GetChirpReplies :many
+SELECT id, created_at, updated_at, body, user_id, needs_review, body_hash, simhash, fanned_out, in_reply_to, root_id, deleted_at, rechirp_of, quote_of, visibility, content_warning, sensitive, forced_content_warning, forced_sensitive FROM chirps
+WHERE in_reply_to = $1
+  AND ($2::timestamp IS null
+    OR (created_at, id) > ($2::timestamp, $3::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT $4
+`
+
+type GetChirpRepliesParams struct {
+	ID         uuid.UUID
+	AfterTime  sql.NullTime
+	AfterID    uuid.NullUUID
+	MaxEntries int32
+}
+
+func (q *Queries) GetChirpReplies(ctx context.Context, arg GetChirpRepliesParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpReplies, arg.ID, arg.AfterTime, arg.AfterID, arg.MaxEntries)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.NeedsReview,
+			&i.BodyHash,
+			&i.Simhash,
+			&i.FannedOut,
+			&i.InReplyTo,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ForcedContentWarning,
+			&i.ForcedSensitive,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChirpsFromAuthorAsc = `-- name: GetChirpsFromAuthorAsc :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, needs_review, body_hash, simhash, fanned_out, in_reply_to, root_id, deleted_at, rechirp_of, quote_of, visibility, content_warning, sensitive, forced_content_warning, forced_sensitive FROM chirps
 WHERE user_id = $1
+  AND deleted_at IS null
+  AND NOT EXISTS (
+      SELECT 1 FROM suspensions
+      WHERE suspensions.user_id = chirps.user_id
+        AND suspensions.lifted_at IS null
+        AND (suspensions.expires_at IS null OR suspensions.expires_at > NOW())
+  )
 ORDER BY created_at ASC
 `
 
@@ -164,6 +489,20 @@ func (q *Queries) GetChirpsFromAuthorAsc(ctx context.Context, userID uuid.UUID)
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.NeedsReview,
+			&i.BodyHash,
+			&i.Simhash,
+			&i.FannedOut,
+			&i.InReplyTo,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ForcedContentWarning,
+			&i.ForcedSensitive,
 		); err != nil {
 			return nil, err
 		}
@@ -179,8 +518,15 @@ func (q *Queries) GetChirpsFromAuthorAsc(ctx context.Context, userID uuid.UUID)
 }
 
 const getChirpsFromAuthorDesc = `-- name: GetChirpsFromAuthorDesc :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, needs_review, body_hash, simhash, fanned_out, in_reply_to, root_id, deleted_at, rechirp_of, quote_of, visibility, content_warning, sensitive, forced_content_warning, forced_sensitive FROM chirps
 WHERE user_id = $1
+  AND deleted_at IS null
+  AND NOT EXISTS (
+      SELECT 1 FROM suspensions
+      WHERE suspensions.user_id = chirps.user_id
+        AND suspensions.lifted_at IS null
+        AND (suspensions.expires_at IS null OR suspensions.expires_at > NOW())
+  )
 ORDER BY created_at DESC
 `
 
@@ -199,6 +545,20 @@ func (q *Queries) GetChirpsFromAuthorDesc(ctx context.Context, userID uuid.UUID)
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.NeedsReview,
+			&i.BodyHash,
+			&i.Simhash,
+			&i.FannedOut,
+			&i.InReplyTo,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ForcedContentWarning,
+			&i.ForcedSensitive,
 		); err != nil {
 			return nil, err
 		}
@@ -213,18 +573,347 @@ func (q *Queries) GetChirpsFromAuthorDesc(ctx context.Context, userID uuid.UUID)
 	return items, nil
 }
 
-const updateChirp = `-- name: UpdateChirp :exec
+const getChirpsNeedingReview = `-- name: GetChirpsNeedingReview :many
+SELECT id, created_at, updated_at, body, user_id, needs_review, body_hash, simhash, fanned_out, in_reply_to, root_id, deleted_at, rechirp_of, quote_of, visibility, content_warning, sensitive, forced_content_warning, forced_sensitive FROM chirps
+WHERE needs_review = true
+  AND deleted_at IS null
+ORDER BY created_at ASC
+`
+
+func (q *Queries) GetChirpsNeedingReview(ctx context.Context) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsNeedingReview)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.NeedsReview,
+			&i.BodyHash,
+			&i.Simhash,
+			&i.FannedOut,
+			&i.InReplyTo,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ForcedContentWarning,
+			&i.ForcedSensitive,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecentChirpFingerprints = `-- name: GetRecentChirpFingerprints :many
+SELECT body_hash, simhash, created_at
+FROM chirps
+WHERE user_id = $1 AND created_at > $2
+  AND rechirp_of IS null
+ORDER BY created_at DESC
+LIMIT 200
+`
+
+type GetRecentChirpFingerprintsParams struct {
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type GetRecentChirpFingerprintsRow struct {
+	BodyHash  string
+	Simhash   int64
+	CreatedAt time.Time
+}
+
+func (q *Queries) GetRecentChirpFingerprints(ctx context.Context, arg GetRecentChirpFingerprintsParams) ([]GetRecentChirpFingerprintsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentChirpFingerprints, arg.UserID, arg.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRecentChirpFingerprintsRow
+	for rows.Next() {
+		var i GetRecentChirpFingerprintsRow
+		if err := rows.Scan(
+			&i.BodyHash,
+			&i.Simhash,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReplyCounts = `-- name: GetReplyCounts :many
+SELECT in_reply_to AS chirp_id, count(*) AS reply_count
+FROM chirps
+WHERE in_reply_to = ANY($1::uuid[])
+  AND deleted_at IS null
+GROUP BY in_reply_to
+`
+
+type GetReplyCountsRow struct {
+	ChirpID    uuid.NullUUID
+	ReplyCount int64
+}
+
+func (q *Queries) GetReplyCounts(ctx context.Context, chirpIds []uuid.UUID) ([]GetReplyCountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getReplyCounts, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetReplyCountsRow
+	for rows.Next() {
+		var i GetReplyCountsRow
+		if err := rows.Scan(
+			&i.ChirpID,
+			&i.ReplyCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getVisibleChirp = `-- name: GetVisibleChirp :one
+SELECT id, created_at, updated_at, body, user_id, needs_review, body_hash, simhash, fanned_out, in_reply_to, root_id, deleted_at, rechirp_of, quote_of, visibility, content_warning, sensitive, forced_content_warning, forced_sensitive
+FROM chirps
+WHERE id = $1
+  AND deleted_at IS null
+  AND NOT EXISTS (
+      SELECT 1 FROM suspensions
+      WHERE suspensions.user_id = chirps.user_id
+        AND suspensions.lifted_at IS null
+        AND (suspensions.expires_at IS null OR suspensions.expires_at > NOW())
+  )
+`
+
+func (q *Queries) GetVisibleChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getVisibleChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.NeedsReview,
+		&i.BodyHash,
+		&i.Simhash,
+		&i.FannedOut,
+		&i.InReplyTo,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ForcedContentWarning,
+		&i.ForcedSensitive,
+	)
+	return i, err
+}
+
+const getVisibleChirpsByIds = `-- name: GetVisibleChirpsByIds :many
+SELECT id, created_at, updated_at, body, user_id, needs_review, body_hash, simhash, fanned_out, in_reply_to, root_id, deleted_at, rechirp_of, quote_of, visibility, content_warning, sensitive, forced_content_warning, forced_sensitive FROM chirps
+WHERE id = ANY($1::uuid[])
+  AND deleted_at IS null
+  AND NOT EXISTS (
+      SELECT 1 FROM suspensions
+      WHERE suspensions.user_id = chirps.user_id
+        AND suspensions.lifted_at IS null
+        AND (suspensions.expires_at IS null OR suspensions.expires_at > NOW())
+  )
+`
+
+func (q *Queries) GetVisibleChirpsByIds(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getVisibleChirpsByIds, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.NeedsReview,
+			&i.BodyHash,
+			&i.Simhash,
+			&i.FannedOut,
+			&i.InReplyTo,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ForcedContentWarning,
+			&i.ForcedSensitive,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setChirpVisibility = `-- name: SetChirpVisibility :execrows
 UPDATE chirps
-SET body = $2, updated_at = NOW()
+SET visibility = $1,
+    updated_at = NOW()
+WHERE id = $2
+  AND (NOT $3::bool
+    OR NOT EXISTS (SELECT 1 FROM chirps replies WHERE replies.in_reply_to = chirps.id))
+`
+
+type SetChirpVisibilityParams struct {
+	Visibility string
+	ID         uuid.UUID
+	Restricts  bool
+}
+
+func (q *Queries) SetChirpVisibility(ctx context.Context, arg SetChirpVisibilityParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setChirpVisibility, arg.Visibility, arg.ID, arg.Restricts)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const softDeleteChirp = `-- name: SoftDeleteChirp :exec
+UPDATE chirps
+SET body = '', body_hash = '', deleted_at = NOW(), updated_at = NOW()
 WHERE id = $1
 `
 
+func (q *Queries) SoftDeleteChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, softDeleteChirp, id)
+	return err
+}
+
+const tombstoneUserChirps = `-- name: TombstoneUserChirps :many
+WITH RECURSIVE kept AS (
+    SELECT chirps.id, chirps.in_reply_to FROM chirps
+    WHERE chirps.user_id = $1
+      AND EXISTS (
+          SELECT 1 FROM chirps AS replies
+          WHERE replies.in_reply_to = chirps.id
+            AND replies.user_id <> $1
+      )
+    UNION
+    SELECT chirps.id, chirps.in_reply_to FROM chirps
+    JOIN kept ON kept.in_reply_to = chirps.id
+    WHERE chirps.user_id = $1
+)
+UPDATE chirps
+SET user_id = $2,
+    body = '',
+    body_hash = '',
+    content_warning = '',
+    deleted_at = COALESCE(deleted_at, NOW()),
+    updated_at = NOW()
+WHERE id IN (SELECT id FROM kept)
+RETURNING id
+`
+
+type TombstoneUserChirpsParams struct {
+	UserID        uuid.UUID
+	DeletedUserID uuid.UUID
+}
+
+func (q *Queries) TombstoneUserChirps(ctx context.Context, arg TombstoneUserChirpsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, tombstoneUserChirps, arg.UserID, arg.DeletedUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateChirp = `-- name: UpdateChirp :exec
+UPDATE chirps
+SET body = $1,
+    needs_review = needs_review OR $2::bool,
+    body_hash = $3,
+    simhash = $4,
+    content_warning = $5,
+    sensitive = $6,
+    updated_at = NOW()
+WHERE id = $7
+`
+
 type UpdateChirpParams struct {
-	ID   uuid.UUID
-	Body string
+	Body           string
+	FlagForReview  bool
+	BodyHash       string
+	Simhash        int64
+	ContentWarning string
+	Sensitive      bool
+	ID             uuid.UUID
 }
 
 func (q *Queries) UpdateChirp(ctx context.Context, arg UpdateChirpParams) error {
-	_, err := q.db.ExecContext(ctx, updateChirp, arg.ID, arg.Body)
+	_, err := q.db.ExecContext(ctx, updateChirp, arg.Body, arg.FlagForReview, arg.BodyHash, arg.Simhash, arg.ContentWarning, arg.Sensitive, arg.ID)
 	return err
 }
@@ -11,12 +11,211 @@ import (
 	"github.com/google/uuid"
 )
 
+type AuditLog struct {
+	ID         int64
+	CreatedAt  time.Time
+	Action     string
+	ActorID    uuid.NullUUID
+	TargetType string
+	TargetID   string
+	Ip         string
+	UserAgent  string
+	RequestID  string
+	Metadata   string
+	PrevHash   string
+	Hash       string
+}
+
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	FolderID  uuid.NullUUID
+	CreatedAt time.Time
+}
+
+type BookmarkFolder struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
+}
+
 type Chirp struct {
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Body                 string
+	UserID               uuid.UUID
+	NeedsReview          bool
+	BodyHash             string
+	Simhash              int64
+	FannedOut            bool
+	InReplyTo            uuid.NullUUID
+	RootID               uuid.NullUUID
+	DeletedAt            sql.NullTime
+	RechirpOf            uuid.NullUUID
+	QuoteOf              uuid.NullUUID
+	Visibility           string
+	ContentWarning       string
+	Sensitive            bool
+	ForcedContentWarning string
+	ForcedSensitive      bool
+}
+
+type ChirpMention struct {
+	ChirpID uuid.UUID
+	UserID  uuid.UUID
+}
+
+type ChirpTag struct {
+	ChirpID   uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type Conversation struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	CreatedBy uuid.NullUUID
+	DirectKey sql.NullString
+}
+
+type ConversationParticipant struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	JoinedAt       time.Time
+	LastReadAt     sql.NullTime
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type HomeTimeline struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	AuthorID  uuid.UUID
+	CreatedAt time.Time
+}
+
+type Like struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type List struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	OwnerID     uuid.UUID
+	Name        string
+	Description string
+	Private     bool
+}
+
+type ListMember struct {
+	ListID    uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type ListSubscription struct {
+	ListID    uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type Medium struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UserID       uuid.NullUUID
+	ChirpID      uuid.NullUUID
+	Position     int32
+	ContentType  string
+	Width        int32
+	Height       int32
+	Size         int64
+	AltText      string
+	Blurhash     string
+	OriginalKey  string
+	ThumbnailKey string
+}
+
+type Message struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+	BodyHash       string
+	Simhash        int64
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt sql.NullTime
+}
+
+type MutedWord struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Phrase    string
+	Scope     string
+	Action    string
+	WholeWord bool
+	ExpiresAt sql.NullTime
+}
+
+type Notification struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Body      string
 	UserID    uuid.UUID
+	Kind      string
+	ChirpID   uuid.NullUUID
+	GroupKey  string
+	ReadAt    sql.NullTime
+}
+
+type NotificationActor struct {
+	NotificationID uuid.UUID
+	ActorID        uuid.UUID
+	CreatedAt      time.Time
+}
+
+type Poll struct {
+	ChirpID     uuid.UUID
+	CreatedAt   time.Time
+	ClosesAt    time.Time
+	FinalizedAt sql.NullTime
+}
+
+type PollOption struct {
+	ID        uuid.UUID
+	ChirpID   uuid.UUID
+	Position  int32
+	Text      string
+	VoteCount int64
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	OptionID  uuid.UUID
+	CreatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -28,11 +227,91 @@ type RefreshToken struct {
 	RevokedAt sql.NullString
 }
 
-type User struct {
+type Report struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	ChirpID        uuid.NullUUID
+	ChirpAuthorID  uuid.UUID
+	ChirpBody      string
+	ReporterID     uuid.UUID
+	Reason         string
+	Details        string
+	Status         string
+	ResolutionNote sql.NullString
+	ResolvedBy     uuid.NullUUID
+	ResolvedAt     sql.NullTime
+}
+
+type ScheduledChirp struct {
 	ID             uuid.UUID
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
-	Email          string
-	HashedPassword string
-	IsChirpyRed    bool
+	UserID         uuid.UUID
+	Body           string
+	InReplyTo      uuid.NullUUID
+	QuoteOf        uuid.NullUUID
+	PublishAt      sql.NullTime
+	LastError      sql.NullString
+	Visibility     string
+	ContentWarning string
+	Sensitive      bool
+	Attempts       int32
+}
+
+type Suspension struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UserID      uuid.UUID
+	ModeratorID uuid.NullUUID
+	Reason      string
+	ExpiresAt   sql.NullTime
+	LiftedAt    sql.NullTime
+	LiftedBy    uuid.NullUUID
+}
+
+type TrendingTag struct {
+	Tag        string
+	Score      float64
+	ChirpCount int64
+	ComputedAt time.Time
+}
+
+type UrlBlocklist struct {
+	Domain    string
+	CreatedAt time.Time
+	CreatedBy uuid.NullUUID
+}
+
+type User struct {
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Email                string
+	HashedPassword       string
+	IsChirpyRed          bool
+	Role                 string
+	Handle               sql.NullString
+	DisplayName          string
+	Bio                  string
+	Location             string
+	Website              string
+	HandleChangedAt      sql.NullTime
+	AvatarKey            sql.NullString
+	BannerKey            sql.NullString
+	ExpandFlaggedContent bool
+}
+
+type WordList struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Name      string
+	Action    string
+}
+
+type WordListEntry struct {
+	ListID    uuid.UUID
+	Word      string
+	CreatedAt time.Time
 }
@@ -78,6 +78,18 @@ func (q *Queries) GetUserFromRefreshToken(ctx context.Context, token string) (uu
 	return user_id, err
 }
 
+const revokeAllUserTokens = `-- name: RevokeAllUserTokens :exec
+UPDATE refresh_tokens
+SET updated_at = NOW(), revoked_at = NOW()
+WHERE user_id = $1
+  AND revoked_at IS null
+`
+
+func (q *Queries) RevokeAllUserTokens(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeAllUserTokens, userID)
+	return err
+}
+
 const revokeToken = `-- name: RevokeToken :exec
 UPDATE refresh_tokens
 SET updated_at = NOW(), revoked_at = NOW()
@@ -7,6 +7,8 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -20,7 +22,7 @@ VALUES (
     $1,
     $2
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, handle, display_name, bio, location, website, handle_changed_at, avatar_key, banner_key, expand_flagged_content
 `
 
 type CreateUserParams struct {
@@ -38,22 +40,43 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Role,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.HandleChangedAt,
+		&i.AvatarKey,
+		&i.BannerKey,
+		&i.ExpandFlaggedContent,
 	)
 	return i, err
 }
 
-const deleteUser = `-- name: DeleteUser :exec
+const deleteUser = `-- name: DeleteUser :one
 DELETE FROM users
 WHERE id = $1
+RETURNING avatar_key, banner_key
 `
 
-func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, deleteUser, id)
-	return err
+type DeleteUserRow struct {
+	AvatarKey sql.NullString
+	BannerKey sql.NullString
+}
+
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) (DeleteUserRow, error) {
+	row := q.db.QueryRowContext(ctx, deleteUser, id)
+	var i DeleteUserRow
+	err := row.Scan(
+		&i.AvatarKey,
+		&i.BannerKey,
+	)
+	return i, err
 }
 
 const findUserByEmail = `-- name: FindUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, handle, display_name, bio, location, website, handle_changed_at, avatar_key, banner_key, expand_flagged_content FROM users
 WHERE email = $1
 `
 
@@ -67,12 +90,51 @@ func (q *Queries) FindUserByEmail(ctx context.Context, email string) (User, erro
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Role,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.HandleChangedAt,
+		&i.AvatarKey,
+		&i.BannerKey,
+		&i.ExpandFlaggedContent,
+	)
+	return i, err
+}
+
+const findUserByHandle = `-- name: FindUserByHandle :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, handle, display_name, bio, location, website, handle_changed_at, avatar_key, banner_key, expand_flagged_content FROM users
+WHERE lower(handle) = lower($1)
+`
+
+func (q *Queries) FindUserByHandle(ctx context.Context, lower string) (User, error) {
+	row := q.db.QueryRowContext(ctx, findUserByHandle, lower)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Role,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.HandleChangedAt,
+		&i.AvatarKey,
+		&i.BannerKey,
+		&i.ExpandFlaggedContent,
 	)
 	return i, err
 }
 
 const findUserById = `-- name: FindUserById :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, handle, display_name, bio, location, website, handle_changed_at, avatar_key, banner_key, expand_flagged_content FROM users
 WHERE id = $1
 `
 
@@ -86,16 +148,124 @@ func (q *Queries) FindUserById(ctx context.Context, id uuid.UUID) (User, error)
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Role,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.HandleChangedAt,
+		&i.AvatarKey,
+		&i.BannerKey,
+		&i.ExpandFlaggedContent,
 	)
 	return i, err
 }
 
 const reset = `-- name: Reset :exec
-DELETE FROM users CASCADE
+WITH tombstones AS (
+    DELETE FROM chirps
+    WHERE user_id = $1
+)
+DELETE FROM users
+WHERE id <> $1
+`
+
+func (q *Queries) Reset(ctx context.Context, deletedUserID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, reset, deletedUserID)
+	return err
+}
+
+const setUserAvatar = `-- name: SetUserAvatar :one
+UPDATE users
+SET avatar_key = $2, updated_at = NOW()
+FROM (SELECT id, avatar_key FROM users WHERE id = $1 FOR UPDATE) old
+WHERE users.id = old.id
+RETURNING old.avatar_key
+`
+
+type SetUserAvatarParams struct {
+	ID        uuid.UUID
+	AvatarKey sql.NullString
+}
+
+func (q *Queries) SetUserAvatar(ctx context.Context, arg SetUserAvatarParams) (sql.NullString, error) {
+	row := q.db.QueryRowContext(ctx, setUserAvatar, arg.ID, arg.AvatarKey)
+	var avatar_key sql.NullString
+	err := row.Scan(&avatar_key)
+	return avatar_key, err
+}
+
+const setUserBanner = `-- name: SetUserBanner :one
+UPDATE users
+SET banner_key = $2, updated_at = NOW()
+FROM (SELECT id, banner_key FROM users WHERE id = $1 FOR UPDATE) old
+WHERE users.id = old.id
+RETURNING old.banner_key
 `
 
-func (q *Queries) Reset(ctx context.Context) error {
-	_, err := q.db.ExecContext(ctx, reset)
+type SetUserBannerParams struct {
+	ID        uuid.UUID
+	BannerKey sql.NullString
+}
+
+func (q *Queries) SetUserBanner(ctx context.Context, arg SetUserBannerParams) (sql.NullString, error) {
+	row := q.db.QueryRowContext(ctx, setUserBanner, arg.ID, arg.BannerKey)
+	var banner_key sql.NullString
+	err := row.Scan(&banner_key)
+	return banner_key, err
+}
+
+const setUserExpandFlaggedContent = `-- name: SetUserExpandFlaggedContent :exec
+UPDATE users
+SET expand_flagged_content = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetUserExpandFlaggedContentParams struct {
+	ID                   uuid.UUID
+	ExpandFlaggedContent bool
+}
+
+func (q *Queries) SetUserExpandFlaggedContent(ctx context.Context, arg SetUserExpandFlaggedContentParams) error {
+	_, err := q.db.ExecContext(ctx, setUserExpandFlaggedContent, arg.ID, arg.ExpandFlaggedContent)
+	return err
+}
+
+const setUserHandle = `-- name: SetUserHandle :execrows
+UPDATE users
+SET handle = $1, handle_changed_at = NOW(), updated_at = NOW()
+WHERE id = $2
+  AND (handle_changed_at IS null OR handle_changed_at < $3)
+`
+
+type SetUserHandleParams struct {
+	Handle        sql.NullString
+	ID            uuid.UUID
+	CooldownStart time.Time
+}
+
+func (q *Queries) SetUserHandle(ctx context.Context, arg SetUserHandleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserHandle, arg.Handle, arg.ID, arg.CooldownStart)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserRole = `-- name: SetUserRole :exec
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetUserRoleParams struct {
+	ID   uuid.UUID
+	Role string
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) error {
+	_, err := q.db.ExecContext(ctx, setUserRole, arg.ID, arg.Role)
 	return err
 }
 
@@ -116,6 +286,25 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
 	return err
 }
 
+const updateUserProfile = `-- name: UpdateUserProfile :exec
+UPDATE users
+SET display_name = $2, bio = $3, location = $4, website = $5, updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateUserProfileParams struct {
+	ID          uuid.UUID
+	DisplayName string
+	Bio         string
+	Location    string
+	Website     string
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserProfile, arg.ID, arg.DisplayName, arg.Bio, arg.Location, arg.Website)
+	return err
+}
+
 const upgradeChirpyRed = `-- name: UpgradeChirpyRed :exec
 UPDATE users
 SET is_chirpy_red = true
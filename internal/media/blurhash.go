@@ -0,0 +1,124 @@
+package media
+
+import (
+	"image"
+	"math"
+	"strings"
+)
+
+
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// blurhash components, 4 across and 3 down suit most pictures
+const (
+	blurhashX = 4
+	blurhashY = 3
+)
+
+// blurhash works on a small copy of the image, the hash would be the same
+const blurhashSide = 32
+
+// Blurhash encodes a short string clients decode into a blurred
+// placeholder shown while the image loads, see https://blurha.sh
+func Blurhash(img image.Image) string {
+	small := Fit(img, blurhashSide)
+	b := small.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	linear := make([][3]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, bl, _ := small.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			linear[y*w+x] = [3]float64{
+				srgbToLinear(int(r >> 8)),
+				srgbToLinear(int(g >> 8)),
+				srgbToLinear(int(bl >> 8)),
+			}
+		}
+	}
+
+	factors := make([][3]float64, 0, blurhashX*blurhashY)
+	for j := 0; j < blurhashY; j++ {
+		for i := 0; i < blurhashX; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+
+			var f [3]float64
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					basis := normalisation *
+						math.Cos(math.Pi*float64(i)*float64(x)/float64(w)) *
+						math.Cos(math.Pi*float64(j)*float64(y)/float64(h))
+					for c := 0; c < 3; c++ {
+						f[c] += basis * linear[y*w+x][c]
+					}
+				}
+			}
+
+			scale := 1 / float64(w*h)
+			factors = append(factors, [3]float64{f[0] * scale, f[1] * scale, f[2] * scale})
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(base83((blurhashX-1)+(blurhashY-1)*9, 1))
+
+	dc, ac := factors[0], factors[1:]
+	maxValue := 1.0
+	if len(ac) > 0 {
+		actual := 0.0
+		for _, f := range ac {
+			actual = math.Max(actual, math.Max(math.Abs(f[0]), math.Max(math.Abs(f[1]), math.Abs(f[2]))))
+		}
+		quantised := int(math.Max(0, math.Min(82, math.Floor(actual*166-0.5))))
+		maxValue = float64(quantised+1) / 166
+		sb.WriteString(base83(quantised, 1))
+	} else {
+		sb.WriteString(base83(0, 1))
+	}
+
+	sb.WriteString(base83(linearToSrgb(dc[0])<<16+linearToSrgb(dc[1])<<8+linearToSrgb(dc[2]), 4))
+
+	for _, f := range ac {
+		quant := func(v float64) int {
+			return int(math.Max(0, math.Min(18, math.Floor(signPow(v/maxValue, 0.5)*9+9.5))))
+		}
+		sb.WriteString(base83(quant(f[0])*19*19+quant(f[1])*19+quant(f[2]), 2))
+	}
+
+	return sb.String()
+}
+
+func base83(value int, length int) string {
+	out := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		out[i] = base83Chars[value%83]
+		value /= 83
+	}
+
+	return string(out)
+}
+
+func srgbToLinear(value int) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSrgb(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value float64, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
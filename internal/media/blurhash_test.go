@@ -0,0 +1,49 @@
+package media
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestBlurhash(t *testing.T) {
+	split := Blurhash(testImage(120, 80))
+
+	// size flag, maximum value, the average color and two characters for
+	// each of the other components
+	if want := 1 + 1 + 4 + 2*(blurhashX*blurhashY-1); len(split) != want {
+		t.Fatalf("hash %q is %d characters long, expected %d", split, len(split), want)
+	}
+
+	// the size flag packs the number of components
+	if split[0] != base83Chars[(blurhashX-1)+(blurhashY-1)*9] {
+		t.Errorf("hash %q has size flag %q", split, split[0])
+	}
+
+	for _, c := range split {
+		if !strings.ContainsRune(base83Chars, c) {
+			t.Errorf("hash %q holds %q, not a base83 character", split, c)
+		}
+	}
+
+	if again := Blurhash(testImage(120, 80)); again != split {
+		t.Errorf("hash changed from %q to %q for the same image", split, again)
+	}
+
+	// the hash is computed on a small copy, the average color does not
+	// depend on the size of the image
+	if larger := Blurhash(testImage(1200, 800)); larger[2:6] != split[2:6] {
+		t.Errorf("average color changed with the size: %q and %q", split, larger)
+	}
+
+	red := image.NewNRGBA(image.Rect(0, 0, 120, 80))
+	for y := 0; y < 80; y++ {
+		for x := 0; x < 120; x++ {
+			red.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	if plain := Blurhash(red); plain == split {
+		t.Errorf("a solid image and a split one share the hash %q", plain)
+	}
+}
@@ -0,0 +1,173 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+
+var ErrUnsupported = errors.New("unsupported image format")
+
+// images above this many pixels are refused before being decoded, so that
+// a small file declaring a huge size can not exhaust the memory
+const maxPixels = 40_000_000
+
+const jpegQuality = 85
+
+// Encoded is an image ready to be stored, re-encoding drops the metadata
+// of the upload, EXIF location included
+type Encoded struct {
+	Data []byte
+	ContentType string
+	Ext string
+	Width int
+	Height int
+}
+
+// Sniff returns the content type of data looking at its bytes, the name
+// and the type declared by the client are not trusted
+func Sniff(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return contentType, nil
+	}
+
+	return "", ErrUnsupported
+}
+
+// Decode reads a jpeg, png, gif or webp image, a jpeg is rotated as its EXIF
+// orientation says since the orientation is lost on re-encoding. Only the
+// first frame of an animated gif is kept
+func Decode(data []byte) (image.Image, string, error) {
+	contentType, errSniff := Sniff(data)
+	if errSniff != nil {
+		return nil, "", errSniff
+	}
+
+	var decodeConfig func(io.Reader) (image.Config, error)
+	var decode func(io.Reader) (image.Image, error)
+	switch contentType {
+	case "image/jpeg":
+		decodeConfig, decode = jpeg.DecodeConfig, jpeg.Decode
+	case "image/png":
+		decodeConfig, decode = png.DecodeConfig, png.Decode
+	case "image/gif":
+		decodeConfig, decode = gif.DecodeConfig, gif.Decode
+	case "image/webp":
+		decodeConfig, decode = webp.DecodeConfig, webp.Decode
+	}
+
+	config, errConfig := decodeConfig(bytes.NewReader(data))
+	if errConfig != nil {
+		return nil, "", fmt.Errorf("invalid image: %w", errConfig)
+	}
+
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return nil, "", fmt.Errorf("image of %dx%d pixels is too large", config.Width, config.Height)
+	}
+
+	img, errDecode := decode(bytes.NewReader(data))
+	if errDecode != nil {
+		return nil, "", fmt.Errorf("invalid image: %w", errDecode)
+	}
+
+	if contentType == "image/jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	return img, contentType, nil
+}
+
+// Fit scales img down to fit in a maxSide square keeping its ratio,
+// smaller images are returned as they are
+func Fit(img image.Image, maxSide int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		return img
+	}
+
+	if w >= h {
+		h = max(1, h*maxSide/w)
+		w = maxSide
+	} else {
+		w = max(1, w*maxSide/h)
+		h = maxSide
+	}
+
+	return Scale(img, w, h)
+}
+
+// Scale resizes img to exactly w by h pixels
+func Scale(img image.Image, w int, h int) image.Image {
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	return dst
+}
+
+// CropToRatio cuts the largest centered area of img with the ratio w:h
+func CropToRatio(img image.Image, w int, h int) image.Image {
+	b := img.Bounds()
+	cw, ch := b.Dx(), b.Dy()
+	if cw*h > ch*w {
+		cw = ch * w / h
+	} else {
+		ch = cw * h / w
+	}
+
+	x := b.Min.X + (b.Dx()-cw)/2
+	y := b.Min.Y + (b.Dy()-ch)/2
+	dst := image.NewNRGBA(image.Rect(0, 0, cw, ch))
+	draw.Draw(dst, dst.Bounds(), img, image.Pt(x, y), draw.Src)
+
+	return dst
+}
+
+// Encode writes img as a png when the upload was a png, keeping the
+// transparency, and as a jpeg otherwise
+func Encode(img image.Image, contentType string) (Encoded, error) {
+	var buf bytes.Buffer
+	enc := Encoded{
+		Width: img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+	}
+
+	if contentType == "image/png" {
+		errEncode := png.Encode(&buf, img)
+		if errEncode != nil {
+			return Encoded{}, fmt.Errorf("failed to encode png: %w", errEncode)
+		}
+		enc.Data, enc.ContentType, enc.Ext = buf.Bytes(), "image/png", ".png"
+		return enc, nil
+	}
+
+	errEncode := jpeg.Encode(&buf, onWhite(img), &jpeg.Options{Quality: jpegQuality})
+	if errEncode != nil {
+		return Encoded{}, fmt.Errorf("failed to encode jpeg: %w", errEncode)
+	}
+	enc.Data, enc.ContentType, enc.Ext = buf.Bytes(), "image/jpeg", ".jpg"
+
+	return enc, nil
+}
+
+// onWhite flattens the transparent parts of img, which jpeg can not hold
+func onWhite(img image.Image) image.Image {
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	return dst
+}
@@ -0,0 +1,281 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// testImage paints the left half of a w by h image red and the right half
+// blue, so that rotations and crops can be told apart
+func testImage(w int, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	errEncode := jpeg.Encode(&buf, img, nil)
+	if errEncode != nil {
+		t.Fatalf("unexpected error: %v", errEncode)
+	}
+
+	return buf.Bytes()
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	errEncode := png.Encode(&buf, img)
+	if errEncode != nil {
+		t.Fatalf("unexpected error: %v", errEncode)
+	}
+
+	return buf.Bytes()
+}
+
+// withExif inserts an APP1 segment right after the start of image marker of
+// a jpeg, holding the orientation and a made up location string
+func withExif(data []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	tiff = append(tiff, []byte("GPS 45.4642N 9.1900E")...)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, app1...)
+
+	return append(out, data[2:]...)
+}
+
+func TestSniff(t *testing.T) {
+	var gifBuf bytes.Buffer
+	gif.Encode(&gifBuf, testImage(4, 4), nil)
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+		wantErr bool
+	}{
+		{"jpeg", encodeJPEG(t, testImage(4, 4)), "image/jpeg", false},
+		{"png", encodePNG(t, testImage(4, 4)), "image/png", false},
+		{"gif", gifBuf.Bytes(), "image/gif", false},
+		{"webp", []byte("RIFF\x24\x00\x00\x00WEBPVP8 "), "image/webp", false},
+		{"text", []byte("<html><body>hello</body></html>"), "", true},
+		{"pdf", []byte("%PDF-1.7\n"), "", true},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), "", true},
+		{"empty", []byte{}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Sniff(tt.data)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupported) {
+					t.Errorf("expected ErrUnsupported, got %q, %v", got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("got %q, %v, expected %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeRefusesTooManyPixels(t *testing.T) {
+	data := encodePNG(t, testImage(1, 1))
+
+	// IHDR is the first chunk, its width and height come right after the
+	// signature, the length and the type, followed by the checksum
+	const ihdr = 8 + 4 + 4
+	binary.BigEndian.PutUint32(data[ihdr:], 10000)
+	binary.BigEndian.PutUint32(data[ihdr+4:], 10000)
+	binary.BigEndian.PutUint32(data[ihdr+13:], crc32.ChecksumIEEE(data[ihdr-4:ihdr+13]))
+
+	_, _, err := Decode(data)
+	if err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("expected a too large error, got %v", err)
+	}
+}
+
+func TestDecodeRefusesBrokenImage(t *testing.T) {
+	data := encodeJPEG(t, testImage(8, 8))
+
+	_, _, err := Decode(data[:len(data)/3])
+	if err == nil {
+		t.Errorf("expected an error for a truncated jpeg")
+	}
+}
+
+func TestDecodeAppliesExifOrientation(t *testing.T) {
+	tests := []struct {
+		orientation uint16
+		width int
+		height int
+	}{
+		{1, 40, 20},
+		{3, 40, 20},
+		{6, 20, 40},
+		{8, 20, 40},
+	}
+
+	for _, tt := range tests {
+		data := withExif(encodeJPEG(t, testImage(40, 20)), tt.orientation)
+
+		img, contentType, err := Decode(data)
+		if err != nil {
+			t.Fatalf("orientation %d: unexpected error: %v", tt.orientation, err)
+		}
+		if contentType != "image/jpeg" {
+			t.Errorf("orientation %d: content type %q", tt.orientation, contentType)
+		}
+		if b := img.Bounds(); b.Dx() != tt.width || b.Dy() != tt.height {
+			t.Errorf("orientation %d: got %dx%d, expected %dx%d", tt.orientation, b.Dx(), b.Dy(), tt.width, tt.height)
+		}
+	}
+
+	// turned half way the red half ends up on the right
+	img, _, _ := Decode(withExif(encodeJPEG(t, testImage(40, 20)), 3))
+	if r, _, b, _ := img.At(35, 10).RGBA(); r < b {
+		t.Errorf("orientation 3 did not rotate the image")
+	}
+}
+
+func TestEncodeStripsExif(t *testing.T) {
+	data := withExif(encodeJPEG(t, testImage(40, 20)), 6)
+	if !bytes.Contains(data, []byte("Exif")) {
+		t.Fatalf("test upload has no EXIF")
+	}
+
+	img, contentType, errDecode := Decode(data)
+	if errDecode != nil {
+		t.Fatalf("unexpected error: %v", errDecode)
+	}
+
+	enc, errEncode := Encode(img, contentType)
+	if errEncode != nil {
+		t.Fatalf("unexpected error: %v", errEncode)
+	}
+
+	if bytes.Contains(enc.Data, []byte("Exif")) || bytes.Contains(enc.Data, []byte("GPS")) {
+		t.Errorf("re-encoded image still holds the EXIF metadata")
+	}
+	if jpegOrientation(enc.Data) != 1 {
+		t.Errorf("re-encoded image keeps an orientation")
+	}
+}
+
+func TestEncodeKeepsPNGAndTurnsTheRestIntoJPEG(t *testing.T) {
+	img := testImage(10, 10)
+
+	tests := []struct {
+		contentType string
+		wantType string
+		wantExt string
+	}{
+		{"image/png", "image/png", ".png"},
+		{"image/jpeg", "image/jpeg", ".jpg"},
+		{"image/gif", "image/jpeg", ".jpg"},
+		{"image/webp", "image/jpeg", ".jpg"},
+	}
+
+	for _, tt := range tests {
+		enc, err := Encode(img, tt.contentType)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.contentType, err)
+		}
+		if enc.ContentType != tt.wantType || enc.Ext != tt.wantExt {
+			t.Errorf("%s: encoded as %s %s", tt.contentType, enc.ContentType, enc.Ext)
+		}
+		if sniffed, _ := Sniff(enc.Data); sniffed != tt.wantType {
+			t.Errorf("%s: data sniffed as %q", tt.contentType, sniffed)
+		}
+		if enc.Width != 10 || enc.Height != 10 {
+			t.Errorf("%s: size %dx%d", tt.contentType, enc.Width, enc.Height)
+		}
+	}
+}
+
+func TestFitMakesThumbnails(t *testing.T) {
+	tests := []struct {
+		name string
+		width int
+		height int
+		side int
+		wantWidth int
+		wantHeight int
+	}{
+		{"landscape", 800, 400, 400, 400, 200},
+		{"portrait", 300, 900, 400, 133, 400},
+		{"square", 1000, 1000, 400, 400, 400},
+		{"smaller than the side", 120, 80, 400, 120, 80},
+		{"thin strip", 2000, 3, 400, 400, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := Fit(testImage(tt.width, tt.height), tt.side).Bounds()
+			if b.Dx() != tt.wantWidth || b.Dy() != tt.wantHeight {
+				t.Errorf("got %dx%d, expected %dx%d", b.Dx(), b.Dy(), tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
+
+func TestCropToRatio(t *testing.T) {
+	tests := []struct {
+		name string
+		width int
+		height int
+		ratioWidth int
+		ratioHeight int
+		wantWidth int
+		wantHeight int
+	}{
+		{"square from landscape", 300, 200, 1, 1, 200, 200},
+		{"square from portrait", 200, 300, 1, 1, 200, 200},
+		{"banner from square", 300, 300, 3, 1, 300, 100},
+		{"banner from wide", 900, 100, 3, 1, 300, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := CropToRatio(testImage(tt.width, tt.height), tt.ratioWidth, tt.ratioHeight).Bounds()
+			if b.Dx() != tt.wantWidth || b.Dy() != tt.wantHeight {
+				t.Errorf("got %dx%d, expected %dx%d", b.Dx(), b.Dy(), tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package mentions
+
+import "strings"
+
+
+const MaxHandleLength = 15
+
+func isHandleRune(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_'
+}
+
+// Normalize folds a handle to the form it is compared with, leading '@'
+// removed and lower case. It reports false for strings that are not valid
+// handles: 1 to MaxHandleLength ascii letters, digits or underscores
+func Normalize(handle string) (string, bool) {
+	handle = strings.TrimPrefix(handle, "@")
+	if handle == "" || len(handle) > MaxHandleLength {
+		return "", false
+	}
+
+	for _, r := range handle {
+		if !isHandleRune(r) {
+			return "", false
+		}
+	}
+
+	return strings.ToLower(handle), true
+}
+
+// Extract returns the normalized handles mentioned in body without
+// duplicates, in the order they first appear. As for hashtags an '@' only
+// starts a mention after a character that can not be part of a handle, so
+// that email addresses are not picked up
+func Extract(body string) []string {
+	found := []string{}
+	seen := map[string]bool{}
+
+	for i := 0; i < len(body); i++ {
+		if body[i] != '@' || (i > 0 && (isHandleRune(rune(body[i-1])) || body[i-1] == '@')) {
+			continue
+		}
+
+		j := i + 1
+		for j < len(body) && isHandleRune(rune(body[j])) {
+			j++
+		}
+
+		// a longer run is not a handle, not its first MaxHandleLength characters
+		handle, ok := Normalize(body[i+1:j])
+		if ok && !seen[handle] {
+			seen[handle] = true
+			found = append(found, handle)
+		}
+		i = j - 1
+	}
+
+	return found
+}
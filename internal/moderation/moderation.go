@@ -0,0 +1,239 @@
+package moderation
+
+import (
+	"strings"
+	"sync"
+)
+
+
+type Action string
+
+const (
+	ActionNone Action = ""
+	ActionCensor Action = "censor"
+	ActionFlag Action = "flag"
+	ActionReject Action = "reject"
+)
+
+const censor = "****"
+
+// severity is used to pick the outcome when an input matches
+// several lists with different actions
+var severity = map[Action]int{
+	ActionNone: 0,
+	ActionCensor: 1,
+	ActionFlag: 2,
+	ActionReject: 3,
+}
+
+func ParseAction(action string) (Action, bool) {
+	a := Action(strings.ToLower(action))
+	if a == ActionNone {
+		return ActionNone, false
+	}
+	_, ok := severity[a]
+
+	return a, ok
+}
+
+type WordList struct {
+	Name string
+	Action Action
+	Words []string
+}
+
+type Match struct {
+	List string
+	Word string
+	Action Action
+	Start int
+	End int
+}
+
+type Result struct {
+	Text string
+	Action Action
+	Matches []Match
+}
+
+type entry struct {
+	words []string
+	list string
+	phrase string
+	action Action
+}
+
+// Matcher finds whole word occurrences of a set of phrases in a text,
+// entries are indexed by their first normalized word
+type Matcher struct {
+	entries map[string][]entry
+}
+
+func NewMatcher(lists []WordList) *Matcher {
+	m := &Matcher{
+		entries: map[string][]entry{},
+	}
+
+	for _, l := range lists {
+		for _, w := range l.Words {
+			words := NormalizePhrase(w)
+			if len(words) == 0 {
+				continue
+			}
+
+			m.entries[words[0]] = append(m.entries[words[0]], entry{
+				words: words,
+				list: l.Name,
+				phrase: w,
+				action: l.Action,
+			})
+		}
+	}
+
+	return m
+}
+
+// Find returns the matches in text ordered by position. When entries
+// overlap the longest one starting first wins the span, and the entries
+// starting inside the span follow it so that their actions still count
+func (m *Matcher) Find(text string) []Match {
+	tokens := Tokenize(text)
+	matches := []Match{}
+
+	for i := 0; i < len(tokens); {
+		found := m.entriesAt(tokens, i)
+		if len(found) == 0 {
+			i++
+			continue
+		}
+
+		// the most severe among the longest ones, so that it is reported first
+		best := 0
+		for k, e := range found {
+			if len(e.words) > len(found[best].words) ||
+				(len(e.words) == len(found[best].words) && severity[e.action] > severity[found[best].action]) {
+				best = k
+			}
+		}
+
+		end := i + len(found[best].words)
+		matches = append(matches, newMatch(tokens, i, &found[best]))
+		for k := range found {
+			if k != best {
+				matches = append(matches, newMatch(tokens, i, &found[k]))
+			}
+		}
+
+		for j := i + 1; j < end; j++ {
+			inside := m.entriesAt(tokens, j)
+			for k := range inside {
+				matches = append(matches, newMatch(tokens, j, &inside[k]))
+			}
+		}
+
+		i = end
+	}
+
+	return matches
+}
+
+// entriesAt returns the entries whose words follow one another in tokens
+// from position i
+func (m *Matcher) entriesAt(tokens []Token, i int) []entry {
+	found := []entry{}
+	for _, e := range m.entries[tokens[i].Norm] {
+		if i + len(e.words) > len(tokens) {
+			continue
+		}
+
+		ok := true
+		for j := 1; j < len(e.words); j++ {
+			if tokens[i+j].Norm != e.words[j] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			found = append(found, e)
+		}
+	}
+
+	return found
+}
+
+func newMatch(tokens []Token, i int, e *entry) Match {
+	return Match{
+		List: e.list,
+		Word: e.phrase,
+		Action: e.action,
+		Start: tokens[i].Start,
+		End: tokens[i+len(e.words)-1].End,
+	}
+}
+
+// Filter holds the compiled word lists, it is safe for concurrent use
+// and can be reloaded at runtime when the lists are edited
+type Filter struct {
+	mu sync.RWMutex
+	matcher *Matcher
+}
+
+func NewFilter(lists []WordList) *Filter {
+	return &Filter{
+		matcher: NewMatcher(lists),
+	}
+}
+
+func (f *Filter) SetLists(lists []WordList) {
+	m := NewMatcher(lists)
+
+	f.mu.Lock()
+	f.matcher = m
+	f.mu.Unlock()
+}
+
+// Apply checks text against every list, censoring the matches of lists
+// with the censor action while leaving the rest of the text untouched.
+// The returned action is the most severe among the matched lists, the
+// ones overlapping a censored span included
+func (f *Filter) Apply(text string) Result {
+	f.mu.RLock()
+	m := f.matcher
+	f.mu.RUnlock()
+
+	res := Result{
+		Text: text,
+		Action: ActionNone,
+		Matches: m.Find(text),
+	}
+
+	if len(res.Matches) == 0 {
+		return res
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, match := range res.Matches {
+		if severity[match.Action] > severity[res.Action] {
+			res.Action = match.Action
+		}
+
+		if match.Action != ActionCensor {
+			continue
+		}
+
+		// a match overlapping the span already censored extends it
+		if match.Start < last {
+			last = max(last, match.End)
+			continue
+		}
+
+		b.WriteString(text[last:match.Start])
+		b.WriteString(censor)
+		last = match.End
+	}
+	b.WriteString(text[last:])
+	res.Text = b.String()
+
+	return res
+}
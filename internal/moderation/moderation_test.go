@@ -0,0 +1,122 @@
+package moderation
+
+import (
+	"testing"
+)
+
+func TestFilterApply(t *testing.T) {
+	tests := []struct {
+		name string
+		lists []WordList
+		text string
+		wantText string
+		wantAction Action
+	}{
+		{
+			name: "no match",
+			lists: []WordList{{Name: "censored", Action: ActionCensor, Words: []string{"bad"}}},
+			text: "a perfectly fine chirp",
+			wantText: "a perfectly fine chirp",
+			wantAction: ActionNone,
+		},
+		{
+			name: "whole words only",
+			lists: []WordList{{Name: "censored", Action: ActionCensor, Words: []string{"bad"}}},
+			text: "a badge",
+			wantText: "a badge",
+			wantAction: ActionNone,
+		},
+		{
+			name: "censored word",
+			lists: []WordList{{Name: "censored", Action: ActionCensor, Words: []string{"bad"}}},
+			text: "this is B4D, really",
+			wantText: "this is ****, really",
+			wantAction: ActionCensor,
+		},
+		{
+			name: "longest censored span",
+			lists: []WordList{{Name: "censored", Action: ActionCensor, Words: []string{"bad", "bad word"}}},
+			text: "a bad word here",
+			wantText: "a **** here",
+			wantAction: ActionCensor,
+		},
+		{
+			name: "reject word inside a longer censor phrase",
+			lists: []WordList{
+				{Name: "censored", Action: ActionCensor, Words: []string{"very bad word"}},
+				{Name: "rejected", Action: ActionReject, Words: []string{"bad"}},
+			},
+			text: "a very bad word here",
+			wantText: "a **** here",
+			wantAction: ActionReject,
+		},
+		{
+			name: "reject word starting a longer censor phrase",
+			lists: []WordList{
+				{Name: "censored", Action: ActionCensor, Words: []string{"bad word"}},
+				{Name: "rejected", Action: ActionReject, Words: []string{"bad"}},
+			},
+			text: "a bad word",
+			wantText: "a ****",
+			wantAction: ActionReject,
+		},
+		{
+			name: "flag phrase overlapping the end of a censor phrase",
+			lists: []WordList{
+				{Name: "censored", Action: ActionCensor, Words: []string{"big bad"}},
+				{Name: "flagged", Action: ActionFlag, Words: []string{"bad wolf"}},
+			},
+			text: "the big bad wolf",
+			wantText: "the **** wolf",
+			wantAction: ActionFlag,
+		},
+		{
+			name: "censor phrases overlapping each other",
+			lists: []WordList{{Name: "censored", Action: ActionCensor, Words: []string{"big bad", "bad wolf"}}},
+			text: "the big bad wolf ran",
+			wantText: "the **** ran",
+			wantAction: ActionCensor,
+		},
+		{
+			name: "same phrase in two lists",
+			lists: []WordList{
+				{Name: "censored", Action: ActionCensor, Words: []string{"bad"}},
+				{Name: "flagged", Action: ActionFlag, Words: []string{"bad"}},
+			},
+			text: "so bad",
+			wantText: "so ****",
+			wantAction: ActionFlag,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := NewFilter(tt.lists).Apply(tt.text)
+			if res.Text != tt.wantText {
+				t.Errorf("got text %q, expected %q", res.Text, tt.wantText)
+			}
+			if res.Action != tt.wantAction {
+				t.Errorf("got action %q, expected %q", res.Action, tt.wantAction)
+			}
+		})
+	}
+}
+
+func TestMatcherFindReportsOverlappedMatches(t *testing.T) {
+	m := NewMatcher([]WordList{
+		{Name: "censored", Action: ActionCensor, Words: []string{"very bad word"}},
+		{Name: "rejected", Action: ActionReject, Words: []string{"bad"}},
+	})
+
+	matches := m.Find("a very bad word")
+	if len(matches) != 2 {
+		t.Fatalf("got %d matches, expected 2: %+v", len(matches), matches)
+	}
+
+	if matches[0].Word != "very bad word" || matches[0].Start != 2 || matches[0].End != 15 {
+		t.Errorf("unexpected span %+v", matches[0])
+	}
+	if matches[1].Action != ActionReject || matches[1].Start != 7 || matches[1].End != 10 {
+		t.Errorf("unexpected overlapped match %+v", matches[1])
+	}
+}
@@ -0,0 +1,99 @@
+package moderation
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+
+// leetspeak substitutions folded back to the letter they usually stand for
+var leetFold = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'8': 'b',
+	'9': 'g',
+	'@': 'a',
+	'$': 's',
+}
+
+type Token struct {
+	Start int // byte offset of the token in the original text
+	End int
+	Norm string
+}
+
+func isTokenRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '@' || r == '$'
+}
+
+// Tokenize splits text on everything that is not a letter, digit or
+// leetspeak symbol, keeping the byte offsets so that matches can be
+// replaced in place without touching the surrounding whitespace
+func Tokenize(text string) []Token {
+	tokens := []Token{}
+	start := -1
+
+	for i, r := range text {
+		if isTokenRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+
+		if start >= 0 {
+			tokens = append(tokens, Token{Start: start, End: i, Norm: Normalize(text[start:i])})
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		tokens = append(tokens, Token{Start: start, End: len(text), Norm: Normalize(text[start:])})
+	}
+
+	return tokens
+}
+
+// Normalize folds a single word to the form used for matching: compatibility
+// decomposition, diacritics removed, lower case and leetspeak folded
+func Normalize(word string) string {
+	decomposed := norm.NFKD.String(word)
+
+	var b strings.Builder
+	b.Grow(len(decomposed))
+	for _, r := range decomposed {
+		if unicode.IsMark(r) {
+			continue
+		}
+
+		r = unicode.ToLower(r)
+		if folded, ok := leetFold[r]; ok {
+			r = folded
+		}
+
+		if r != utf8.RuneError {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// NormalizePhrase turns a list entry into the sequence of normalized tokens
+// it has to match, so that multi word entries are matched on word boundaries too
+func NormalizePhrase(phrase string) []string {
+	tokens := Tokenize(phrase)
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		words[i] = t.Norm
+	}
+
+	return words
+}
@@ -0,0 +1,80 @@
+package spam
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"math/bits"
+	"strings"
+
+	"github.com/niccolot/Chirpy/internal/moderation"
+)
+
+
+// normalizedWords reuses the moderation tokenizer so that case, accents,
+// punctuation and leetspeak tricks do not defeat the duplicate detection
+func normalizedWords(body string) []string {
+	tokens := moderation.Tokenize(body)
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		words[i] = t.Norm
+	}
+
+	return words
+}
+
+// Hash is the sha256 of the normalized body, equal for exact duplicates.
+// Bodies without words, like a single emoji, are hashed as they are so
+// that they do not all normalize to the same empty string
+func Hash(body string) string {
+	normalized := strings.Join(normalizedWords(body), " ")
+	if normalized == "" {
+		normalized = strings.TrimSpace(body)
+	}
+	sum := sha256.Sum256([]byte(normalized))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Simhash computes a 64 bit locality sensitive hash of the body over
+// single words and word pairs, similar bodies get hashes that differ
+// in only a few bits
+func Simhash(body string) uint64 {
+	words := normalizedWords(body)
+	features := make([]string, 0, 2*len(words))
+	features = append(features, words...)
+	for i := 0; i+1 < len(words); i++ {
+		features = append(features, words[i]+" "+words[i+1])
+	}
+
+	if len(features) == 0 {
+		return 0
+	}
+
+	var weights [64]int
+	for _, f := range features {
+		h := fnv.New64a()
+		h.Write([]byte(f))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var hash uint64
+	for bit := 0; bit < 64; bit++ {
+		if weights[bit] > 0 {
+			hash |= 1 << bit
+		}
+	}
+
+	return hash
+}
+
+func HammingDistance(a uint64, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
@@ -0,0 +1,61 @@
+package spam
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+
+var linkRegex = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"]+`)
+
+// ExtractDomains returns the lower cased host of every link in the body
+func ExtractDomains(body string) []string {
+	links := linkRegex.FindAllString(body, -1)
+	domains := make([]string, 0, len(links))
+	for _, link := range links {
+		if !strings.Contains(strings.ToLower(link), "://") {
+			link = "http://" + link
+		}
+
+		u, errParse := url.Parse(strings.TrimRight(link, ".,;:!?)"))
+		if errParse != nil || u.Hostname() == "" {
+			continue
+		}
+
+		domains = append(domains, strings.ToLower(u.Hostname()))
+	}
+
+	return domains
+}
+
+// NormalizeDomain accepts either a bare domain or a full url
+func NormalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if strings.Contains(domain, "://") {
+		u, errParse := url.Parse(domain)
+		if errParse == nil {
+			domain = u.Hostname()
+		}
+	}
+
+	return strings.TrimPrefix(strings.TrimSuffix(domain, "."), "www.")
+}
+
+// isBlocked matches the domain itself and all its subdomains
+func isBlocked(domain string, blocklist map[string]bool) bool {
+	domain = NormalizeDomain(domain)
+	for domain != "" {
+		if blocklist[domain] {
+			return true
+		}
+
+		dot := strings.IndexByte(domain, '.')
+		if dot < 0 {
+			break
+		}
+		domain = domain[dot+1:]
+	}
+
+	return false
+}
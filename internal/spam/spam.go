@@ -0,0 +1,167 @@
+package spam
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+
+type Verdict string
+
+const (
+	VerdictAllow Verdict = "allow"
+	VerdictReview Verdict = "review"
+	VerdictThrottle Verdict = "throttle"
+	VerdictReject Verdict = "reject"
+)
+
+// Fingerprint is what is kept of the previous bodies of an author
+type Fingerprint struct {
+	Hash string
+	Simhash uint64
+	CreatedAt time.Time
+}
+
+type Config struct {
+	// bodies whose simhashes differ in at most this many bits are near duplicates
+	NearDuplicateDistance int
+	// bodies with fewer words are too short for their simhashes to tell
+	// them apart, they are only checked for exact duplicates
+	NearDuplicateMinWords int
+	// how far back the caller should look for duplicates
+	DuplicateWindow time.Duration
+	// more than VelocityLimit bodies in VelocityWindow get throttled
+	VelocityWindow time.Duration
+	VelocityLimit int
+	MaxLinks int
+	// links per word
+	MaxLinkDensity float64
+	ReviewScore float64
+	RejectScore float64
+}
+
+func DefaultConfig() Config {
+	return Config{
+		NearDuplicateDistance: 3,
+		NearDuplicateMinWords: 4,
+		DuplicateWindow: 24 * time.Hour,
+		VelocityWindow: time.Minute,
+		VelocityLimit: 5,
+		MaxLinks: 2,
+		MaxLinkDensity: 0.3,
+		ReviewScore: 0.5,
+		RejectScore: 1.0,
+	}
+}
+
+type Result struct {
+	Score float64
+	Verdict Verdict
+	Reasons []string
+	Hash string
+	Simhash uint64
+	RetryAfter time.Duration
+}
+
+// Scorer holds the configuration and the url blocklist, the blocklist
+// can be swapped at runtime when it is edited
+type Scorer struct {
+	config Config
+	mu sync.RWMutex
+	blocklist map[string]bool
+}
+
+func NewScorer(config Config) *Scorer {
+	return &Scorer{
+		config: config,
+		blocklist: map[string]bool{},
+	}
+}
+
+func (s *Scorer) Config() Config {
+	return s.config
+}
+
+func (s *Scorer) SetBlocklist(domains []string) {
+	blocklist := make(map[string]bool, len(domains))
+	for _, d := range domains {
+		blocklist[NormalizeDomain(d)] = true
+	}
+
+	s.mu.Lock()
+	s.blocklist = blocklist
+	s.mu.Unlock()
+}
+
+// Evaluate scores body against the recent history of its author.
+// Exact duplicates and blocklisted links are rejected straight away,
+// posting too fast is throttled and the other signals add up to a
+// score that sends the body to review or rejects it
+func (s *Scorer) Evaluate(body string, history []Fingerprint, now time.Time) Result {
+	s.mu.RLock()
+	blocklist := s.blocklist
+	s.mu.RUnlock()
+
+	res := Result{
+		Hash: Hash(body),
+		Simhash: Simhash(body),
+		Reasons: []string{},
+	}
+
+	words := len(normalizedWords(body))
+	checkNear := words >= s.config.NearDuplicateMinWords
+	nearDuplicate := false
+	recent := 0
+	var oldestRecent time.Time
+	for _, f := range history {
+		if f.Hash == res.Hash {
+			res.Score += s.config.RejectScore
+			res.Reasons = append(res.Reasons, "duplicate of a recent chirp")
+		} else if checkNear && !nearDuplicate && HammingDistance(f.Simhash, res.Simhash) <= s.config.NearDuplicateDistance {
+			nearDuplicate = true
+			res.Score += 0.6
+			res.Reasons = append(res.Reasons, "near duplicate of a recent chirp")
+		}
+
+		if now.Sub(f.CreatedAt) < s.config.VelocityWindow {
+			recent++
+			if oldestRecent.IsZero() || f.CreatedAt.Before(oldestRecent) {
+				oldestRecent = f.CreatedAt
+			}
+		}
+	}
+
+	domains := ExtractDomains(body)
+	for _, d := range domains {
+		if isBlocked(d, blocklist) {
+			res.Score += s.config.RejectScore
+			res.Reasons = append(res.Reasons, fmt.Sprintf("link to blocked domain %s", d))
+		}
+	}
+
+	if len(domains) > s.config.MaxLinks {
+		res.Score += 0.4
+		res.Reasons = append(res.Reasons, "too many links")
+	}
+
+	if words > 0 && float64(len(domains))/float64(words) > s.config.MaxLinkDensity {
+		res.Score += 0.3
+		res.Reasons = append(res.Reasons, "link density too high")
+	}
+
+	switch {
+	case res.Score >= s.config.RejectScore:
+		res.Verdict = VerdictReject
+	case recent >= s.config.VelocityLimit:
+		res.Verdict = VerdictThrottle
+		res.Reasons = append(res.Reasons, "posting too fast")
+		res.RetryAfter = oldestRecent.Add(s.config.VelocityWindow).Sub(now)
+	case res.Score >= s.config.ReviewScore:
+		res.Verdict = VerdictReview
+	default:
+		res.Verdict = VerdictAllow
+	}
+
+	return res
+}
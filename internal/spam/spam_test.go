@@ -0,0 +1,212 @@
+package spam
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const mixtape = "Check out my new mixtape, it is the best thing you will hear this year, link in bio"
+
+func TestHash(t *testing.T) {
+	tests := []struct {
+		name string
+		a string
+		b string
+		want bool
+	}{
+		{"same body", mixtape, mixtape, true},
+		{"case and punctuation", "Buy NOW!!! limited offer", "buy now, limited offer", true},
+		{"accents and leetspeak", "fr33 crédit", "free credit", true},
+		{"one word changed", mixtape, "Check out my new mixtape, it is the best thing you will hear this month, link in bio", false},
+		{"different emoji", "🎉", "😢", false},
+		{"same emoji", "🎉", " 🎉 ", true},
+		{"emoji and empty", "🎉", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Hash(tt.a) == Hash(tt.b); got != tt.want {
+				t.Errorf("Hash(%q) == Hash(%q) is %v, expected %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	tests := []struct {
+		a uint64
+		b uint64
+		want int
+	}{
+		{0, 0, 0},
+		{0b1011, 0b0001, 2},
+		{0, ^uint64(0), 64},
+		{1 << 63, 1, 2},
+	}
+
+	for _, tt := range tests {
+		if got := HammingDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("HammingDistance(%b, %b) = %d, expected %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSimhash(t *testing.T) {
+	if Simhash("") != 0 || Simhash("!!! ???") != 0 {
+		t.Errorf("body without words has a non zero simhash")
+	}
+
+	if got := HammingDistance(Simhash(mixtape), Simhash("CHECK out my new mixtape!! It is the best thing you will hear this year. Link in bio")); got != 0 {
+		t.Errorf("the same words differ in %d bits", got)
+	}
+
+	near := HammingDistance(Simhash(mixtape), Simhash(mixtape+" pls"))
+	far := HammingDistance(Simhash(mixtape), Simhash("The weather in Milan is lovely today, going for a walk along the canals"))
+	if near > DefaultConfig().NearDuplicateDistance+1 || far <= 2*near {
+		t.Errorf("one more word differs in %d bits and another body in %d", near, far)
+	}
+}
+
+func TestExtractDomains(t *testing.T) {
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{"no links here", []string{}},
+		{"see https://Example.com/path?q=1", []string{"example.com"}},
+		{"go to www.spam.example.net.", []string{"www.spam.example.net"}},
+		{"two links http://a.io and (https://b.io/x)", []string{"a.io", "b.io"}},
+		{"port and user https://me@host.example:8080/", []string{"host.example"}},
+		{"not a link example.com", []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := ExtractDomains(tt.body); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExtractDomains(%q) = %v, expected %v", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestBlocklist(t *testing.T) {
+	s := NewScorer(DefaultConfig())
+	s.SetBlocklist([]string{"Spam.example.com", "https://www.casino.test/landing", "bad.io."})
+
+	tests := []struct {
+		domain string
+		want bool
+	}{
+		{"spam.example.com", true},
+		{"www.spam.example.com", true},
+		{"deep.sub.spam.example.com", true},
+		{"example.com", false},
+		{"notspam.example.com", false},
+		{"casino.test", true},
+		{"promo.casino.test", true},
+		{"BAD.IO", true},
+		{"bad.io.evil.com", false},
+		{"good.io", false},
+	}
+
+	for _, tt := range tests {
+		if got := isBlocked(tt.domain, s.blocklist); got != tt.want {
+			t.Errorf("isBlocked(%q) = %v, expected %v", tt.domain, got, tt.want)
+		}
+	}
+
+	res := s.Evaluate("great deals at https://promo.casino.test today", nil, time.Now())
+	if res.Verdict != VerdictReject {
+		t.Errorf("link to a blocked subdomain got %s, reasons %v", res.Verdict, res.Reasons)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	config := DefaultConfig()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-time.Hour)
+
+	fingerprint := func(body string, createdAt time.Time) Fingerprint {
+		return Fingerprint{Hash: Hash(body), Simhash: Simhash(body), CreatedAt: createdAt}
+	}
+	// a body whose simhash differs from the one of mixtape in the given
+	// number of bits, to test the near duplicate threshold exactly
+	flipped := func(n int) Fingerprint {
+		return Fingerprint{Hash: "other", Simhash: Simhash(mixtape) ^ (1<<n - 1), CreatedAt: old}
+	}
+	burst := func(n int) []Fingerprint {
+		history := make([]Fingerprint, n)
+		for i := range history {
+			history[i] = fingerprint("unrelated chirp number "+string(rune('a'+i)), now.Add(-time.Duration(50-10*i)*time.Second))
+		}
+		return history
+	}
+
+	tests := []struct {
+		name string
+		body string
+		history []Fingerprint
+		want Verdict
+		wantRetryAfter time.Duration
+	}{
+		{"first chirp", mixtape, nil, VerdictAllow, 0},
+		{"exact duplicate", mixtape, []Fingerprint{fingerprint(mixtape, old)}, VerdictReject, 0},
+		{"exact duplicate with other case", mixtape, []Fingerprint{fingerprint("CHECK OUT my new mixtape: it is the best thing you will hear this year. Link in bio", old)}, VerdictReject, 0},
+		{"at the near duplicate distance", mixtape, []Fingerprint{flipped(config.NearDuplicateDistance)}, VerdictReview, 0},
+		{"past the near duplicate distance", mixtape, []Fingerprint{flipped(config.NearDuplicateDistance + 1)}, VerdictAllow, 0},
+		{"several near duplicates count once", mixtape, []Fingerprint{flipped(1), flipped(2)}, VerdictReview, 0},
+		{"short bodies", "lol", []Fingerprint{fingerprint("lmao", old)}, VerdictAllow, 0},
+		{"short bodies sharing a word", "no no", []Fingerprint{fingerprint("no hi", old)}, VerdictAllow, 0},
+		{"short body against a close simhash", "good morning", []Fingerprint{{Hash: "other", Simhash: Simhash("good morning") ^ 1, CreatedAt: old}}, VerdictAllow, 0},
+		{"different emoji", "🎉", []Fingerprint{fingerprint("😢", old)}, VerdictAllow, 0},
+		{"same short body", "lol", []Fingerprint{fingerprint("LOL!", old)}, VerdictReject, 0},
+		{"below the velocity limit", "one more", burst(config.VelocityLimit - 1), VerdictAllow, 0},
+		{"at the velocity limit", "one more", burst(config.VelocityLimit), VerdictThrottle, 10 * time.Second},
+		{"burst older than the window", "one more", []Fingerprint{
+			fingerprint("a", now.Add(-2*time.Minute)),
+			fingerprint("b", now.Add(-90*time.Second)),
+			fingerprint("c", now.Add(-61*time.Second)),
+			fingerprint("d", now.Add(-30*time.Second)),
+			fingerprint("e", now.Add(-5*time.Second)),
+		}, VerdictAllow, 0},
+		{"duplicate while throttled", "unrelated chirp number a", burst(config.VelocityLimit), VerdictReject, 0},
+	}
+
+	s := NewScorer(config)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := s.Evaluate(tt.body, tt.history, now)
+			if res.Verdict != tt.want {
+				t.Errorf("got %s with score %.1f and reasons %v, expected %s", res.Verdict, res.Score, res.Reasons, tt.want)
+			}
+			if res.RetryAfter != tt.wantRetryAfter {
+				t.Errorf("got retry after %s, expected %s", res.RetryAfter, tt.wantRetryAfter)
+			}
+			if res.Hash != Hash(tt.body) || res.Simhash != Simhash(tt.body) {
+				t.Errorf("result does not carry the fingerprint of the body")
+			}
+		})
+	}
+}
+
+func TestEvaluateLinks(t *testing.T) {
+	s := NewScorer(DefaultConfig())
+
+	tests := []struct {
+		name string
+		body string
+		want Verdict
+	}{
+		{"one link", "my blog post about sourdough is up at https://bread.example/post", VerdictAllow},
+		{"too many links", "reading list for the weekend: https://a.example https://b.example https://c.example and a few more pages of notes to go with them", VerdictAllow},
+		{"too many and too dense", "https://a.example https://b.example https://c.example", VerdictReview},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := s.Evaluate(tt.body, nil, time.Now())
+			if res.Verdict != tt.want {
+				t.Errorf("got %s with score %.1f and reasons %v, expected %s", res.Verdict, res.Score, res.Reasons, tt.want)
+			}
+		})
+	}
+}
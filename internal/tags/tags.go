@@ -0,0 +1,67 @@
+package tags
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+
+const MaxLength = 64
+
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_'
+}
+
+// Normalize folds a tag to the form it is indexed with: leading '#' removed,
+// compatibility composition and lower case. It reports false for strings
+// that are not valid tags, a tag needs at least one letter
+func Normalize(tag string) (string, bool) {
+	tag = strings.ToLower(norm.NFKC.String(strings.TrimPrefix(tag, "#")))
+	if tag == "" || len([]rune(tag)) > MaxLength {
+		return "", false
+	}
+
+	hasLetter := false
+	for _, r := range tag {
+		if !isTagRune(r) {
+			return "", false
+		}
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		}
+	}
+
+	return tag, hasLetter
+}
+
+// Extract returns the normalized hashtags of body without duplicates, in the
+// order they first appear. A '#' only starts a tag at the beginning of the
+// body or after a character that can not be part of a tag, so that
+// fragments like "c#" or "a#b" are not picked up
+func Extract(body string) []string {
+	found := []string{}
+	seen := map[string]bool{}
+
+	runes := []rune(body)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' || (i > 0 && (isTagRune(runes[i-1]) || runes[i-1] == '#')) {
+			continue
+		}
+
+		j := i + 1
+		for j < len(runes) && isTagRune(runes[j]) {
+			j++
+		}
+
+		tag, ok := Normalize(string(runes[i+1:j]))
+		if ok && !seen[tag] {
+			seen[tag] = true
+			found = append(found, tag)
+		}
+		i = j - 1
+	}
+
+	return found
+}
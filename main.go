@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
@@ -24,6 +25,14 @@ func main() {
 	}
 	
 	defer db.Close()
+
+	if len(os.Args) > 1 && os.Args[1] == "create-admin" {
+		errAdmin := createAdminCommand(db, os.Args[2:])
+		if errAdmin != nil {
+			log.Fatal(errAdmin.Message)
+		}
+		return
+	}
 	
 	cfg, errAPIConfig := NewAPIConfig(db)
 	if errAPIConfig != nil {
@@ -33,10 +42,14 @@ func main() {
 	mux := http.NewServeMux()
 
 	server := &http.Server{
-		Handler: mux,
+		Handler: cfg.middlewareRequestId(mux),
 		Addr: "localhost:8080",
 	}
 
 	initMultiplexer(mux, cfg)
+	go cfg.runTrendsWorker(context.Background())
+	go cfg.runPollsWorker(context.Background())
+	go cfg.runScheduler(context.Background())
+	go cfg.runMediaWorker(context.Background())
 	server.ListenAndServe()
 }
\ No newline at end of file
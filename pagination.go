@@ -0,0 +1,109 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+)
+
+
+const (
+	defaultPageSize = 20
+	maxPageSize = 100
+)
+
+// cursor points right after the last item of a page ordered by time and id,
+// the id breaks the ties between items created in the same instant
+type cursor struct {
+	Time time.Time
+	Id uuid.UUID
+}
+
+func (c *cursor) encode() string {
+	raw := c.Time.UTC().Format(time.RFC3339Nano) + "|" + c.Id.String()
+
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (*cursor, *customErrors.CodedError) {
+	invalid := customErrors.CodedError{
+		Message: "invalid cursor",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	raw, errDecode := base64.RawURLEncoding.DecodeString(s)
+	if errDecode != nil {
+		return nil, &invalid
+	}
+
+	t, id, found := strings.Cut(string(raw), "|")
+	if !found {
+		return nil, &invalid
+	}
+
+	parsedTime, errTime := time.Parse(time.RFC3339Nano, t)
+	if errTime != nil {
+		return nil, &invalid
+	}
+
+	parsedId, errId := uuid.Parse(id)
+	if errId != nil {
+		return nil, &invalid
+	}
+
+	return &cursor{Time: parsedTime, Id: parsedId}, nil
+}
+
+type page struct {
+	Limit int32
+	CursorTime sql.NullTime
+	CursorId uuid.NullUUID
+}
+
+// pageFromQuery reads the limit and cursor queries of a paginated listing
+func pageFromQuery(r *http.Request) (page, *customErrors.CodedError) {
+	p := page{
+		Limit: defaultPageSize,
+	}
+
+	query := r.URL.Query()
+	if l := query.Get("limit"); l != "" {
+		limit, errLimit := strconv.Atoi(l)
+		if errLimit != nil || limit < 1 || limit > maxPageSize {
+			e := customErrors.CodedError{
+				Message: fmt.Sprintf("limit must be between 1 and %d", maxPageSize),
+				StatusCode: http.StatusBadRequest,
+			}
+			return p, &e
+		}
+		p.Limit = int32(limit)
+	}
+
+	if c := query.Get("cursor"); c != "" {
+		decoded, errCursor := decodeCursor(c)
+		if errCursor != nil {
+			return p, errCursor
+		}
+		p.CursorTime = sql.NullTime{Time: decoded.Time, Valid: true}
+		p.CursorId = uuid.NullUUID{UUID: decoded.Id, Valid: true}
+	}
+
+	return p, nil
+}
+
+// nextCursor returns the cursor of the following page, or an empty
+// string when the page was not full and there is nothing left
+func nextCursor(p *page, count int, last cursor) string {
+	if count < int(p.Limit) {
+		return ""
+	}
+
+	return last.encode()
+}
@@ -1,15 +1,30 @@
 package main
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type chirpPostRequest struct {
 	Body string `json:"body"`
 	UserId uuid.UUID `json:"user_id"`
+	InReplyTo *uuid.UUID `json:"in_reply_to"`
+	QuoteOf *uuid.UUID `json:"quote_of"`
+	Visibility string `json:"visibility"`
+	ContentWarning string `json:"content_warning"`
+	Sensitive bool `json:"sensitive"`
+	Poll *pollRequest `json:"poll"`
+	MediaIds []uuid.UUID `json:"media_ids"`
 }
 
 type chirpPutRequest struct {
 	ChirpId uuid.UUID `json:"id"`
 	Body string `json:"body"`
+	// the fields left out keep their current value
+	Visibility *string `json:"visibility"`
+	ContentWarning *string `json:"content_warning"`
+	Sensitive *bool `json:"sensitive"`
 }
 
 type userPostRequest struct {
@@ -34,4 +49,125 @@ type polkaWebhookPostRequest struct {
 
 type polkaWebhookData struct {
 	UserId uuid.UUID `json:"user_id"`
-}
\ No newline at end of file
+}
+
+type wordListPostRequest struct {
+	Name string `json:"name"`
+	Action string `json:"action"`
+	Words []string `json:"words"`
+}
+
+type wordListPutRequest struct {
+	Action string `json:"action"`
+}
+
+type wordListWordsPostRequest struct {
+	Words []string `json:"words"`
+}
+
+type reportPostRequest struct {
+	Reason string `json:"reason"`
+	Details string `json:"details"`
+}
+
+type reportResolveRequest struct {
+	Action string `json:"action"`
+	Note string `json:"note"`
+	SuspendHours int `json:"suspend_hours"`
+}
+
+type userRolePutRequest struct {
+	Role string `json:"role"`
+}
+
+type suspensionPostRequest struct {
+	Reason string `json:"reason"`
+	DurationHours int `json:"duration_hours"`
+}
+
+type blocklistPostRequest struct {
+	Domains []string `json:"domains"`
+}
+
+type notificationsReadPostRequest struct {
+	Ids []uuid.UUID `json:"ids"`
+}
+
+// nil fields of a profile patch are left as they are
+type profilePatchRequest struct {
+	Handle *string `json:"handle"`
+	DisplayName *string `json:"display_name"`
+	Bio *string `json:"bio"`
+	Location *string `json:"location"`
+	Website *string `json:"website"`
+}
+
+type conversationPostRequest struct {
+	ParticipantIds []uuid.UUID `json:"participant_ids"`
+}
+
+type messagePostRequest struct {
+	Body string `json:"body"`
+}
+
+// a mute without duration lasts until it is removed
+type mutePostRequest struct {
+	DurationHours int `json:"duration_hours"`
+}
+
+// whole_word defaults to true, hashtags always match whole tags
+type mutedWordPostRequest struct {
+	Phrase string `json:"phrase"`
+	Scope string `json:"scope"`
+	Action string `json:"action"`
+	WholeWord *bool `json:"whole_word"`
+	DurationHours int `json:"duration_hours"`
+}
+
+type bookmarkPostRequest struct {
+	FolderId *uuid.UUID `json:"folder_id"`
+}
+
+type bookmarkFolderRequest struct {
+	Name string `json:"name"`
+}
+
+type listRequest struct {
+	Name string `json:"name"`
+	Description string `json:"description"`
+	Private bool `json:"private"`
+}
+
+type pollRequest struct {
+	Options []string `json:"options"`
+	ClosesAt time.Time `json:"closes_at"`
+}
+
+type pollVotePostRequest struct {
+	OptionId uuid.UUID `json:"option_id"`
+}
+
+// a scheduled chirp without publish_at is saved as a draft
+type scheduledChirpRequest struct {
+	Body string `json:"body"`
+	InReplyTo *uuid.UUID `json:"in_reply_to"`
+	QuoteOf *uuid.UUID `json:"quote_of"`
+	Visibility string `json:"visibility"`
+	ContentWarning string `json:"content_warning"`
+	Sensitive bool `json:"sensitive"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+type mediaPutRequest struct {
+	AltText string `json:"alt_text"`
+}
+
+// an empty content warning and a false sensitive lift the forced ones
+type chirpWarningRequest struct {
+	ContentWarning string `json:"content_warning"`
+	Sensitive bool `json:"sensitive"`
+}
+
+type preferencesPutRequest struct {
+	ExpandFlaggedContent *bool `json:"expand_flagged_content"`
+}
@@ -22,6 +22,10 @@ type respSuccUserPostData struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	Email string `json:"email"`
 	IsChirpyRed bool `json:"is_chirpy_red"`
+	Role string `json:"role"`
+	FollowersCount int64 `json:"followers_count"`
+	FollowingCount int64 `json:"following_count"`
+	Handle *string `json:"handle"`
 }
 
 type respSuccUserPutData struct {
@@ -30,6 +34,10 @@ type respSuccUserPutData struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	Email string `json:"email"`
 	IsChirpyRed bool `json:"is_chirpy_red"`
+	Role string `json:"role"`
+	FollowersCount int64 `json:"followers_count"`
+	FollowingCount int64 `json:"following_count"`
+	Handle *string `json:"handle"`
 }
 
 type respSuccLoginPostData struct {
@@ -40,6 +48,10 @@ type respSuccLoginPostData struct {
 	Token string `json:"token"`
 	RefreshToken string `json:"refresh_token"`
 	IsChirpyRed bool `json:"is_chirpy_red"`
+	Role string `json:"role"`
+	FollowersCount int64 `json:"followers_count"`
+	FollowingCount int64 `json:"following_count"`
+	Handle *string `json:"handle"`
 }
 
 type respSuccRefreshPostData struct {
@@ -122,6 +134,10 @@ func respSuccesfullUserPost(w *http.ResponseWriter, user *User) {
 		UpdatedAt: user.UpdatedAt,
 		Email: user.Email,
 		IsChirpyRed: user.IsChirpyred,
+		Role: user.Role,
+		FollowersCount: user.FollowersCount,
+		FollowingCount: user.FollowingCount,
+		Handle: user.Handle,
 	}
 
 	dat, errMarshal := json.Marshal(respStruct)
@@ -142,6 +158,10 @@ func respSuccesfullUserPut(w *http.ResponseWriter, user *User) {
 		UpdatedAt: user.UpdatedAt,
 		Email: user.Email,
 		IsChirpyRed: user.IsChirpyred,
+		Role: user.Role,
+		FollowersCount: user.FollowersCount,
+		FollowingCount: user.FollowingCount,
+		Handle: user.Handle,
 	}
 
 	dat, errMarshal := json.Marshal(respStruct)
@@ -164,6 +184,10 @@ func respSuccesfullLoginPost(w *http.ResponseWriter, user *User, jwt *string, re
 		Token: *jwt,
 		RefreshToken: *refreshToken,
 		IsChirpyRed: user.IsChirpyred,
+		Role: user.Role,
+		FollowersCount: user.FollowersCount,
+		FollowingCount: user.FollowingCount,
+		Handle: user.Handle,
 	}
 
 	dat, errMarshal := json.Marshal(respStruct)
@@ -196,4 +220,213 @@ func respSuccesfullRefreshPost(w *http.ResponseWriter, token string, refreshToke
 
 func respNoContent(w *http.ResponseWriter) {
 	(*w).WriteHeader(http.StatusNoContent)
-}
\ No newline at end of file
+}
+
+func respondWithJSON(w *http.ResponseWriter, code int, payload interface{}) {
+	dat, errMarshal := json.Marshal(payload)
+	if errMarshal != nil {
+		customErrors.ErrorMarshal(w, errMarshal)
+		return 
+	}
+
+	(*w).Header().Set("Content-Type", "application/json")
+	(*w).WriteHeader(code)
+	(*w).Write(dat)
+}
+
+func respSuccesfullWordListsGet(w *http.ResponseWriter, lists []WordList) {
+	respondWithJSON(w, http.StatusOK, lists)
+}
+
+func respSuccesfullWordListPost(w *http.ResponseWriter, list *WordList) {
+	respondWithJSON(w, http.StatusCreated, list)
+}
+
+func respSuccesfullWordListPut(w *http.ResponseWriter, list *WordList) {
+	respondWithJSON(w, http.StatusOK, list)
+}
+
+func respSuccesfullFlaggedChirpsGet(w *http.ResponseWriter, chirps []Chirp) {
+	respondWithJSON(w, http.StatusOK, chirps)
+}
+
+func respSuccesfullReportPost(w *http.ResponseWriter, report *OwnReport) {
+	respondWithJSON(w, http.StatusCreated, report)
+}
+
+func respSuccesfullOwnReportsGet(w *http.ResponseWriter, reports []OwnReport) {
+	respondWithJSON(w, http.StatusOK, reports)
+}
+
+func respSuccesfullReportsGet(w *http.ResponseWriter, reports []Report) {
+	respondWithJSON(w, http.StatusOK, reports)
+}
+
+func respSuccesfullReportResolve(w *http.ResponseWriter, report *Report) {
+	respondWithJSON(w, http.StatusOK, report)
+}
+
+func respSuccesfullSuspensionPost(w *http.ResponseWriter, suspension *Suspension) {
+	respondWithJSON(w, http.StatusCreated, suspension)
+}
+
+func respSuccesfullSuspensionsGet(w *http.ResponseWriter, suspensions []Suspension) {
+	respondWithJSON(w, http.StatusOK, suspensions)
+}
+
+func respSuccesfullBlocklistGet(w *http.ResponseWriter, domains []BlockedDomain) {
+	respondWithJSON(w, http.StatusOK, domains)
+}
+
+func respSuccesfullAuditGet(w *http.ResponseWriter, entries []AuditEntry) {
+	respondWithJSON(w, http.StatusOK, entries)
+}
+
+func respSuccesfullAuditVerify(w *http.ResponseWriter, verification *AuditVerification) {
+	respondWithJSON(w, http.StatusOK, verification)
+}
+
+func respSuccesfullFollowsGet(w *http.ResponseWriter, follows *FollowPage) {
+	respondWithJSON(w, http.StatusOK, follows)
+}
+
+func respSuccesfullTimelineGet(w *http.ResponseWriter, chirps *ChirpPage) {
+	respondWithJSON(w, http.StatusOK, chirps)
+}
+
+func respSuccesfullThreadGet(w *http.ResponseWriter, thread *Thread) {
+	respondWithJSON(w, http.StatusOK, thread)
+}
+
+func respSuccesfullLikesGet(w *http.ResponseWriter, chirps *ChirpPage) {
+	respondWithJSON(w, http.StatusOK, chirps)
+}
+
+func respSuccesfullTagChirpsGet(w *http.ResponseWriter, chirps *ChirpPage) {
+	respondWithJSON(w, http.StatusOK, chirps)
+}
+
+func respSuccesfullTrendsGet(w *http.ResponseWriter, trends *Trends) {
+	respondWithJSON(w, http.StatusOK, trends)
+}
+
+func respSuccesfullNotificationsGet(w *http.ResponseWriter, notifications *NotificationPage) {
+	respondWithJSON(w, http.StatusOK, notifications)
+}
+
+func respSuccesfullProfileGet(w *http.ResponseWriter, profile *Profile) {
+	respondWithJSON(w, http.StatusOK, profile)
+}
+
+func respSuccesfullProfilePatch(w *http.ResponseWriter, profile *Profile) {
+	respondWithJSON(w, http.StatusOK, profile)
+}
+
+func respSuccesfullProfileImagePut(w *http.ResponseWriter, profile *Profile) {
+	respondWithJSON(w, http.StatusOK, profile)
+}
+
+// a one to one conversation that already exists is returned with 200
+func respSuccesfullConversationPost(w *http.ResponseWriter, conversation *Conversation, code int) {
+	respondWithJSON(w, code, conversation)
+}
+
+func respSuccesfullConversationsGet(w *http.ResponseWriter, conversations *ConversationPage) {
+	respondWithJSON(w, http.StatusOK, conversations)
+}
+
+func respSuccesfullMessagePost(w *http.ResponseWriter, message *Message) {
+	respondWithJSON(w, http.StatusCreated, message)
+}
+
+func respSuccesfullMessagesGet(w *http.ResponseWriter, messages *MessagePage) {
+	respondWithJSON(w, http.StatusOK, messages)
+}
+
+func respSuccesfullBlocksGet(w *http.ResponseWriter, blocks *BlockPage) {
+	respondWithJSON(w, http.StatusOK, blocks)
+}
+
+func respSuccesfullMutesGet(w *http.ResponseWriter, mutes *MutePage) {
+	respondWithJSON(w, http.StatusOK, mutes)
+}
+
+func respSuccesfullMutedWordPost(w *http.ResponseWriter, mutedWord *MutedWord) {
+	respondWithJSON(w, http.StatusCreated, mutedWord)
+}
+
+func respSuccesfullMutedWordsGet(w *http.ResponseWriter, mutedWords []MutedWord) {
+	respondWithJSON(w, http.StatusOK, mutedWords)
+}
+
+func respSuccesfullBookmarksGet(w *http.ResponseWriter, bookmarks *BookmarkPage) {
+	respondWithJSON(w, http.StatusOK, bookmarks)
+}
+
+func respSuccesfullBookmarkFolderPost(w *http.ResponseWriter, folder *BookmarkFolder) {
+	respondWithJSON(w, http.StatusCreated, folder)
+}
+
+func respSuccesfullBookmarkFolderPut(w *http.ResponseWriter, folder *BookmarkFolder) {
+	respondWithJSON(w, http.StatusOK, folder)
+}
+
+func respSuccesfullBookmarkFoldersGet(w *http.ResponseWriter, folders []BookmarkFolder) {
+	respondWithJSON(w, http.StatusOK, folders)
+}
+
+func respSuccesfullListPost(w *http.ResponseWriter, list *List) {
+	respondWithJSON(w, http.StatusCreated, list)
+}
+
+func respSuccesfullListGet(w *http.ResponseWriter, list *List) {
+	respondWithJSON(w, http.StatusOK, list)
+}
+
+func respSuccesfullListPut(w *http.ResponseWriter, list *List) {
+	respondWithJSON(w, http.StatusOK, list)
+}
+
+func respSuccesfullListsGet(w *http.ResponseWriter, lists []List) {
+	respondWithJSON(w, http.StatusOK, lists)
+}
+
+func respSuccesfullListMembersGet(w *http.ResponseWriter, members *ListMemberPage) {
+	respondWithJSON(w, http.StatusOK, members)
+}
+
+func respSuccesfullPollVotePost(w *http.ResponseWriter, poll *Poll) {
+	respondWithJSON(w, http.StatusCreated, poll)
+}
+
+func respSuccesfullScheduledChirpPost(w *http.ResponseWriter, scheduled *ScheduledChirp) {
+	respondWithJSON(w, http.StatusCreated, scheduled)
+}
+
+func respSuccesfullScheduledChirpGet(w *http.ResponseWriter, scheduled *ScheduledChirp) {
+	respondWithJSON(w, http.StatusOK, scheduled)
+}
+
+func respSuccesfullScheduledChirpPut(w *http.ResponseWriter, scheduled *ScheduledChirp) {
+	respondWithJSON(w, http.StatusOK, scheduled)
+}
+
+func respSuccesfullScheduledChirpsGet(w *http.ResponseWriter, scheduled []ScheduledChirp) {
+	respondWithJSON(w, http.StatusOK, scheduled)
+}
+
+func respSuccesfullMediaPost(w *http.ResponseWriter, media *Media) {
+	respondWithJSON(w, http.StatusCreated, media)
+}
+
+func respSuccesfullMediaPut(w *http.ResponseWriter, media *Media) {
+	respondWithJSON(w, http.StatusOK, media)
+}
+
+func respSuccesfullPreferencesGet(w *http.ResponseWriter, preferences *Preferences) {
+	respondWithJSON(w, http.StatusOK, preferences)
+}
+
+func respSuccesfullPreferencesPut(w *http.ResponseWriter, preferences *Preferences) {
+	respondWithJSON(w, http.StatusOK, preferences)
+}
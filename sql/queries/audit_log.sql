@@ -0,0 +1,47 @@
+-- name: LockAuditLog :exec
+SELECT pg_advisory_xact_lock(hashtext('audit_log'));
+
+-- name: GetLastAuditHash :one
+SELECT hash FROM audit_log
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: CreateAuditEntry :exec
+INSERT INTO audit_log (created_at, action, actor_id, target_type, target_id, ip, user_agent, request_id, metadata, prev_hash, hash)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11
+);
+
+-- name: GetAuditEntries :many
+SELECT * FROM audit_log
+WHERE (sqlc.narg(action)::text IS null OR action = sqlc.narg(action))
+  AND (sqlc.narg(actor_id)::uuid IS null OR actor_id = sqlc.narg(actor_id))
+  AND (sqlc.narg(target_type)::text IS null OR target_type = sqlc.narg(target_type))
+  AND (sqlc.narg(target_id)::text IS null OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(since)::timestamp IS null OR created_at >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamp IS null OR created_at < sqlc.narg(until))
+  AND (sqlc.narg(before_id)::bigint IS null OR id < sqlc.narg(before_id))
+ORDER BY id DESC
+LIMIT sqlc.arg(max_entries);
+
+-- name: GetAuditEntriesAfter :many
+SELECT * FROM audit_log
+WHERE (sqlc.narg(action)::text IS null OR action = sqlc.narg(action))
+  AND (sqlc.narg(actor_id)::uuid IS null OR actor_id = sqlc.narg(actor_id))
+  AND (sqlc.narg(target_type)::text IS null OR target_type = sqlc.narg(target_type))
+  AND (sqlc.narg(target_id)::text IS null OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(since)::timestamp IS null OR created_at >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamp IS null OR created_at < sqlc.narg(until))
+  AND id > sqlc.arg(after_id)
+ORDER BY id ASC
+LIMIT sqlc.arg(max_entries);
@@ -0,0 +1,63 @@
+-- name: BlockUser :execrows
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :execrows
+DELETE FROM blocks
+WHERE blocker_id = $1
+  AND blocked_id = $2;
+
+-- name: GetBlocks :many
+SELECT blocked_id AS user_id, created_at FROM blocks
+WHERE blocker_id = sqlc.arg(user_id)
+  AND (sqlc.narg(before_time)::timestamp IS null
+    OR (created_at, blocked_id) < (sqlc.narg(before_time)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY created_at DESC, blocked_id DESC
+LIMIT sqlc.arg(max_entries);
+
+-- name: GetBlockedAmong :many
+SELECT blocked_id AS user_id FROM blocks
+WHERE blocker_id = sqlc.arg(user_id)
+  AND blocked_id = ANY(sqlc.arg(user_ids)::uuid[])
+UNION
+SELECT blocker_id AS user_id FROM blocks
+WHERE blocked_id = sqlc.arg(user_id)
+  AND blocker_id = ANY(sqlc.arg(user_ids)::uuid[]);
+
+-- name: MuteUser :exec
+INSERT INTO mutes (muter_id, muted_id, created_at, expires_at)
+VALUES (
+    $1,
+    $2,
+    NOW(),
+    $3
+)
+ON CONFLICT (muter_id, muted_id) DO UPDATE
+SET created_at = NOW(),
+    expires_at = EXCLUDED.expires_at;
+
+-- name: UnmuteUser :execrows
+DELETE FROM mutes
+WHERE muter_id = $1
+  AND muted_id = $2
+  AND (expires_at IS null OR expires_at > NOW());
+
+-- name: GetMutes :many
+SELECT muted_id AS user_id, created_at, expires_at FROM mutes
+WHERE muter_id = sqlc.arg(user_id)
+  AND (expires_at IS null OR expires_at > NOW())
+  AND (sqlc.narg(before_time)::timestamp IS null
+    OR (created_at, muted_id) < (sqlc.narg(before_time)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY created_at DESC, muted_id DESC
+LIMIT sqlc.arg(max_entries);
+
+-- name: GetMutedAmong :many
+SELECT muted_id FROM mutes
+WHERE muter_id = sqlc.arg(muter_id)
+  AND muted_id = ANY(sqlc.arg(user_ids)::uuid[])
+  AND (expires_at IS null OR expires_at > NOW());
@@ -0,0 +1,60 @@
+-- name: BookmarkChirp :exec
+INSERT INTO bookmarks (user_id, chirp_id, folder_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    NOW()
+)
+ON CONFLICT (user_id, chirp_id) DO UPDATE
+SET folder_id = EXCLUDED.folder_id;
+
+-- name: DeleteBookmark :execrows
+DELETE FROM bookmarks
+WHERE user_id = $1
+  AND chirp_id = $2;
+
+-- name: GetBookmarks :many
+SELECT * FROM bookmarks
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(folder_id)::uuid IS null OR folder_id = sqlc.narg(folder_id)::uuid)
+  AND (sqlc.narg(before_time)::timestamp IS null
+    OR (created_at, chirp_id) < (sqlc.narg(before_time)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY created_at DESC, chirp_id DESC
+LIMIT sqlc.arg(max_entries);
+
+-- name: CreateBookmarkFolder :one
+INSERT INTO bookmark_folders (id, created_at, user_id, name)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2
+)
+RETURNING *;
+
+-- name: GetBookmarkFolders :many
+SELECT * FROM bookmark_folders
+WHERE user_id = $1
+ORDER BY lower(name);
+
+-- name: GetBookmarkFolder :one
+SELECT * FROM bookmark_folders
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: CountBookmarkFolders :one
+SELECT count(*) FROM bookmark_folders
+WHERE user_id = $1;
+
+-- name: RenameBookmarkFolder :one
+UPDATE bookmark_folders
+SET name = $3
+WHERE id = $1
+  AND user_id = $2
+RETURNING *;
+
+-- name: DeleteBookmarkFolder :execrows
+DELETE FROM bookmark_folders
+WHERE id = $1
+  AND user_id = $2;
@@ -1,30 +1,67 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, needs_review, body_hash, simhash, in_reply_to, root_id, quote_of, visibility, content_warning, sensitive)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11
 )
 RETURNING *;
 
 -- name: GetAllChirpsAsc :many
 SELECT * FROM chirps
+WHERE deleted_at IS null
+  AND NOT EXISTS (
+    SELECT 1 FROM suspensions
+    WHERE suspensions.user_id = chirps.user_id
+      AND suspensions.lifted_at IS null
+      AND (suspensions.expires_at IS null OR suspensions.expires_at > NOW())
+)
 ORDER BY created_at ASC;
 
 -- name: GetChirpsFromAuthorAsc :many
 SELECT * FROM chirps
 WHERE user_id = $1
+  AND deleted_at IS null
+  AND NOT EXISTS (
+      SELECT 1 FROM suspensions
+      WHERE suspensions.user_id = chirps.user_id
+        AND suspensions.lifted_at IS null
+        AND (suspensions.expires_at IS null OR suspensions.expires_at > NOW())
+  )
 ORDER BY created_at ASC;
 
 -- name: GetAllChirpsDesc :many
 SELECT * FROM chirps
+WHERE deleted_at IS null
+  AND NOT EXISTS (
+    SELECT 1 FROM suspensions
+    WHERE suspensions.user_id = chirps.user_id
+      AND suspensions.lifted_at IS null
+      AND (suspensions.expires_at IS null OR suspensions.expires_at > NOW())
+)
 ORDER BY created_at DESC;
 
 -- name: GetChirpsFromAuthorDesc :many
 SELECT * FROM chirps
 WHERE user_id = $1
+  AND deleted_at IS null
+  AND NOT EXISTS (
+      SELECT 1 FROM suspensions
+      WHERE suspensions.user_id = chirps.user_id
+        AND suspensions.lifted_at IS null
+        AND (suspensions.expires_at IS null OR suspensions.expires_at > NOW())
+  )
 ORDER BY created_at DESC;
 
 -- name: GetChirp :one
@@ -32,11 +69,156 @@ SELECT *
 FROM chirps
 WHERE id = $1;
 
--- name: DeleteChirp :exec
-DELETE from chirps
-WHERE id = $1 AND user_id = $2;
+-- name: GetVisibleChirp :one
+SELECT *
+FROM chirps
+WHERE id = $1
+  AND deleted_at IS null
+  AND NOT EXISTS (
+      SELECT 1 FROM suspensions
+      WHERE suspensions.user_id = chirps.user_id
+        AND suspensions.lifted_at IS null
+        AND (suspensions.expires_at IS null OR suspensions.expires_at > NOW())
+  );
 
 -- name: UpdateChirp :exec
 UPDATE chirps
-SET body = $2, updated_at = NOW()
-WHERE id = $1;
\ No newline at end of file
+SET body = sqlc.arg(body),
+    needs_review = needs_review OR sqlc.arg(flag_for_review)::bool,
+    body_hash = sqlc.arg(body_hash),
+    simhash = sqlc.arg(simhash),
+    content_warning = sqlc.arg(content_warning),
+    sensitive = sqlc.arg(sensitive),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: GetChirpsNeedingReview :many
+SELECT * FROM chirps
+WHERE needs_review = true
+  AND deleted_at IS null
+ORDER BY created_at ASC;
+
+-- name: ClearChirpReview :exec
+UPDATE chirps
+SET needs_review = false
+WHERE id = $1;
+
+-- name: DeleteChirpById :exec
+DELETE FROM chirps
+WHERE id = $1;
+
+-- name: GetRecentChirpFingerprints :many
+SELECT body_hash, simhash, created_at
+FROM chirps
+WHERE user_id = $1 AND created_at > $2
+  AND rechirp_of IS null
+ORDER BY created_at DESC
+LIMIT 200;
+
+-- name: SoftDeleteChirp :exec
+UPDATE chirps
+SET body = '', body_hash = '', deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1;
+
+-- name: TombstoneUserChirps :many
+WITH RECURSIVE kept AS (
+    SELECT chirps.id, chirps.in_reply_to FROM chirps
+    WHERE chirps.user_id = sqlc.arg(user_id)
+      AND EXISTS (
+          SELECT 1 FROM chirps AS replies
+          WHERE replies.in_reply_to = chirps.id
+            AND replies.user_id <> sqlc.arg(user_id)
+      )
+    UNION
+    SELECT chirps.id, chirps.in_reply_to FROM chirps
+    JOIN kept ON kept.in_reply_to = chirps.id
+    WHERE chirps.user_id = sqlc.arg(user_id)
+)
+UPDATE chirps
+SET user_id = sqlc.arg(deleted_user_id),
+    body = '',
+    body_hash = '',
+    content_warning = '',
+    deleted_at = COALESCE(deleted_at, NOW()),
+    updated_at = NOW()
+WHERE id IN (SELECT id FROM kept)
+RETURNING id;
+
+-- name: ChirpHasReplies :one
+SELECT EXISTS (
+    SELECT 1 FROM chirps
+    WHERE in_reply_to = $1
+);
+
+-- name: GetReplyCounts :many
+SELECT in_reply_to AS chirp_id, count(*) AS reply_count
+FROM chirps
+WHERE in_reply_to = ANY(sqlc.arg(chirp_ids)::uuid[])
+  AND deleted_at IS null
+GROUP BY in_reply_to;
+
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT parent.id, parent.in_reply_to, 1 AS depth
+    FROM chirps AS child
+    JOIN chirps AS parent ON parent.id = child.in_reply_to
+    WHERE child.id = $1
+    UNION ALL
+    SELECT parent.id, parent.in_reply_to, ancestors.depth + 1
+    FROM ancestors
+    JOIN chirps AS parent ON parent.id = ancestors.in_reply_to
+)
+SELECT chirps.* FROM chirps
+JOIN ancestors ON ancestors.id = chirps.id
+ORDER BY ancestors.depth DESC;
+
+-- name: GetChirpReplies :many
+SELECT * FROM chirps
+WHERE in_reply_to = sqlc.arg(id)
+  AND (sqlc.narg(after_time)::timestamp IS null
+    OR (created_at, id) > (sqlc.narg(after_time)::timestamp, sqlc.narg(after_id)::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg(max_entries);
+
+-- name: GetChirpDescendants :many
+WITH RECURSIVE descendants AS (
+    SELECT chirps.id, 1 AS depth
+    FROM chirps
+    WHERE chirps.in_reply_to = ANY(sqlc.arg(parent_ids)::uuid[])
+    UNION ALL
+    SELECT chirps.id, descendants.depth + 1
+    FROM descendants
+    JOIN chirps ON chirps.in_reply_to = descendants.id
+    WHERE descendants.depth < sqlc.arg(max_depth)::int
+)
+SELECT chirps.* FROM chirps
+JOIN descendants ON descendants.id = chirps.id
+ORDER BY chirps.created_at ASC, chirps.id ASC
+LIMIT sqlc.arg(max_entries);
+
+-- name: GetVisibleChirpsByIds :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg(ids)::uuid[])
+  AND deleted_at IS null
+  AND NOT EXISTS (
+      SELECT 1 FROM suspensions
+      WHERE suspensions.user_id = chirps.user_id
+        AND suspensions.lifted_at IS null
+        AND (suspensions.expires_at IS null OR suspensions.expires_at > NOW())
+  );
+
+-- name: SetChirpVisibility :execrows
+UPDATE chirps
+SET visibility = sqlc.arg(visibility),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+  AND (NOT sqlc.arg(restricts)::bool
+    OR NOT EXISTS (SELECT 1 FROM chirps replies WHERE replies.in_reply_to = chirps.id));
+
+-- name: ForceChirpWarning :execrows
+UPDATE chirps
+SET forced_content_warning = sqlc.arg(forced_content_warning),
+    forced_sensitive = sqlc.arg(forced_sensitive)
+WHERE id = sqlc.arg(id)
+  AND deleted_at IS null
+  AND rechirp_of IS null;
@@ -0,0 +1,44 @@
+-- name: FollowUser :execrows
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :execrows
+DELETE FROM follows
+WHERE follower_id = $1
+  AND followee_id = $2;
+
+-- name: GetFollowers :many
+SELECT follower_id AS user_id, created_at FROM follows
+WHERE followee_id = sqlc.arg(user_id)
+  AND (sqlc.narg(before_time)::timestamp IS null
+    OR (created_at, follower_id) < (sqlc.narg(before_time)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY created_at DESC, follower_id DESC
+LIMIT sqlc.arg(max_entries);
+
+-- name: GetFollowing :many
+SELECT followee_id AS user_id, created_at FROM follows
+WHERE follower_id = sqlc.arg(user_id)
+  AND (sqlc.narg(before_time)::timestamp IS null
+    OR (created_at, followee_id) < (sqlc.narg(before_time)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY created_at DESC, followee_id DESC
+LIMIT sqlc.arg(max_entries);
+
+-- name: GetFollowCounts :one
+SELECT
+    (SELECT count(*) FROM follows WHERE followee_id = $1) AS followers_count,
+    (SELECT count(*) FROM follows WHERE follower_id = $1) AS following_count;
+
+-- name: GetFollowersAmong :many
+SELECT follower_id FROM follows
+WHERE followee_id = sqlc.arg(followee_id)
+  AND follower_id = ANY(sqlc.arg(user_ids)::uuid[]);
+
+-- name: GetFollowedAmong :many
+SELECT followee_id FROM follows
+WHERE follower_id = sqlc.arg(follower_id)
+  AND followee_id = ANY(sqlc.arg(user_ids)::uuid[]);
@@ -0,0 +1,67 @@
+-- name: LockUserFollows :exec
+SELECT id FROM users
+WHERE id = $1
+FOR UPDATE;
+
+-- name: FanOutChirp :exec
+INSERT INTO home_timeline (user_id, chirp_id, author_id, created_at)
+SELECT follows.follower_id, chirps.id, chirps.user_id, chirps.created_at
+FROM chirps
+JOIN follows ON follows.followee_id = chirps.user_id
+WHERE chirps.id = $1
+UNION ALL
+SELECT chirps.user_id, chirps.id, chirps.user_id, chirps.created_at
+FROM chirps
+WHERE chirps.id = $1
+ON CONFLICT DO NOTHING;
+
+-- name: MarkChirpFannedOut :exec
+UPDATE chirps
+SET fanned_out = true
+WHERE id = $1;
+
+-- name: BackfillHomeTimeline :exec
+INSERT INTO home_timeline (user_id, chirp_id, author_id, created_at)
+SELECT sqlc.arg(follower_id)::uuid, chirps.id, chirps.user_id, chirps.created_at
+FROM chirps
+WHERE chirps.user_id = sqlc.arg(followee_id)
+  AND chirps.fanned_out
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteHomeTimelineAuthor :exec
+DELETE FROM home_timeline
+WHERE user_id = $1
+  AND author_id = $2;
+
+-- name: GetFannedOutTimeline :many
+SELECT chirps.* FROM home_timeline
+JOIN chirps ON chirps.id = home_timeline.chirp_id
+WHERE home_timeline.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(before_time)::timestamp IS null
+    OR (home_timeline.created_at, home_timeline.chirp_id) < (sqlc.narg(before_time)::timestamp, sqlc.narg(before_id)::uuid))
+  AND chirps.deleted_at IS null
+  AND NOT EXISTS (
+      SELECT 1 FROM suspensions
+      WHERE suspensions.user_id = chirps.user_id
+        AND suspensions.lifted_at IS null
+        AND (suspensions.expires_at IS null OR suspensions.expires_at > NOW())
+  )
+ORDER BY home_timeline.created_at DESC, home_timeline.chirp_id DESC
+LIMIT sqlc.arg(max_entries);
+
+-- name: GetUnfannedTimeline :many
+SELECT * FROM chirps
+WHERE NOT fanned_out
+  AND (user_id = sqlc.arg(user_id)
+    OR user_id IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.arg(user_id)))
+  AND (sqlc.narg(before_time)::timestamp IS null
+    OR (created_at, id) < (sqlc.narg(before_time)::timestamp, sqlc.narg(before_id)::uuid))
+  AND deleted_at IS null
+  AND NOT EXISTS (
+      SELECT 1 FROM suspensions
+      WHERE suspensions.user_id = chirps.user_id
+        AND suspensions.lifted_at IS null
+        AND (suspensions.expires_at IS null OR suspensions.expires_at > NOW())
+  )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_entries);
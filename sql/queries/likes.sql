@@ -0,0 +1,32 @@
+-- name: LikeChirp :execrows
+INSERT INTO likes (user_id, chirp_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnlikeChirp :execrows
+DELETE FROM likes
+WHERE user_id = $1
+  AND chirp_id = $2;
+
+-- name: GetLikeCounts :many
+SELECT chirp_id, count(*) AS like_count
+FROM likes
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+GROUP BY chirp_id;
+
+-- name: GetLikedAmong :many
+SELECT chirp_id FROM likes
+WHERE user_id = sqlc.arg(user_id)
+  AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: GetUserLikes :many
+SELECT chirp_id, created_at FROM likes
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(before_time)::timestamp IS null
+    OR (created_at, chirp_id) < (sqlc.narg(before_time)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY created_at DESC, chirp_id DESC
+LIMIT sqlc.arg(max_entries);
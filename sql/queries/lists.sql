@@ -0,0 +1,116 @@
+-- name: CreateList :one
+INSERT INTO lists (id, created_at, updated_at, owner_id, name, description, private)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: GetList :one
+SELECT * FROM lists
+WHERE id = $1;
+
+-- name: LockList :exec
+SELECT id FROM lists
+WHERE id = $1
+FOR UPDATE;
+
+-- name: UpdateList :one
+UPDATE lists
+SET name = $2,
+    description = $3,
+    private = $4,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteList :exec
+DELETE FROM lists
+WHERE id = $1;
+
+-- name: CountOwnedLists :one
+SELECT count(*) FROM lists
+WHERE owner_id = $1;
+
+-- name: GetUserLists :many
+SELECT * FROM lists
+WHERE owner_id = $1
+   OR id IN (SELECT list_id FROM list_subscriptions WHERE user_id = $1)
+ORDER BY lower(name), id;
+
+-- name: GetListCounts :many
+SELECT
+    lists.id AS list_id,
+    (SELECT count(*) FROM list_members WHERE list_members.list_id = lists.id) AS member_count,
+    (SELECT count(*) FROM list_subscriptions WHERE list_subscriptions.list_id = lists.id) AS subscriber_count
+FROM lists
+WHERE lists.id = ANY(sqlc.arg(list_ids)::uuid[]);
+
+-- name: GetSubscribedAmong :many
+SELECT list_id FROM list_subscriptions
+WHERE user_id = sqlc.arg(user_id)
+  AND list_id = ANY(sqlc.arg(list_ids)::uuid[]);
+
+-- name: AddListMember :execrows
+INSERT INTO list_members (list_id, user_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveListMember :execrows
+DELETE FROM list_members
+WHERE list_id = $1
+  AND user_id = $2;
+
+-- name: CountListMembers :one
+SELECT count(*) FROM list_members
+WHERE list_id = $1;
+
+-- name: GetListMembers :many
+SELECT user_id, created_at FROM list_members
+WHERE list_id = sqlc.arg(list_id)
+  AND (sqlc.narg(before_time)::timestamp IS null
+    OR (created_at, user_id) < (sqlc.narg(before_time)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY created_at DESC, user_id DESC
+LIMIT sqlc.arg(max_entries);
+
+-- name: SubscribeList :execrows
+INSERT INTO list_subscriptions (list_id, user_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnsubscribeList :execrows
+DELETE FROM list_subscriptions
+WHERE list_id = $1
+  AND user_id = $2;
+
+-- name: DeleteListSubscriptions :exec
+DELETE FROM list_subscriptions
+WHERE list_id = $1;
+
+-- name: GetListTimeline :many
+SELECT * FROM chirps
+WHERE user_id IN (SELECT list_members.user_id FROM list_members WHERE list_members.list_id = sqlc.arg(list_id))
+  AND deleted_at IS null
+  AND NOT EXISTS (
+      SELECT 1 FROM suspensions
+      WHERE suspensions.user_id = chirps.user_id
+        AND suspensions.lifted_at IS null
+        AND (suspensions.expires_at IS null OR suspensions.expires_at > NOW())
+  )
+  AND (sqlc.narg(before_time)::timestamp IS null
+    OR (created_at, id) < (sqlc.narg(before_time)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_entries);
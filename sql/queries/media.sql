@@ -0,0 +1,47 @@
+-- name: CreateMedia :one
+INSERT INTO media (id, created_at, user_id, content_type, width, height, size, alt_text, blurhash, original_key, thumbnail_key)
+VALUES (
+    $1,
+    NOW(),
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10
+)
+RETURNING *;
+
+-- name: UpdateMediaAltText :one
+UPDATE media
+SET alt_text = $3
+WHERE id = $1
+  AND user_id = $2
+RETURNING *;
+
+-- name: AttachMedia :execrows
+UPDATE media
+SET chirp_id = $3,
+    position = $4
+WHERE id = $1
+  AND user_id = $2
+  AND chirp_id IS null;
+
+-- name: GetChirpMedia :many
+SELECT * FROM media
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: ClaimOrphanedMedia :many
+SELECT * FROM media
+WHERE (media.chirp_id IS null AND (media.user_id IS null OR media.created_at < sqlc.arg(created_before)))
+   OR media.chirp_id IN (SELECT chirps.id FROM chirps WHERE chirps.deleted_at IS NOT null)
+LIMIT sqlc.arg(max_entries)
+FOR UPDATE SKIP LOCKED;
+
+-- name: DeleteMediaByIds :exec
+DELETE FROM media
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
@@ -0,0 +1,20 @@
+-- name: GetUsersByHandles :many
+SELECT id, handle FROM users
+WHERE lower(handle) = ANY(sqlc.arg(handles)::text[]);
+
+-- name: AddChirpMentions :many
+INSERT INTO chirp_mentions (chirp_id, user_id)
+SELECT sqlc.arg(chirp_id)::uuid, mentioned
+FROM unnest(sqlc.arg(user_ids)::uuid[]) AS mentioned
+ON CONFLICT DO NOTHING
+RETURNING user_id;
+
+-- name: DeleteStaleChirpMentions :exec
+DELETE FROM chirp_mentions
+WHERE chirp_id = sqlc.arg(chirp_id)
+  AND NOT user_id = ANY(sqlc.arg(user_ids)::uuid[]);
+
+-- name: GetMentionedAmong :many
+SELECT chirp_id FROM chirp_mentions
+WHERE user_id = sqlc.arg(user_id)
+  AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
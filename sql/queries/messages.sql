@@ -0,0 +1,94 @@
+-- name: CreateConversation :one
+INSERT INTO conversations (id, created_at, updated_at, created_by, direct_key)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2
+)
+RETURNING *;
+
+-- name: GetDirectConversation :one
+SELECT * FROM conversations
+WHERE direct_key = $1;
+
+-- name: GetConversationForUser :one
+SELECT conversations.* FROM conversations
+JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id
+WHERE conversations.id = sqlc.arg(id)
+  AND conversation_participants.user_id = sqlc.arg(user_id);
+
+-- name: AddConversationParticipants :exec
+INSERT INTO conversation_participants (conversation_id, user_id, joined_at)
+SELECT sqlc.arg(conversation_id)::uuid, participant, NOW()
+FROM unnest(sqlc.arg(user_ids)::uuid[]) AS participant
+ON CONFLICT DO NOTHING;
+
+-- name: GetConversationParticipants :many
+SELECT conversation_id, user_id FROM conversation_participants
+WHERE conversation_id = ANY(sqlc.arg(conversation_ids)::uuid[])
+ORDER BY conversation_id, joined_at, user_id;
+
+-- name: GetConversations :many
+SELECT conversations.* FROM conversations
+JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id
+WHERE conversation_participants.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(before_time)::timestamp IS null
+    OR (conversations.updated_at, conversations.id) < (sqlc.narg(before_time)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY conversations.updated_at DESC, conversations.id DESC
+LIMIT sqlc.arg(max_entries);
+
+-- name: TouchConversation :exec
+UPDATE conversations
+SET updated_at = NOW()
+WHERE id = $1;
+
+-- name: CreateMessage :one
+INSERT INTO messages (id, created_at, conversation_id, sender_id, body, body_hash, simhash)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+RETURNING *;
+
+-- name: GetMessages :many
+SELECT * FROM messages
+WHERE conversation_id = sqlc.arg(conversation_id)
+  AND (sqlc.narg(before_time)::timestamp IS null
+    OR (created_at, id) < (sqlc.narg(before_time)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_entries);
+
+-- name: GetLastMessages :many
+SELECT DISTINCT ON (conversation_id) * FROM messages
+WHERE conversation_id = ANY(sqlc.arg(conversation_ids)::uuid[])
+ORDER BY conversation_id, created_at DESC, id DESC;
+
+-- name: GetUnreadMessageCounts :many
+SELECT messages.conversation_id, count(*) AS unread_count
+FROM messages
+JOIN conversation_participants ON conversation_participants.conversation_id = messages.conversation_id
+WHERE conversation_participants.user_id = sqlc.arg(user_id)
+  AND messages.conversation_id = ANY(sqlc.arg(conversation_ids)::uuid[])
+  AND messages.sender_id <> sqlc.arg(user_id)
+  AND (conversation_participants.last_read_at IS null OR messages.created_at > conversation_participants.last_read_at)
+GROUP BY messages.conversation_id;
+
+-- name: MarkConversationRead :exec
+UPDATE conversation_participants
+SET last_read_at = NOW()
+WHERE conversation_id = $1
+  AND user_id = $2;
+
+-- name: GetRecentMessageFingerprints :many
+SELECT body_hash, simhash, created_at
+FROM messages
+WHERE sender_id = $1 AND created_at > $2
+ORDER BY created_at DESC
+LIMIT 200;
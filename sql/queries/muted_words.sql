@@ -0,0 +1,40 @@
+-- name: CreateMutedWord :one
+INSERT INTO muted_words (id, created_at, user_id, phrase, scope, action, whole_word, expires_at)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING *;
+
+-- name: GetMutedWords :many
+SELECT * FROM muted_words
+WHERE user_id = $1
+  AND (expires_at IS null OR expires_at > NOW())
+ORDER BY created_at DESC;
+
+-- name: GetActiveMutedWords :many
+SELECT * FROM muted_words
+WHERE user_id = sqlc.arg(user_id)
+  AND scope = ANY(sqlc.arg(scopes)::text[])
+  AND (expires_at IS null OR expires_at > NOW());
+
+-- name: CountMutedWords :one
+SELECT count(*) FROM muted_words
+WHERE user_id = $1
+  AND (expires_at IS null OR expires_at > NOW());
+
+-- name: DeleteExpiredMutedWords :exec
+DELETE FROM muted_words
+WHERE user_id = $1
+  AND expires_at <= NOW();
+
+-- name: DeleteMutedWord :execrows
+DELETE FROM muted_words
+WHERE id = $1
+  AND user_id = $2;
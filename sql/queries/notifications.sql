@@ -0,0 +1,59 @@
+-- name: UpsertNotification :one
+INSERT INTO notifications (id, created_at, updated_at, user_id, kind, chirp_id, group_key)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (user_id, group_key) WHERE read_at IS null
+DO UPDATE SET updated_at = NOW()
+RETURNING id;
+
+-- name: AddNotificationActor :exec
+INSERT INTO notification_actors (notification_id, actor_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (notification_id, actor_id)
+DO UPDATE SET created_at = NOW();
+
+-- name: GetNotifications :many
+SELECT * FROM notifications
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(before_time)::timestamp IS null
+    OR (updated_at, id) < (sqlc.narg(before_time)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY updated_at DESC, id DESC
+LIMIT sqlc.arg(max_entries);
+
+-- name: GetNotificationActors :many
+SELECT notification_id, actor_id, total FROM (
+    SELECT notification_id,
+        actor_id,
+        created_at,
+        row_number() OVER (PARTITION BY notification_id ORDER BY created_at DESC, actor_id) AS rank,
+        count(*) OVER (PARTITION BY notification_id) AS total
+    FROM notification_actors
+    WHERE notification_id = ANY(sqlc.arg(notification_ids)::uuid[])
+) AS ranked
+WHERE rank <= sqlc.arg(max_actors)::int
+ORDER BY notification_id, created_at DESC, actor_id;
+
+-- name: CountUnreadNotifications :one
+SELECT count(*) FROM notifications
+WHERE user_id = $1
+  AND read_at IS null;
+
+-- name: MarkNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = sqlc.arg(user_id)
+  AND id = ANY(sqlc.arg(ids)::uuid[])
+  AND read_at IS null;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1
+  AND read_at IS null;
@@ -0,0 +1,67 @@
+-- name: CreatePoll :exec
+INSERT INTO polls (chirp_id, created_at, closes_at)
+VALUES (
+    $1,
+    NOW(),
+    $2
+);
+
+-- name: CreatePollOption :exec
+INSERT INTO poll_options (id, chirp_id, position, text)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3
+);
+
+-- name: GetPoll :one
+SELECT * FROM polls
+WHERE chirp_id = $1;
+
+-- name: GetPolls :many
+SELECT * FROM polls
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: GetPollOptions :many
+SELECT * FROM poll_options
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: GetPollVotesAmong :many
+SELECT chirp_id, option_id FROM poll_votes
+WHERE user_id = sqlc.arg(user_id)
+  AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: CastPollVote :execrows
+WITH vote AS (
+    INSERT INTO poll_votes (chirp_id, user_id, option_id, created_at)
+    SELECT poll_options.chirp_id, sqlc.arg(user_id)::uuid, poll_options.id, NOW()
+    FROM poll_options
+    JOIN polls ON polls.chirp_id = poll_options.chirp_id
+    WHERE poll_options.id = sqlc.arg(option_id)
+      AND poll_options.chirp_id = sqlc.arg(chirp_id)
+      AND polls.closes_at > NOW()
+    ON CONFLICT (chirp_id, user_id) DO NOTHING
+    RETURNING option_id
+)
+UPDATE poll_options
+SET vote_count = vote_count + 1
+WHERE id IN (SELECT vote.option_id FROM vote);
+
+-- name: FinalizeClosedPolls :many
+UPDATE polls
+SET finalized_at = NOW()
+WHERE chirp_id IN (
+    SELECT pending.chirp_id FROM polls AS pending
+    WHERE pending.finalized_at IS null
+      AND pending.closes_at <= NOW()
+    ORDER BY pending.closes_at
+    LIMIT sqlc.arg(max_entries)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING chirp_id;
+
+-- name: GetPollVoters :many
+SELECT user_id FROM poll_votes
+WHERE chirp_id = $1;
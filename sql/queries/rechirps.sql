@@ -0,0 +1,33 @@
+-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, rechirp_of)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    '',
+    $1,
+    $2
+)
+RETURNING *;
+
+-- name: DeleteRechirp :execrows
+DELETE FROM chirps
+WHERE user_id = $1
+  AND rechirp_of = $2;
+
+-- name: DeleteRechirpsOf :exec
+DELETE FROM chirps
+WHERE rechirp_of = $1;
+
+-- name: DeleteRechirpsOfChirps :exec
+DELETE FROM chirps
+WHERE rechirp_of = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: GetShareCounts :many
+SELECT coalesce(rechirp_of, quote_of)::uuid AS chirp_id,
+    count(rechirp_of) AS rechirp_count,
+    count(quote_of) AS quote_count
+FROM chirps
+WHERE (rechirp_of = ANY(sqlc.arg(chirp_ids)::uuid[]) OR quote_of = ANY(sqlc.arg(chirp_ids)::uuid[]))
+  AND deleted_at IS null
+GROUP BY coalesce(rechirp_of, quote_of);
@@ -0,0 +1,45 @@
+-- name: CreateReport :one
+INSERT INTO reports (id, created_at, updated_at, chirp_id, chirp_author_id, chirp_body, reporter_id, reason, details)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING *;
+
+-- name: GetReport :one
+SELECT * FROM reports
+WHERE id = $1;
+
+-- name: LockPendingReports :many
+SELECT * FROM reports
+WHERE status = 'pending'
+  AND (id = $1 OR chirp_id = (SELECT chirp_id FROM reports WHERE id = $1))
+ORDER BY id
+FOR UPDATE;
+
+-- name: GetReportsByStatus :many
+SELECT * FROM reports
+WHERE status = $1
+ORDER BY created_at ASC;
+
+-- name: GetReportsByReporter :many
+SELECT * FROM reports
+WHERE reporter_id = $1
+ORDER BY created_at DESC;
+
+-- name: ResolveReport :exec
+UPDATE reports
+SET status = $2, resolution_note = $3, resolved_by = $4, resolved_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND status = 'pending';
+
+-- name: ResolvePendingReportsForChirp :exec
+UPDATE reports
+SET status = $2, resolution_note = $3, resolved_by = $4, resolved_at = NOW(), updated_at = NOW()
+WHERE chirp_id = $1 AND status = 'pending';
@@ -0,0 +1,87 @@
+-- name: CreateScheduledChirp :one
+INSERT INTO scheduled_chirps (id, created_at, updated_at, user_id, body, in_reply_to, quote_of, publish_at, visibility, content_warning, sensitive)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+RETURNING *;
+
+-- name: GetScheduledChirps :many
+SELECT * FROM scheduled_chirps
+WHERE user_id = $1
+ORDER BY publish_at ASC NULLS LAST, updated_at DESC;
+
+-- name: GetScheduledChirp :one
+SELECT * FROM scheduled_chirps
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: CountScheduledChirps :one
+SELECT COUNT(*) FROM scheduled_chirps
+WHERE user_id = $1;
+
+-- name: UpdateScheduledChirp :one
+UPDATE scheduled_chirps
+SET body = $3,
+    in_reply_to = $4,
+    quote_of = $5,
+    publish_at = $6,
+    visibility = $7,
+    content_warning = $8,
+    sensitive = $9,
+    last_error = null,
+    attempts = 0,
+    updated_at = NOW()
+WHERE id = $1
+  AND user_id = $2
+RETURNING *;
+
+-- name: DeleteScheduledChirp :execrows
+DELETE FROM scheduled_chirps
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: ClaimDueScheduledChirp :one
+SELECT * FROM scheduled_chirps
+WHERE publish_at <= NOW()
+ORDER BY publish_at, id
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: DeletePublishedScheduledChirp :exec
+DELETE FROM scheduled_chirps
+WHERE id = $1;
+
+-- name: FailScheduledChirp :exec
+UPDATE scheduled_chirps
+SET publish_at = null,
+    last_error = $2,
+    attempts = 0,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: PostponeScheduledChirp :exec
+UPDATE scheduled_chirps
+SET publish_at = $2
+WHERE id = $1;
+
+-- name: RetryScheduledChirp :exec
+UPDATE scheduled_chirps
+SET publish_at = $2,
+    attempts = attempts + 1
+WHERE id = $1;
+
+-- name: SavepointScheduledChirp :exec
+SAVEPOINT publish_scheduled_chirp;
+
+-- name: RollbackScheduledChirp :exec
+ROLLBACK TO SAVEPOINT publish_scheduled_chirp;
@@ -0,0 +1,37 @@
+-- name: CreateSuspension :one
+INSERT INTO suspensions (id, created_at, user_id, moderator_id, reason, expires_at)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: GetActiveSuspension :one
+SELECT * FROM suspensions
+WHERE user_id = $1
+  AND lifted_at IS null
+  AND (expires_at IS null OR expires_at > NOW())
+ORDER BY expires_at DESC NULLS FIRST
+LIMIT 1;
+
+-- name: GetSuspensionsForUser :many
+SELECT * FROM suspensions
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: LiftActiveSuspensions :execrows
+UPDATE suspensions
+SET lifted_at = NOW(), lifted_by = $2
+WHERE user_id = $1
+  AND lifted_at IS null
+  AND (expires_at IS null OR expires_at > NOW());
+
+-- name: GetSuspendedUsers :many
+SELECT DISTINCT user_id FROM suspensions
+WHERE user_id = ANY(sqlc.arg(user_ids)::uuid[])
+  AND lifted_at IS null
+  AND (expires_at IS null OR expires_at > NOW());
@@ -0,0 +1,62 @@
+-- name: AddChirpTags :exec
+INSERT INTO chirp_tags (chirp_id, tag, created_at)
+SELECT sqlc.arg(chirp_id)::uuid, tag, sqlc.arg(created_at)::timestamp
+FROM unnest(sqlc.arg(tags)::text[]) AS tag
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpTags :exec
+DELETE FROM chirp_tags
+WHERE chirp_id = $1;
+
+-- name: DeleteTagsOfChirps :exec
+DELETE FROM chirp_tags
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: GetTagChirps :many
+SELECT chirps.* FROM chirps
+JOIN chirp_tags ON chirp_tags.chirp_id = chirps.id
+WHERE chirp_tags.tag = sqlc.arg(tag)
+  AND chirps.deleted_at IS null
+  AND NOT chirps.needs_review
+  AND NOT EXISTS (
+      SELECT 1 FROM suspensions
+      WHERE suspensions.user_id = chirps.user_id
+        AND suspensions.lifted_at IS null
+        AND (suspensions.expires_at IS null OR suspensions.expires_at > NOW())
+  )
+  AND (sqlc.narg(before_time)::timestamp IS null
+    OR (chirps.created_at, chirps.id) < (sqlc.narg(before_time)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(max_entries);
+
+-- name: LockTrendingTags :exec
+SELECT pg_advisory_xact_lock(hashtext('trending_tags'));
+
+-- name: ClearTrendingTags :exec
+DELETE FROM trending_tags;
+
+-- name: ComputeTrendingTags :exec
+INSERT INTO trending_tags (tag, score, chirp_count, computed_at)
+SELECT chirp_tags.tag,
+    sum(power(0.5, extract(epoch FROM NOW() - chirp_tags.created_at) / sqlc.arg(half_life_seconds)::float8)) AS score,
+    count(*),
+    NOW()
+FROM chirp_tags
+JOIN chirps ON chirps.id = chirp_tags.chirp_id
+WHERE chirp_tags.created_at > sqlc.arg(window_start)
+  AND chirps.deleted_at IS null
+  AND chirps.visibility = 'public'
+  AND NOT chirps.needs_review
+  AND NOT EXISTS (
+      SELECT 1 FROM suspensions
+      WHERE suspensions.user_id = chirps.user_id
+        AND suspensions.lifted_at IS null
+        AND (suspensions.expires_at IS null OR suspensions.expires_at > NOW())
+  )
+GROUP BY chirp_tags.tag
+ORDER BY score DESC
+LIMIT sqlc.arg(max_entries);
+
+-- name: GetTrendingTags :many
+SELECT * FROM trending_tags
+ORDER BY score DESC, tag ASC;
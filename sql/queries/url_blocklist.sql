@@ -0,0 +1,12 @@
+-- name: AddBlockedDomain :exec
+INSERT INTO url_blocklist (domain, created_at, created_by)
+VALUES ($1, NOW(), $2)
+ON CONFLICT (domain) DO NOTHING;
+
+-- name: DeleteBlockedDomain :exec
+DELETE FROM url_blocklist
+WHERE domain = $1;
+
+-- name: GetBlockedDomains :many
+SELECT * FROM url_blocklist
+ORDER BY domain ASC;
@@ -10,7 +10,12 @@ VALUES (
 RETURNING *;
 
 -- name: Reset :exec
-DELETE FROM users CASCADE;
+WITH tombstones AS (
+    DELETE FROM chirps
+    WHERE user_id = sqlc.arg(deleted_user_id)
+)
+DELETE FROM users
+WHERE id <> sqlc.arg(deleted_user_id);
 
 -- name: FindUserByEmail :one
 SELECT * FROM users
@@ -30,6 +35,46 @@ UPDATE users
 SET is_chirpy_red = true
 WHERE id = $1;
 
--- name: DeleteUser :exec
+-- name: DeleteUser :one
 DELETE FROM users
+WHERE id = $1
+RETURNING avatar_key, banner_key;
+
+-- name: SetUserRole :exec
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: FindUserByHandle :one
+SELECT * FROM users
+WHERE lower(handle) = lower($1);
+
+-- name: SetUserHandle :execrows
+UPDATE users
+SET handle = sqlc.arg(handle), handle_changed_at = NOW(), updated_at = NOW()
+WHERE id = sqlc.arg(id)
+  AND (handle_changed_at IS null OR handle_changed_at < sqlc.arg(cooldown_start));
+
+-- name: UpdateUserProfile :exec
+UPDATE users
+SET display_name = $2, bio = $3, location = $4, website = $5, updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetUserAvatar :one
+UPDATE users
+SET avatar_key = $2, updated_at = NOW()
+FROM (SELECT id, avatar_key FROM users WHERE id = $1 FOR UPDATE) old
+WHERE users.id = old.id
+RETURNING old.avatar_key;
+
+-- name: SetUserBanner :one
+UPDATE users
+SET banner_key = $2, updated_at = NOW()
+FROM (SELECT id, banner_key FROM users WHERE id = $1 FOR UPDATE) old
+WHERE users.id = old.id
+RETURNING old.banner_key;
+
+-- name: SetUserExpandFlaggedContent :exec
+UPDATE users
+SET expand_flagged_content = $2, updated_at = NOW()
 WHERE id = $1;
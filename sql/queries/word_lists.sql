@@ -0,0 +1,40 @@
+-- name: CreateWordList :one
+INSERT INTO word_lists (id, created_at, updated_at, name, action)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2
+)
+RETURNING *;
+
+-- name: GetWordLists :many
+SELECT * FROM word_lists
+ORDER BY name ASC;
+
+-- name: GetWordList :one
+SELECT * FROM word_lists
+WHERE id = $1;
+
+-- name: UpdateWordListAction :exec
+UPDATE word_lists
+SET action = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteWordList :exec
+DELETE FROM word_lists
+WHERE id = $1;
+
+-- name: AddWordListEntry :exec
+INSERT INTO word_list_entries (list_id, word, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (list_id, word) DO NOTHING;
+
+-- name: DeleteWordListEntry :exec
+DELETE FROM word_list_entries
+WHERE list_id = $1 AND word = $2;
+
+-- name: GetWordListEntries :many
+SELECT * FROM word_list_entries
+ORDER BY list_id, word;
@@ -0,0 +1,44 @@
+-- +goose Up
+CREATE TABLE word_lists(
+    id uuid primary key not null,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    name text not null unique,
+    action text not null default 'censor'
+);
+
+ALTER TABLE word_lists
+ADD CONSTRAINT word_lists_action_check
+CHECK (action IN ('censor', 'reject', 'flag'));
+
+CREATE TABLE word_list_entries(
+    list_id uuid not null,
+    word text not null,
+    created_at timestamp not null,
+    primary key (list_id, word)
+);
+
+ALTER TABLE word_list_entries
+ADD CONSTRAINT fk_word_list
+FOREIGN KEY (list_id)
+REFERENCES word_lists(id)
+ON DELETE CASCADE;
+
+INSERT INTO word_lists (id, created_at, updated_at, name, action)
+VALUES (gen_random_uuid(), NOW(), NOW(), 'default', 'censor');
+
+INSERT INTO word_list_entries (list_id, word, created_at)
+SELECT word_lists.id, w, NOW()
+FROM word_lists, unnest(ARRAY['kerfuffle', 'sharbert', 'fornax']) AS w
+WHERE word_lists.name = 'default';
+
+ALTER TABLE chirps
+ADD COLUMN needs_review BOOL NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN needs_review;
+
+DROP TABLE word_list_entries;
+
+DROP TABLE word_lists;
@@ -0,0 +1,71 @@
+-- +goose Up
+CREATE TABLE reports(
+    id uuid primary key not null,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    chirp_id uuid,
+    chirp_author_id uuid not null,
+    chirp_body text not null,
+    reporter_id uuid not null,
+    reason text not null,
+    details text not null default '',
+    status text not null default 'pending',
+    resolution_note text,
+    resolved_by uuid,
+    resolved_at timestamp
+);
+
+ALTER TABLE reports
+ADD CONSTRAINT reports_reason_check
+CHECK (reason IN ('spam', 'harassment', 'hate_speech', 'violence', 'sexual_content', 'misinformation', 'self_harm', 'other'));
+
+ALTER TABLE reports
+ADD CONSTRAINT reports_status_check
+CHECK (status IN ('pending', 'dismissed', 'chirp_deleted', 'author_suspended'));
+
+ALTER TABLE reports
+ADD CONSTRAINT fk_chirp
+FOREIGN KEY (chirp_id)
+REFERENCES chirps(id)
+ON DELETE SET NULL;
+
+ALTER TABLE reports
+ADD CONSTRAINT fk_reporter
+FOREIGN KEY (reporter_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+ALTER TABLE reports
+ADD CONSTRAINT fk_resolved_by
+FOREIGN KEY (resolved_by)
+REFERENCES users(id)
+ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX reports_chirp_reporter_idx ON reports (chirp_id, reporter_id);
+
+CREATE INDEX reports_status_idx ON reports (status, created_at);
+
+CREATE TABLE suspensions(
+    id uuid primary key not null,
+    created_at timestamp not null,
+    user_id uuid not null,
+    moderator_id uuid,
+    reason text not null
+);
+
+ALTER TABLE suspensions
+ADD CONSTRAINT fk_user
+FOREIGN KEY (user_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+ALTER TABLE suspensions
+ADD CONSTRAINT fk_moderator
+FOREIGN KEY (moderator_id)
+REFERENCES users(id)
+ON DELETE SET NULL;
+
+-- +goose Down
+DROP TABLE suspensions;
+
+DROP TABLE reports;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN role TEXT NOT NULL DEFAULT 'user';
+
+ALTER TABLE users
+ADD CONSTRAINT users_role_check
+CHECK (role IN ('user', 'moderator', 'admin'));
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN role;
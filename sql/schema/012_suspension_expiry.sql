@@ -0,0 +1,29 @@
+-- +goose Up
+ALTER TABLE suspensions
+ADD COLUMN expires_at TIMESTAMP DEFAULT null;
+
+ALTER TABLE suspensions
+ADD COLUMN lifted_at TIMESTAMP DEFAULT null;
+
+ALTER TABLE suspensions
+ADD COLUMN lifted_by UUID DEFAULT null;
+
+ALTER TABLE suspensions
+ADD CONSTRAINT fk_lifted_by
+FOREIGN KEY (lifted_by)
+REFERENCES users(id)
+ON DELETE SET NULL;
+
+CREATE INDEX suspensions_user_idx ON suspensions (user_id);
+
+-- +goose Down
+DROP INDEX suspensions_user_idx;
+
+ALTER TABLE suspensions
+DROP COLUMN lifted_by;
+
+ALTER TABLE suspensions
+DROP COLUMN lifted_at;
+
+ALTER TABLE suspensions
+DROP COLUMN expires_at;
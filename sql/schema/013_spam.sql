@@ -0,0 +1,31 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN body_hash TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE chirps
+ADD COLUMN simhash BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX chirps_user_created_idx ON chirps (user_id, created_at);
+
+CREATE TABLE url_blocklist(
+    domain text primary key not null,
+    created_at timestamp not null,
+    created_by uuid
+);
+
+ALTER TABLE url_blocklist
+ADD CONSTRAINT fk_created_by
+FOREIGN KEY (created_by)
+REFERENCES users(id)
+ON DELETE SET NULL;
+
+-- +goose Down
+DROP TABLE url_blocklist;
+
+DROP INDEX chirps_user_created_idx;
+
+ALTER TABLE chirps
+DROP COLUMN simhash;
+
+ALTER TABLE chirps
+DROP COLUMN body_hash;
@@ -0,0 +1,42 @@
+-- +goose Up
+CREATE TABLE audit_log(
+    id bigserial primary key,
+    created_at timestamp not null,
+    action text not null,
+    actor_id uuid,
+    target_type text not null default '',
+    target_id text not null default '',
+    ip text not null default '',
+    user_agent text not null default '',
+    request_id text not null default '',
+    metadata text not null default '{}',
+    prev_hash text not null,
+    hash text not null unique
+);
+
+CREATE INDEX audit_log_action_idx ON audit_log (action, id);
+
+CREATE INDEX audit_log_actor_idx ON audit_log (actor_id, id);
+
+CREATE INDEX audit_log_target_idx ON audit_log (target_id, id);
+
+-- +goose StatementBegin
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_log_no_update
+BEFORE UPDATE OR DELETE ON audit_log
+FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate
+BEFORE TRUNCATE ON audit_log
+FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+
+-- +goose Down
+DROP TABLE audit_log;
+
+DROP FUNCTION audit_log_append_only;
@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE follows(
+    follower_id uuid not null,
+    followee_id uuid not null,
+    created_at timestamp not null,
+    primary key (follower_id, followee_id)
+);
+
+ALTER TABLE follows
+ADD CONSTRAINT follows_not_self_check
+CHECK (follower_id <> followee_id);
+
+ALTER TABLE follows
+ADD CONSTRAINT fk_follower
+FOREIGN KEY (follower_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+ALTER TABLE follows
+ADD CONSTRAINT fk_followee
+FOREIGN KEY (followee_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+CREATE INDEX follows_follower_idx ON follows (follower_id, created_at);
+
+CREATE INDEX follows_followee_idx ON follows (followee_id, created_at);
+
+-- +goose Down
+DROP TABLE follows;
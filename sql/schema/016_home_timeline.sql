@@ -0,0 +1,39 @@
+-- +goose Up
+CREATE TABLE home_timeline(
+    user_id uuid not null,
+    chirp_id uuid not null,
+    author_id uuid not null,
+    created_at timestamp not null,
+    primary key (user_id, chirp_id)
+);
+
+ALTER TABLE home_timeline
+ADD CONSTRAINT fk_user
+FOREIGN KEY (user_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+ALTER TABLE home_timeline
+ADD CONSTRAINT fk_chirp
+FOREIGN KEY (chirp_id)
+REFERENCES chirps(id)
+ON DELETE CASCADE;
+
+CREATE INDEX home_timeline_user_idx ON home_timeline (user_id, created_at DESC, chirp_id DESC);
+
+CREATE INDEX home_timeline_author_idx ON home_timeline (user_id, author_id);
+
+-- chirps that were not copied in the timelines of the followers of their
+-- author, existing ones included, are read straight from the chirps table
+ALTER TABLE chirps
+ADD COLUMN fanned_out boolean not null default false;
+
+CREATE INDEX chirps_not_fanned_out_idx ON chirps (user_id, created_at) WHERE NOT fanned_out;
+
+-- +goose Down
+DROP INDEX chirps_not_fanned_out_idx;
+
+ALTER TABLE chirps
+DROP COLUMN fanned_out;
+
+DROP TABLE home_timeline;
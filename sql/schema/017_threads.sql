@@ -0,0 +1,40 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN in_reply_to uuid DEFAULT null;
+
+ALTER TABLE chirps
+ADD COLUMN root_id uuid DEFAULT null;
+
+-- deleted chirps with replies are kept as tombstones so the thread still renders
+ALTER TABLE chirps
+ADD COLUMN deleted_at timestamp DEFAULT null;
+
+ALTER TABLE chirps
+ADD CONSTRAINT fk_in_reply_to
+FOREIGN KEY (in_reply_to)
+REFERENCES chirps(id)
+ON DELETE SET NULL;
+
+ALTER TABLE chirps
+ADD CONSTRAINT fk_root
+FOREIGN KEY (root_id)
+REFERENCES chirps(id)
+ON DELETE SET NULL;
+
+CREATE INDEX chirps_in_reply_to_idx ON chirps (in_reply_to, created_at, id);
+
+CREATE INDEX chirps_root_idx ON chirps (root_id);
+
+-- +goose Down
+DROP INDEX chirps_root_idx;
+
+DROP INDEX chirps_in_reply_to_idx;
+
+ALTER TABLE chirps
+DROP COLUMN deleted_at;
+
+ALTER TABLE chirps
+DROP COLUMN root_id;
+
+ALTER TABLE chirps
+DROP COLUMN in_reply_to;
@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE likes(
+    user_id uuid not null,
+    chirp_id uuid not null,
+    created_at timestamp not null,
+    primary key (user_id, chirp_id)
+);
+
+ALTER TABLE likes
+ADD CONSTRAINT fk_user
+FOREIGN KEY (user_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+ALTER TABLE likes
+ADD CONSTRAINT fk_chirp
+FOREIGN KEY (chirp_id)
+REFERENCES chirps(id)
+ON DELETE CASCADE;
+
+CREATE INDEX likes_chirp_idx ON likes (chirp_id);
+
+CREATE INDEX likes_user_created_idx ON likes (user_id, created_at DESC, chirp_id DESC);
+
+-- +goose Down
+DROP TABLE likes;
@@ -0,0 +1,42 @@
+-- +goose Up
+-- a rechirp is a chirp without a body pointing to the chirp it reposts, so
+-- it goes through the timelines and the author listings like any other chirp
+ALTER TABLE chirps
+ADD COLUMN rechirp_of uuid DEFAULT null;
+
+-- quotes have no foreign key, a quote outlives the chirp it quotes
+-- which is then shown as unavailable
+ALTER TABLE chirps
+ADD COLUMN quote_of uuid DEFAULT null;
+
+ALTER TABLE chirps
+ADD CONSTRAINT fk_rechirp_of
+FOREIGN KEY (rechirp_of)
+REFERENCES chirps(id)
+ON DELETE CASCADE;
+
+ALTER TABLE chirps
+ADD CONSTRAINT rechirp_or_quote
+CHECK (rechirp_of IS null OR quote_of IS null);
+
+CREATE UNIQUE INDEX chirps_rechirp_unique_idx ON chirps (user_id, rechirp_of) WHERE rechirp_of IS NOT null;
+
+CREATE INDEX chirps_rechirp_of_idx ON chirps (rechirp_of) WHERE rechirp_of IS NOT null;
+
+CREATE INDEX chirps_quote_of_idx ON chirps (quote_of) WHERE quote_of IS NOT null;
+
+-- +goose Down
+DROP INDEX chirps_quote_of_idx;
+
+DROP INDEX chirps_rechirp_of_idx;
+
+DROP INDEX chirps_rechirp_unique_idx;
+
+ALTER TABLE chirps
+DROP CONSTRAINT rechirp_or_quote;
+
+ALTER TABLE chirps
+DROP COLUMN quote_of;
+
+ALTER TABLE chirps
+DROP COLUMN rechirp_of;
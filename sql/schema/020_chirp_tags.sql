@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE chirp_tags(
+    chirp_id uuid not null,
+    tag text not null,
+    created_at timestamp not null,
+    primary key (chirp_id, tag)
+);
+
+ALTER TABLE chirp_tags
+ADD CONSTRAINT fk_chirp
+FOREIGN KEY (chirp_id)
+REFERENCES chirps(id)
+ON DELETE CASCADE;
+
+CREATE INDEX chirp_tags_tag_idx ON chirp_tags (tag, created_at DESC, chirp_id DESC);
+
+CREATE INDEX chirp_tags_created_at_idx ON chirp_tags (created_at);
+
+-- rewritten as a whole by the trends worker
+CREATE TABLE trending_tags(
+    tag text primary key,
+    score double precision not null,
+    chirp_count bigint not null,
+    computed_at timestamp not null
+);
+
+-- +goose Down
+DROP TABLE trending_tags;
+
+DROP TABLE chirp_tags;
@@ -0,0 +1,34 @@
+-- +goose Up
+-- handles keep the case chosen by the user but are unique ignoring it
+ALTER TABLE users
+ADD COLUMN handle text DEFAULT null;
+
+CREATE UNIQUE INDEX users_handle_idx ON users (lower(handle));
+
+CREATE TABLE chirp_mentions(
+    chirp_id uuid not null,
+    user_id uuid not null,
+    primary key (chirp_id, user_id)
+);
+
+ALTER TABLE chirp_mentions
+ADD CONSTRAINT fk_chirp
+FOREIGN KEY (chirp_id)
+REFERENCES chirps(id)
+ON DELETE CASCADE;
+
+ALTER TABLE chirp_mentions
+ADD CONSTRAINT fk_user
+FOREIGN KEY (user_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+CREATE INDEX chirp_mentions_user_idx ON chirp_mentions (user_id);
+
+-- +goose Down
+DROP TABLE chirp_mentions;
+
+DROP INDEX users_handle_idx;
+
+ALTER TABLE users
+DROP COLUMN handle;
@@ -0,0 +1,57 @@
+-- +goose Up
+-- a notification groups the events of the same kind on the same subject,
+-- group_key, while it is unread: once read the next event starts a new one
+CREATE TABLE notifications(
+    id uuid primary key,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    user_id uuid not null,
+    kind text not null,
+    chirp_id uuid DEFAULT null,
+    group_key text not null,
+    read_at timestamp DEFAULT null
+);
+
+ALTER TABLE notifications
+ADD CONSTRAINT fk_user
+FOREIGN KEY (user_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+ALTER TABLE notifications
+ADD CONSTRAINT fk_chirp
+FOREIGN KEY (chirp_id)
+REFERENCES chirps(id)
+ON DELETE CASCADE;
+
+ALTER TABLE notifications
+ADD CONSTRAINT notifications_kind_check
+CHECK (kind IN ('mention', 'reply', 'like', 'follow', 'rechirp', 'red_upgrade'));
+
+CREATE UNIQUE INDEX notifications_unread_group_idx ON notifications (user_id, group_key) WHERE read_at IS null;
+
+CREATE INDEX notifications_user_idx ON notifications (user_id, updated_at DESC, id DESC);
+
+CREATE TABLE notification_actors(
+    notification_id uuid not null,
+    actor_id uuid not null,
+    created_at timestamp not null,
+    primary key (notification_id, actor_id)
+);
+
+ALTER TABLE notification_actors
+ADD CONSTRAINT fk_notification
+FOREIGN KEY (notification_id)
+REFERENCES notifications(id)
+ON DELETE CASCADE;
+
+ALTER TABLE notification_actors
+ADD CONSTRAINT fk_actor
+FOREIGN KEY (actor_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+-- +goose Down
+DROP TABLE notification_actors;
+
+DROP TABLE notifications;
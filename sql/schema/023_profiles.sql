@@ -0,0 +1,32 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN display_name text not null DEFAULT '';
+
+ALTER TABLE users
+ADD COLUMN bio text not null DEFAULT '';
+
+ALTER TABLE users
+ADD COLUMN location text not null DEFAULT '';
+
+ALTER TABLE users
+ADD COLUMN website text not null DEFAULT '';
+
+-- the handle can be changed again only after a cooldown
+ALTER TABLE users
+ADD COLUMN handle_changed_at timestamp DEFAULT null;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN handle_changed_at;
+
+ALTER TABLE users
+DROP COLUMN website;
+
+ALTER TABLE users
+DROP COLUMN location;
+
+ALTER TABLE users
+DROP COLUMN bio;
+
+ALTER TABLE users
+DROP COLUMN display_name;
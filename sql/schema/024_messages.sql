@@ -0,0 +1,71 @@
+-- +goose Up
+-- direct_key is set only for one to one conversations, so that the same
+-- two users always end up in the same conversation
+CREATE TABLE conversations(
+    id uuid primary key,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    created_by uuid DEFAULT null,
+    direct_key text unique DEFAULT null
+);
+
+ALTER TABLE conversations
+ADD CONSTRAINT fk_created_by
+FOREIGN KEY (created_by)
+REFERENCES users(id)
+ON DELETE SET NULL;
+
+CREATE TABLE conversation_participants(
+    conversation_id uuid not null,
+    user_id uuid not null,
+    joined_at timestamp not null,
+    last_read_at timestamp DEFAULT null,
+    primary key (conversation_id, user_id)
+);
+
+ALTER TABLE conversation_participants
+ADD CONSTRAINT fk_conversation
+FOREIGN KEY (conversation_id)
+REFERENCES conversations(id)
+ON DELETE CASCADE;
+
+ALTER TABLE conversation_participants
+ADD CONSTRAINT fk_user
+FOREIGN KEY (user_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+CREATE INDEX conversation_participants_user_idx ON conversation_participants (user_id);
+
+CREATE TABLE messages(
+    id uuid primary key,
+    created_at timestamp not null,
+    conversation_id uuid not null,
+    sender_id uuid not null,
+    body text not null,
+    body_hash text not null DEFAULT '',
+    simhash bigint not null DEFAULT 0
+);
+
+ALTER TABLE messages
+ADD CONSTRAINT fk_conversation
+FOREIGN KEY (conversation_id)
+REFERENCES conversations(id)
+ON DELETE CASCADE;
+
+ALTER TABLE messages
+ADD CONSTRAINT fk_sender
+FOREIGN KEY (sender_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+CREATE INDEX messages_conversation_idx ON messages (conversation_id, created_at DESC, id DESC);
+
+CREATE INDEX messages_sender_idx ON messages (sender_id, created_at);
+
+-- +goose Down
+DROP TABLE messages;
+
+DROP TABLE conversation_participants;
+
+DROP TABLE conversations;
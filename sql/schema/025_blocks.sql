@@ -0,0 +1,53 @@
+-- +goose Up
+CREATE TABLE blocks(
+    blocker_id uuid not null,
+    blocked_id uuid not null,
+    created_at timestamp not null,
+    primary key (blocker_id, blocked_id)
+);
+
+ALTER TABLE blocks
+ADD CONSTRAINT blocks_not_self_check
+CHECK (blocker_id <> blocked_id);
+
+ALTER TABLE blocks
+ADD CONSTRAINT fk_blocker
+FOREIGN KEY (blocker_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+ALTER TABLE blocks
+ADD CONSTRAINT fk_blocked
+FOREIGN KEY (blocked_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+CREATE INDEX blocks_blocked_idx ON blocks (blocked_id);
+
+CREATE TABLE mutes(
+    muter_id uuid not null,
+    muted_id uuid not null,
+    created_at timestamp not null,
+    expires_at timestamp,
+    primary key (muter_id, muted_id)
+);
+
+ALTER TABLE mutes
+ADD CONSTRAINT mutes_not_self_check
+CHECK (muter_id <> muted_id);
+
+ALTER TABLE mutes
+ADD CONSTRAINT fk_muter
+FOREIGN KEY (muter_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+ALTER TABLE mutes
+ADD CONSTRAINT fk_muted
+FOREIGN KEY (muted_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+-- +goose Down
+DROP TABLE mutes;
+DROP TABLE blocks;
@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE muted_words(
+    id uuid primary key,
+    created_at timestamp not null,
+    user_id uuid not null,
+    phrase text not null,
+    scope text not null,
+    action text not null,
+    whole_word boolean not null,
+    expires_at timestamp
+);
+
+ALTER TABLE muted_words
+ADD CONSTRAINT fk_user
+FOREIGN KEY (user_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+ALTER TABLE muted_words
+ADD CONSTRAINT muted_words_scope_check
+CHECK (scope IN ('home', 'notifications', 'everywhere'));
+
+ALTER TABLE muted_words
+ADD CONSTRAINT muted_words_action_check
+CHECK (action IN ('hide', 'warn'));
+
+CREATE UNIQUE INDEX muted_words_user_phrase_idx ON muted_words (user_id, lower(phrase));
+
+-- +goose Down
+DROP TABLE muted_words;
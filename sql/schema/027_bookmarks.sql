@@ -0,0 +1,43 @@
+-- +goose Up
+CREATE TABLE bookmark_folders(
+    id uuid primary key,
+    created_at timestamp not null,
+    user_id uuid not null,
+    name text not null
+);
+
+ALTER TABLE bookmark_folders
+ADD CONSTRAINT fk_user
+FOREIGN KEY (user_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX bookmark_folders_user_name_idx ON bookmark_folders (user_id, lower(name));
+
+-- chirp_id has no foreign key so that the bookmarks of a deleted chirp
+-- are kept and listed as tombstones
+CREATE TABLE bookmarks(
+    user_id uuid not null,
+    chirp_id uuid not null,
+    folder_id uuid,
+    created_at timestamp not null,
+    primary key (user_id, chirp_id)
+);
+
+ALTER TABLE bookmarks
+ADD CONSTRAINT fk_user
+FOREIGN KEY (user_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+ALTER TABLE bookmarks
+ADD CONSTRAINT fk_folder
+FOREIGN KEY (folder_id)
+REFERENCES bookmark_folders(id)
+ON DELETE SET NULL;
+
+CREATE INDEX bookmarks_user_created_idx ON bookmarks (user_id, created_at DESC, chirp_id DESC);
+
+-- +goose Down
+DROP TABLE bookmarks;
+DROP TABLE bookmark_folders;
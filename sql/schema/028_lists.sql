@@ -0,0 +1,65 @@
+-- +goose Up
+CREATE TABLE lists(
+    id uuid primary key,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    owner_id uuid not null,
+    name text not null,
+    description text not null default '',
+    private boolean not null default false
+);
+
+ALTER TABLE lists
+ADD CONSTRAINT fk_owner
+FOREIGN KEY (owner_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+CREATE INDEX lists_owner_idx ON lists (owner_id);
+
+CREATE TABLE list_members(
+    list_id uuid not null,
+    user_id uuid not null,
+    created_at timestamp not null,
+    primary key (list_id, user_id)
+);
+
+ALTER TABLE list_members
+ADD CONSTRAINT fk_list
+FOREIGN KEY (list_id)
+REFERENCES lists(id)
+ON DELETE CASCADE;
+
+ALTER TABLE list_members
+ADD CONSTRAINT fk_user
+FOREIGN KEY (user_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+CREATE INDEX list_members_user_idx ON list_members (user_id);
+
+CREATE TABLE list_subscriptions(
+    list_id uuid not null,
+    user_id uuid not null,
+    created_at timestamp not null,
+    primary key (list_id, user_id)
+);
+
+ALTER TABLE list_subscriptions
+ADD CONSTRAINT fk_list
+FOREIGN KEY (list_id)
+REFERENCES lists(id)
+ON DELETE CASCADE;
+
+ALTER TABLE list_subscriptions
+ADD CONSTRAINT fk_user
+FOREIGN KEY (user_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+CREATE INDEX list_subscriptions_user_idx ON list_subscriptions (user_id);
+
+-- +goose Down
+DROP TABLE list_subscriptions;
+DROP TABLE list_members;
+DROP TABLE lists;
@@ -0,0 +1,80 @@
+-- +goose Up
+-- a poll belongs to the chirp it was posted with, finalized_at is set by
+-- the worker once the poll closed and its participants were notified
+CREATE TABLE polls(
+    chirp_id uuid primary key,
+    created_at timestamp not null,
+    closes_at timestamp not null,
+    finalized_at timestamp DEFAULT null
+);
+
+ALTER TABLE polls
+ADD CONSTRAINT fk_chirp
+FOREIGN KEY (chirp_id)
+REFERENCES chirps(id)
+ON DELETE CASCADE;
+
+CREATE INDEX polls_pending_idx ON polls (closes_at) WHERE finalized_at IS null;
+
+-- vote_count is kept in the same statement that stores the vote
+CREATE TABLE poll_options(
+    id uuid primary key,
+    chirp_id uuid not null,
+    position int not null,
+    text text not null,
+    vote_count bigint not null DEFAULT 0,
+    UNIQUE (chirp_id, position)
+);
+
+ALTER TABLE poll_options
+ADD CONSTRAINT fk_poll
+FOREIGN KEY (chirp_id)
+REFERENCES polls(chirp_id)
+ON DELETE CASCADE;
+
+CREATE TABLE poll_votes(
+    chirp_id uuid not null,
+    user_id uuid not null,
+    option_id uuid not null,
+    created_at timestamp not null,
+    primary key (chirp_id, user_id)
+);
+
+ALTER TABLE poll_votes
+ADD CONSTRAINT fk_poll
+FOREIGN KEY (chirp_id)
+REFERENCES polls(chirp_id)
+ON DELETE CASCADE;
+
+ALTER TABLE poll_votes
+ADD CONSTRAINT fk_user
+FOREIGN KEY (user_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+ALTER TABLE poll_votes
+ADD CONSTRAINT fk_option
+FOREIGN KEY (option_id)
+REFERENCES poll_options(id)
+ON DELETE CASCADE;
+
+ALTER TABLE notifications
+DROP CONSTRAINT notifications_kind_check;
+
+ALTER TABLE notifications
+ADD CONSTRAINT notifications_kind_check
+CHECK (kind IN ('mention', 'reply', 'like', 'follow', 'rechirp', 'red_upgrade', 'poll_closed'));
+
+-- +goose Down
+DELETE FROM notifications WHERE kind = 'poll_closed';
+
+ALTER TABLE notifications
+DROP CONSTRAINT notifications_kind_check;
+
+ALTER TABLE notifications
+ADD CONSTRAINT notifications_kind_check
+CHECK (kind IN ('mention', 'reply', 'like', 'follow', 'rechirp', 'red_upgrade'));
+
+DROP TABLE poll_votes;
+DROP TABLE poll_options;
+DROP TABLE polls;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- a scheduled chirp without publish_at is a draft. The row is deleted in
+-- the transaction that publishes it, last_error tells why a scheduled
+-- chirp could not be published and was turned back into a draft
+CREATE TABLE scheduled_chirps(
+    id uuid primary key,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    user_id uuid not null,
+    body text not null,
+    in_reply_to uuid DEFAULT null,
+    quote_of uuid DEFAULT null,
+    publish_at timestamp DEFAULT null,
+    last_error text DEFAULT null
+);
+
+ALTER TABLE scheduled_chirps
+ADD CONSTRAINT fk_user
+FOREIGN KEY (user_id)
+REFERENCES users(id)
+ON DELETE CASCADE;
+
+CREATE INDEX scheduled_chirps_user_idx ON scheduled_chirps (user_id);
+
+CREATE INDEX scheduled_chirps_due_idx ON scheduled_chirps (publish_at) WHERE publish_at IS NOT null;
+
+-- +goose Down
+DROP TABLE scheduled_chirps;
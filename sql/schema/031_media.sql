@@ -0,0 +1,38 @@
+-- +goose Up
+-- media are uploaded before the chirp using them is posted. Media that are
+-- not attached to a chirp after a while, belong to a deleted chirp or to
+-- a deleted user are orphaned and collected with their blobs
+CREATE TABLE media(
+    id uuid primary key,
+    created_at timestamp not null,
+    user_id uuid,
+    chirp_id uuid DEFAULT null,
+    position int not null DEFAULT 0,
+    content_type text not null,
+    width int not null,
+    height int not null,
+    size bigint not null,
+    alt_text text not null DEFAULT '',
+    blurhash text not null,
+    original_key text not null,
+    thumbnail_key text not null
+);
+
+ALTER TABLE media
+ADD CONSTRAINT fk_user
+FOREIGN KEY (user_id)
+REFERENCES users(id)
+ON DELETE SET NULL;
+
+ALTER TABLE media
+ADD CONSTRAINT fk_chirp
+FOREIGN KEY (chirp_id)
+REFERENCES chirps(id)
+ON DELETE SET NULL;
+
+CREATE INDEX media_chirp_idx ON media (chirp_id, position);
+
+CREATE INDEX media_unattached_idx ON media (created_at) WHERE chirp_id IS null;
+
+-- +goose Down
+DROP TABLE media;
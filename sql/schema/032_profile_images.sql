@@ -0,0 +1,15 @@
+-- +goose Up
+-- the keys are the blob names of the largest size of the images, named
+-- after their content, the smaller sizes are stored next to them
+ALTER TABLE users
+ADD COLUMN avatar_key text DEFAULT null;
+
+ALTER TABLE users
+ADD COLUMN banner_key text DEFAULT null;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN banner_key;
+
+ALTER TABLE users
+DROP COLUMN avatar_key;
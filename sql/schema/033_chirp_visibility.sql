@@ -0,0 +1,18 @@
+-- +goose Up
+-- followers chirps are readable by the followers of the author and the
+-- mentioned users, mentioned chirps by the mentioned users only. The author
+-- always reads their own chirps
+ALTER TABLE chirps
+ADD COLUMN visibility text not null DEFAULT 'public'
+CONSTRAINT chirps_visibility_check CHECK (visibility IN ('public', 'followers', 'mentioned'));
+
+ALTER TABLE scheduled_chirps
+ADD COLUMN visibility text not null DEFAULT 'public'
+CONSTRAINT scheduled_chirps_visibility_check CHECK (visibility IN ('public', 'followers', 'mentioned'));
+
+-- +goose Down
+ALTER TABLE scheduled_chirps
+DROP COLUMN visibility;
+
+ALTER TABLE chirps
+DROP COLUMN visibility;
@@ -0,0 +1,46 @@
+-- +goose Up
+-- the author sets content_warning and sensitive, moderators set the forced
+-- ones which the edits of the author leave alone
+ALTER TABLE chirps
+ADD COLUMN content_warning text not null DEFAULT '';
+
+ALTER TABLE chirps
+ADD COLUMN sensitive boolean not null DEFAULT false;
+
+ALTER TABLE chirps
+ADD COLUMN forced_content_warning text not null DEFAULT '';
+
+ALTER TABLE chirps
+ADD COLUMN forced_sensitive boolean not null DEFAULT false;
+
+ALTER TABLE scheduled_chirps
+ADD COLUMN content_warning text not null DEFAULT '';
+
+ALTER TABLE scheduled_chirps
+ADD COLUMN sensitive boolean not null DEFAULT false;
+
+-- flagged chirps are shown collapsed unless the user asks otherwise
+ALTER TABLE users
+ADD COLUMN expand_flagged_content boolean not null DEFAULT false;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN expand_flagged_content;
+
+ALTER TABLE scheduled_chirps
+DROP COLUMN sensitive;
+
+ALTER TABLE scheduled_chirps
+DROP COLUMN content_warning;
+
+ALTER TABLE chirps
+DROP COLUMN forced_sensitive;
+
+ALTER TABLE chirps
+DROP COLUMN forced_content_warning;
+
+ALTER TABLE chirps
+DROP COLUMN sensitive;
+
+ALTER TABLE chirps
+DROP COLUMN content_warning;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- counts the publications that failed on an error of the server, the
+-- scheduler retries them later and gives up after a few attempts
+ALTER TABLE scheduled_chirps
+ADD COLUMN attempts integer not null DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE scheduled_chirps
+DROP COLUMN attempts;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- the chirps of a deleted user that others replied to are handed to this
+-- account as tombstones, the threads keep their shape once the author is
+-- gone. It has no handle and no usable password, nobody can log into it
+INSERT INTO users (id, created_at, updated_at, email, display_name)
+VALUES ('00000000-0000-0000-0000-000000000001', NOW(), NOW(), 'deleted-user@chirpy.invalid', 'Deleted user');
+
+-- +goose Down
+DELETE FROM users
+WHERE id = '00000000-0000-0000-0000-000000000001';
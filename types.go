@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +16,16 @@ type User struct {
 	Email     string `json:"email"`
 	HashedPassword string `json:"hashed_password"`
 	IsChirpyred bool `json:"is_chirpy_red"`
+	Role string `json:"role"`
+	FollowersCount int64 `json:"followers_count"`
+	FollowingCount int64 `json:"following_count"`
+	Handle *string `json:"handle"`
+	DisplayName string `json:"display_name"`
+	Bio string `json:"bio"`
+	Location string `json:"location"`
+	Website string `json:"website"`
+	Avatar map[string]string `json:"avatar"`
+	Banner map[string]string `json:"banner"`
 }
 
 func (u *User) mapUser(user *database.User) {
@@ -24,6 +35,48 @@ func (u *User) mapUser(user *database.User) {
 	u.Email = user.Email
 	u.HashedPassword = user.HashedPassword
 	u.IsChirpyred = user.IsChirpyRed
+	u.Role = user.Role
+	u.Handle = nil
+	if user.Handle.Valid {
+		u.Handle = &user.Handle.String
+	}
+	u.DisplayName = user.DisplayName
+	u.Bio = user.Bio
+	u.Location = user.Location
+	u.Website = user.Website
+	u.Avatar = avatarImage.urls(user.AvatarKey)
+	u.Banner = bannerImage.urls(user.BannerKey)
+}
+
+// Profile is the public view of a user, without the email
+type Profile struct {
+	Id        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Handle *string `json:"handle"`
+	DisplayName string `json:"display_name"`
+	Bio string `json:"bio"`
+	Location string `json:"location"`
+	Website string `json:"website"`
+	Avatar map[string]string `json:"avatar"`
+	Banner map[string]string `json:"banner"`
+	IsChirpyRed bool `json:"is_chirpy_red"`
+	FollowersCount int64 `json:"followers_count"`
+	FollowingCount int64 `json:"following_count"`
+}
+
+func (p *Profile) mapProfile(user *User) {
+	p.Id = user.Id
+	p.CreatedAt = user.CreatedAt
+	p.Handle = user.Handle
+	p.DisplayName = user.DisplayName
+	p.Bio = user.Bio
+	p.Location = user.Location
+	p.Website = user.Website
+	p.Avatar = user.Avatar
+	p.Banner = user.Banner
+	p.IsChirpyRed = user.IsChirpyred
+	p.FollowersCount = user.FollowersCount
+	p.FollowingCount = user.FollowingCount
 }
 
 type TemplateData struct {
@@ -36,6 +89,60 @@ type Chirp struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	Body     string `json:"body"`
 	UserId uuid.UUID `json:"user_id"`
+	Visibility string `json:"visibility"`
+	InReplyTo *uuid.UUID `json:"in_reply_to"`
+	RootId *uuid.UUID `json:"root_id"`
+	ReplyCount int64 `json:"reply_count"`
+	LikeCount int64 `json:"like_count"`
+	LikedByMe bool `json:"liked_by_me"`
+	RechirpOf *ChirpRef `json:"rechirp_of"`
+	QuoteOf *ChirpRef `json:"quote_of"`
+	RechirpCount int64 `json:"rechirp_count"`
+	QuoteCount int64 `json:"quote_count"`
+	Filtered *FilterMatch `json:"filtered"`
+	ContentWarning *ContentWarning `json:"content_warning"`
+	Poll *Poll `json:"poll"`
+	Media []Media `json:"media"`
+}
+
+// FilterMatch marks a chirp to be shown collapsed because of the muted
+// words of the viewer, null for chirps shown as they are
+type FilterMatch struct {
+	Phrases []string `json:"phrases"`
+}
+
+// ContentWarning marks a chirp with a content warning or flagged as
+// sensitive, by its author or by a moderator, null for the other chirps.
+// Collapsed follows the preference of the viewer
+type ContentWarning struct {
+	Text string `json:"text"`
+	Sensitive bool `json:"sensitive"`
+	ByModerator bool `json:"by_moderator"`
+	Collapsed bool `json:"collapsed"`
+}
+
+// Poll is the poll of a chirp as seen by the viewer, the votes are null
+// until the viewer voted or the poll closed
+type Poll struct {
+	ClosesAt time.Time `json:"closes_at"`
+	Closed bool `json:"closed"`
+	Options []PollOption `json:"options"`
+	TotalVotes *int64 `json:"total_votes"`
+	VotedOption *uuid.UUID `json:"voted_option"`
+}
+
+type PollOption struct {
+	Id uuid.UUID `json:"id"`
+	Text string `json:"text"`
+	Votes *int64 `json:"votes"`
+}
+
+// ChirpRef is a chirp embedded in another one, Chirp is nil when the
+// referenced chirp is unavailable or when it is nested too deep to be loaded
+type ChirpRef struct {
+	Id uuid.UUID `json:"id"`
+	Unavailable bool `json:"unavailable"`
+	Chirp *Chirp `json:"chirp"`
 }
 
 func (c *Chirp) mapChirp(chirp *database.Chirp) {
@@ -44,5 +151,544 @@ func (c *Chirp) mapChirp(chirp *database.Chirp) {
 	c.UpdatedAt = chirp.UpdatedAt
 	c.Body = chirp.Body
 	c.UserId = chirp.UserID
+	c.Visibility = chirp.Visibility
+	c.InReplyTo = nil
+	if chirp.InReplyTo.Valid {
+		c.InReplyTo = &chirp.InReplyTo.UUID
+	}
+	c.RootId = nil
+	if chirp.RootID.Valid {
+		c.RootId = &chirp.RootID.UUID
+	}
+	c.RechirpOf = nil
+	if chirp.RechirpOf.Valid {
+		c.RechirpOf = &ChirpRef{Id: chirp.RechirpOf.UUID}
+	}
+	c.QuoteOf = nil
+	if chirp.QuoteOf.Valid {
+		c.QuoteOf = &ChirpRef{Id: chirp.QuoteOf.UUID}
+	}
+	c.ContentWarning = nil
+	// the warning of a moderator is shown when both set one, the author
+	// can not hide it behind a milder text of their own
+	text := chirp.ForcedContentWarning
+	if text == "" {
+		text = chirp.ContentWarning
+	}
+	if text != "" || chirp.Sensitive || chirp.ForcedSensitive {
+		c.ContentWarning = &ContentWarning{
+			Text: text,
+			Sensitive: chirp.Sensitive || chirp.ForcedSensitive,
+			ByModerator: chirp.ForcedContentWarning != "" || chirp.ForcedSensitive,
+			Collapsed: true,
+		}
+	}
+}
+
+type WordList struct {
+	Id        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name string `json:"name"`
+	Action string `json:"action"`
+	Words []string `json:"words"`
+}
+
+func (wl *WordList) mapWordList(list *database.WordList, entries []database.WordListEntry) {
+	wl.Id = list.ID
+	wl.CreatedAt = list.CreatedAt
+	wl.UpdatedAt = list.UpdatedAt
+	wl.Name = list.Name
+	wl.Action = list.Action
+	wl.Words = []string{}
+	for _, entry := range entries {
+		if entry.ListID == list.ID {
+			wl.Words = append(wl.Words, entry.Word)
+		}
+	}
+}
+
+type Report struct {
+	Id        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ChirpId *uuid.UUID `json:"chirp_id"`
+	ChirpAuthorId uuid.UUID `json:"chirp_author_id"`
+	ChirpBody string `json:"chirp_body"`
+	ReporterId uuid.UUID `json:"reporter_id"`
+	Reason string `json:"reason"`
+	Details string `json:"details"`
+	Status string `json:"status"`
+	ResolutionNote *string `json:"resolution_note"`
+	ResolvedBy *uuid.UUID `json:"resolved_by"`
+	ResolvedAt *time.Time `json:"resolved_at"`
 }
 
+func (rep *Report) mapReport(report *database.Report) {
+	rep.Id = report.ID
+	rep.CreatedAt = report.CreatedAt
+	rep.UpdatedAt = report.UpdatedAt
+	rep.ChirpId = nil
+	if report.ChirpID.Valid {
+		rep.ChirpId = &report.ChirpID.UUID
+	}
+	rep.ChirpAuthorId = report.ChirpAuthorID
+	rep.ChirpBody = report.ChirpBody
+	rep.ReporterId = report.ReporterID
+	rep.Reason = report.Reason
+	rep.Details = report.Details
+	rep.Status = report.Status
+	rep.ResolutionNote = nil
+	if report.ResolutionNote.Valid {
+		rep.ResolutionNote = &report.ResolutionNote.String
+	}
+	rep.ResolvedBy = nil
+	if report.ResolvedBy.Valid {
+		rep.ResolvedBy = &report.ResolvedBy.UUID
+	}
+	rep.ResolvedAt = nil
+	if report.ResolvedAt.Valid {
+		rep.ResolvedAt = &report.ResolvedAt.Time
+	}
+}
+
+// OwnReport is what a reporter gets to see about their own reports,
+// the moderator identity and internal notes are left out
+type OwnReport struct {
+	Id        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	ChirpId *uuid.UUID `json:"chirp_id"`
+	Reason string `json:"reason"`
+	Details string `json:"details"`
+	Status string `json:"status"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+}
+
+func (own *OwnReport) mapOwnReport(report *database.Report) {
+	own.Id = report.ID
+	own.CreatedAt = report.CreatedAt
+	own.ChirpId = nil
+	if report.ChirpID.Valid {
+		own.ChirpId = &report.ChirpID.UUID
+	}
+	own.Reason = report.Reason
+	own.Details = report.Details
+	own.Status = report.Status
+	own.ResolvedAt = nil
+	if report.ResolvedAt.Valid {
+		own.ResolvedAt = &report.ResolvedAt.Time
+	}
+}
+
+type Suspension struct {
+	Id        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserId uuid.UUID `json:"user_id"`
+	ModeratorId *uuid.UUID `json:"moderator_id"`
+	Reason string `json:"reason"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	LiftedAt *time.Time `json:"lifted_at"`
+	LiftedBy *uuid.UUID `json:"lifted_by"`
+}
+
+func (s *Suspension) mapSuspension(suspension *database.Suspension) {
+	s.Id = suspension.ID
+	s.CreatedAt = suspension.CreatedAt
+	s.UserId = suspension.UserID
+	s.ModeratorId = nil
+	if suspension.ModeratorID.Valid {
+		s.ModeratorId = &suspension.ModeratorID.UUID
+	}
+	s.Reason = suspension.Reason
+	s.ExpiresAt = nil
+	if suspension.ExpiresAt.Valid {
+		s.ExpiresAt = &suspension.ExpiresAt.Time
+	}
+	s.LiftedAt = nil
+	if suspension.LiftedAt.Valid {
+		s.LiftedAt = &suspension.LiftedAt.Time
+	}
+	s.LiftedBy = nil
+	if suspension.LiftedBy.Valid {
+		s.LiftedBy = &suspension.LiftedBy.UUID
+	}
+}
+
+type BlockedDomain struct {
+	Domain string `json:"domain"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy *uuid.UUID `json:"created_by"`
+}
+
+func (b *BlockedDomain) mapBlockedDomain(domain *database.UrlBlocklist) {
+	b.Domain = domain.Domain
+	b.CreatedAt = domain.CreatedAt
+	b.CreatedBy = nil
+	if domain.CreatedBy.Valid {
+		b.CreatedBy = &domain.CreatedBy.UUID
+	}
+}
+
+type AuditEntry struct {
+	Id int64 `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Action string `json:"action"`
+	ActorId *uuid.UUID `json:"actor_id"`
+	TargetType string `json:"target_type"`
+	TargetId string `json:"target_id"`
+	IP string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+	RequestId string `json:"request_id"`
+	Metadata json.RawMessage `json:"metadata"`
+	PrevHash string `json:"prev_hash"`
+	Hash string `json:"hash"`
+}
+
+func (a *AuditEntry) mapAuditEntry(entry *database.AuditLog) {
+	a.Id = entry.ID
+	a.CreatedAt = entry.CreatedAt
+	a.Action = entry.Action
+	a.ActorId = nil
+	if entry.ActorID.Valid {
+		a.ActorId = &entry.ActorID.UUID
+	}
+	a.TargetType = entry.TargetType
+	a.TargetId = entry.TargetID
+	a.IP = entry.Ip
+	a.UserAgent = entry.UserAgent
+	a.RequestId = entry.RequestID
+	a.Metadata = json.RawMessage(entry.Metadata)
+	a.PrevHash = entry.PrevHash
+	a.Hash = entry.Hash
+}
+
+type AuditVerification struct {
+	Valid bool `json:"valid"`
+	Checked int64 `json:"checked"`
+	BrokenAt *int64 `json:"broken_at"`
+}
+
+type Follow struct {
+	UserId uuid.UUID `json:"user_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type FollowPage struct {
+	Users []Follow `json:"users"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type ChirpPage struct {
+	Chirps []Chirp `json:"chirps"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type Trend struct {
+	Tag string `json:"tag"`
+	Score float64 `json:"score"`
+	ChirpCount int64 `json:"chirp_count"`
+}
+
+func (t *Trend) mapTrend(trend *database.TrendingTag) {
+	t.Tag = trend.Tag
+	t.Score = trend.Score
+	t.ChirpCount = trend.ChirpCount
+}
+
+type Trends struct {
+	Trends []Trend `json:"trends"`
+	ComputedAt *time.Time `json:"computed_at"`
+}
+
+type Notification struct {
+	Id uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Kind string `json:"kind"`
+	ChirpId *uuid.UUID `json:"chirp_id"`
+	Actors []uuid.UUID `json:"actors"`
+	ActorCount int64 `json:"actor_count"`
+	Read bool `json:"read"`
+}
+
+// mapNotification takes the most recent actors of the notification
+// together with the total number of actors, which can be larger
+func (n *Notification) mapNotification(notification *database.Notification, actors []uuid.UUID, actorCount int64) {
+	n.Id = notification.ID
+	n.CreatedAt = notification.CreatedAt
+	n.UpdatedAt = notification.UpdatedAt
+	n.Kind = notification.Kind
+	n.ChirpId = nil
+	if notification.ChirpID.Valid {
+		n.ChirpId = &notification.ChirpID.UUID
+	}
+	n.Actors = actors
+	if n.Actors == nil {
+		n.Actors = []uuid.UUID{}
+	}
+	n.ActorCount = actorCount
+	n.Read = notification.ReadAt.Valid
+}
+
+type NotificationPage struct {
+	Notifications []Notification `json:"notifications"`
+	UnreadCount int64 `json:"unread_count"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type Message struct {
+	Id uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	ConversationId uuid.UUID `json:"conversation_id"`
+	SenderId uuid.UUID `json:"sender_id"`
+	Body string `json:"body"`
+}
+
+func (m *Message) mapMessage(message *database.Message) {
+	m.Id = message.ID
+	m.CreatedAt = message.CreatedAt
+	m.ConversationId = message.ConversationID
+	m.SenderId = message.SenderID
+	m.Body = message.Body
+}
+
+type MessagePage struct {
+	Messages []Message `json:"messages"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type Conversation struct {
+	Id uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Participants []uuid.UUID `json:"participants"`
+	LastMessage *Message `json:"last_message"`
+	UnreadCount int64 `json:"unread_count"`
+}
+
+func (c *Conversation) mapConversation(conversation *database.Conversation) {
+	c.Id = conversation.ID
+	c.CreatedAt = conversation.CreatedAt
+	c.UpdatedAt = conversation.UpdatedAt
+}
+
+type ConversationPage struct {
+	Conversations []Conversation `json:"conversations"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type ThreadNode struct {
+	Id uuid.UUID `json:"id"`
+	Tombstone bool `json:"tombstone"`
+	Chirp *Chirp `json:"chirp"`
+	Replies []ThreadNode `json:"replies,omitempty"`
+}
+
+type Thread struct {
+	Ancestors []ThreadNode `json:"ancestors"`
+	Chirp ThreadNode `json:"chirp"`
+	Truncated bool `json:"truncated"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type Block struct {
+	UserId uuid.UUID `json:"user_id"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+type BlockPage struct {
+	Users []Block `json:"users"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type Mute struct {
+	UserId uuid.UUID `json:"user_id"`
+	MutedAt time.Time `json:"muted_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (m *Mute) mapMute(mute *database.GetMutesRow) {
+	m.UserId = mute.UserID
+	m.MutedAt = mute.CreatedAt
+	m.ExpiresAt = nil
+	if mute.ExpiresAt.Valid {
+		m.ExpiresAt = &mute.ExpiresAt.Time
+	}
+}
+
+type MutePage struct {
+	Users []Mute `json:"users"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type MutedWord struct {
+	Id uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Phrase string `json:"phrase"`
+	Scope string `json:"scope"`
+	Action string `json:"action"`
+	WholeWord bool `json:"whole_word"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (mw *MutedWord) mapMutedWord(mutedWord *database.MutedWord) {
+	mw.Id = mutedWord.ID
+	mw.CreatedAt = mutedWord.CreatedAt
+	mw.Phrase = mutedWord.Phrase
+	mw.Scope = mutedWord.Scope
+	mw.Action = mutedWord.Action
+	mw.WholeWord = mutedWord.WholeWord
+	mw.ExpiresAt = nil
+	if mutedWord.ExpiresAt.Valid {
+		mw.ExpiresAt = &mutedWord.ExpiresAt.Time
+	}
+}
+
+// Bookmark holds the chirp as it is now, edits included. Chirp is nil and
+// Tombstone true when the chirp was deleted or is not visible anymore
+type Bookmark struct {
+	ChirpId uuid.UUID `json:"chirp_id"`
+	FolderId *uuid.UUID `json:"folder_id"`
+	BookmarkedAt time.Time `json:"bookmarked_at"`
+	Tombstone bool `json:"tombstone"`
+	Chirp *Chirp `json:"chirp"`
+}
+
+func (b *Bookmark) mapBookmark(bookmark *database.Bookmark) {
+	b.ChirpId = bookmark.ChirpID
+	b.FolderId = nil
+	if bookmark.FolderID.Valid {
+		b.FolderId = &bookmark.FolderID.UUID
+	}
+	b.BookmarkedAt = bookmark.CreatedAt
+}
+
+type BookmarkPage struct {
+	Bookmarks []Bookmark `json:"bookmarks"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type BookmarkFolder struct {
+	Id uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Name string `json:"name"`
+}
+
+func (bf *BookmarkFolder) mapBookmarkFolder(folder *database.BookmarkFolder) {
+	bf.Id = folder.ID
+	bf.CreatedAt = folder.CreatedAt
+	bf.Name = folder.Name
+}
+
+type List struct {
+	Id uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	OwnerId uuid.UUID `json:"owner_id"`
+	Name string `json:"name"`
+	Description string `json:"description"`
+	Private bool `json:"private"`
+	MemberCount int64 `json:"member_count"`
+	SubscriberCount int64 `json:"subscriber_count"`
+	SubscribedByMe bool `json:"subscribed_by_me"`
+}
+
+func (l *List) mapList(list *database.List) {
+	l.Id = list.ID
+	l.CreatedAt = list.CreatedAt
+	l.UpdatedAt = list.UpdatedAt
+	l.OwnerId = list.OwnerID
+	l.Name = list.Name
+	l.Description = list.Description
+	l.Private = list.Private
+}
+
+type ListMember struct {
+	UserId uuid.UUID `json:"user_id"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+type ListMemberPage struct {
+	Users []ListMember `json:"users"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ScheduledChirp is a draft or a chirp waiting to be published, LastError
+// tells why a scheduled chirp was turned back into a draft
+type ScheduledChirp struct {
+	Id uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Body string `json:"body"`
+	InReplyTo *uuid.UUID `json:"in_reply_to"`
+	QuoteOf *uuid.UUID `json:"quote_of"`
+	Visibility string `json:"visibility"`
+	ContentWarning string `json:"content_warning"`
+	Sensitive bool `json:"sensitive"`
+	Status string `json:"status"`
+	PublishAt *time.Time `json:"publish_at"`
+	LastError *string `json:"last_error"`
+}
+
+func (sc *ScheduledChirp) mapScheduledChirp(scheduled *database.ScheduledChirp) {
+	sc.Id = scheduled.ID
+	sc.CreatedAt = scheduled.CreatedAt
+	sc.UpdatedAt = scheduled.UpdatedAt
+	sc.Body = scheduled.Body
+	sc.InReplyTo = nil
+	if scheduled.InReplyTo.Valid {
+		sc.InReplyTo = &scheduled.InReplyTo.UUID
+	}
+	sc.QuoteOf = nil
+	if scheduled.QuoteOf.Valid {
+		sc.QuoteOf = &scheduled.QuoteOf.UUID
+	}
+	sc.Visibility = scheduled.Visibility
+	sc.ContentWarning = scheduled.ContentWarning
+	sc.Sensitive = scheduled.Sensitive
+	sc.Status = scheduledStatusDraft
+	sc.PublishAt = nil
+	if scheduled.PublishAt.Valid {
+		sc.Status = scheduledStatusScheduled
+		sc.PublishAt = &scheduled.PublishAt.Time
+	}
+	sc.LastError = nil
+	if scheduled.LastError.Valid {
+		sc.LastError = &scheduled.LastError.String
+	}
+}
+
+type Media struct {
+	Id uuid.UUID `json:"id"`
+	ContentType string `json:"content_type"`
+	Url string `json:"url"`
+	ThumbnailUrl string `json:"thumbnail_url"`
+	Width int32 `json:"width"`
+	Height int32 `json:"height"`
+	AltText string `json:"alt_text"`
+	Blurhash string `json:"blurhash"`
+}
+
+func (m *Media) mapMedia(medium *database.Medium) {
+	m.Id = medium.ID
+	m.ContentType = medium.ContentType
+	m.Url = mediaFileURL(medium.OriginalKey)
+	m.ThumbnailUrl = mediaFileURL(medium.ThumbnailKey)
+	m.Width = medium.Width
+	m.Height = medium.Height
+	m.AltText = medium.AltText
+	m.Blurhash = medium.Blurhash
+}
+
+func mediaFileURL(key string) string {
+	return "/api/media/files/" + key
+}
+
+// Preferences are the settings of a user that only the user sees
+type Preferences struct {
+	ExpandFlaggedContent bool `json:"expand_flagged_content"`
+}
+
+func (p *Preferences) mapPreferences(user *database.User) {
+	p.ExpandFlaggedContent = user.ExpandFlaggedContent
+}
@@ -1,43 +1,50 @@
 package main
 
 import (
-	"strings"
+	"errors"
+	"net/http"
 
+	"github.com/lib/pq"
 	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/moderation"
 )
 
 
-func ValidateChirp(body *string) *customErrors.CodedError {
+func ValidateChirp(body *string, filter *moderation.Filter) (moderation.Action, *customErrors.CodedError) {
 	maxChirpLength := 140
 	if len(*body) > maxChirpLength {
 		e := customErrors.CodedError{
 			Message:   "Error: chirp is too long\n",
 			StatusCode: 400,
 		}
-		return &e
+		return moderation.ActionNone, &e
 	}
 
-	cleanProfanity(body)
-
-	return nil
+	return cleanProfanity(body, filter)
 }
 
-func cleanProfanity(body *string) {
-	
-	badWords := map[string]bool{		
-		"kerfuffle": true,
-		"sharbert": true,
-		"fornax": true,
+// cleanProfanity runs the body through the word lists, censored words are
+// replaced in place while a match on a reject list refuses the whole chirp
+func cleanProfanity(body *string, filter *moderation.Filter) (moderation.Action, *customErrors.CodedError) {
+	res := filter.Apply(*body)
+	if res.Action == moderation.ActionReject {
+		e := customErrors.CodedError{
+			Message: "chirp contains prohibited language",
+			StatusCode: http.StatusUnprocessableEntity,
+		}
+		return res.Action, &e
 	}
 
-	censor := "****"
-	words := strings.Fields(*body)
+	*body = res.Text
 
-	for i, word := range  words {
-		if badWords[strings.ToLower(word)] {
-			words[i] = censor
-		}
+	return res.Action, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
 	}
 
-	*body = strings.Join(words, " ")
-}
\ No newline at end of file
+	return false
+}
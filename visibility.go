@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/niccolot/Chirpy/internal/customErrors"
+	"github.com/niccolot/Chirpy/internal/database"
+)
+
+
+const (
+	visibilityPublic = "public"
+	visibilityFollowers = "followers"
+	visibilityMentioned = "mentioned"
+)
+
+// visibilityRank orders the visibilities from the widest audience to the
+// narrowest, moving up the rank restricts a chirp
+var visibilityRank = map[string]int{
+	visibilityPublic: 0,
+	visibilityFollowers: 1,
+	visibilityMentioned: 2,
+}
+
+// validateVisibility checks the visibility of a new chirp, an empty one
+// becomes public
+func validateVisibility(visibility *string) *customErrors.CodedError {
+	if *visibility == "" {
+		*visibility = visibilityPublic
+	}
+
+	if _, ok := visibilityRank[*visibility]; !ok {
+		e := customErrors.CodedError{
+			Message: "visibility must be public, followers or mentioned",
+			StatusCode: http.StatusBadRequest,
+		}
+		return &e
+	}
+
+	return nil
+}
+
+// hiddenAmong returns the ids of the chirps that viewerId can not read.
+// Followers chirps are read by the followers of the author, both followers
+// and mentioned chirps by the users they mention, and every chirp by its
+// author. Anonymous requests only read public chirps
+func hiddenAmong(ctx context.Context, q *database.Queries, viewerId uuid.UUID, chirps []database.Chirp) (map[uuid.UUID]bool, *customErrors.CodedError) {
+	hidden := map[uuid.UUID]bool{}
+	restricted := []database.Chirp{}
+	for _, c := range chirps {
+		if c.Visibility == visibilityPublic || c.UserID == viewerId {
+			continue
+		}
+		if viewerId == uuid.Nil {
+			hidden[c.ID] = true
+			continue
+		}
+		restricted = append(restricted, c)
+	}
+
+	if len(restricted) == 0 {
+		return hidden, nil
+	}
+
+	ids := make([]uuid.UUID, len(restricted))
+	for i, c := range restricted {
+		ids[i] = c.ID
+	}
+
+	mentionedPars := database.GetMentionedAmongParams{
+		UserID: viewerId,
+		ChirpIds: ids,
+	}
+
+	mentionedIds, errMentioned := q.GetMentionedAmong(ctx, mentionedPars)
+	if errMentioned != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to check mentions: %w, function: %s",
+				errMentioned,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	followedPars := database.GetFollowedAmongParams{
+		FollowerID: viewerId,
+		UserIds: chirpAuthors(restricted),
+	}
+
+	followedIds, errFollowed := q.GetFollowedAmong(ctx, followedPars)
+	if errFollowed != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to check follows: %w, function: %s",
+				errFollowed,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return nil, &e
+	}
+
+	mentioned := make(map[uuid.UUID]bool, len(mentionedIds))
+	for _, id := range mentionedIds {
+		mentioned[id] = true
+	}
+
+	followed := make(map[uuid.UUID]bool, len(followedIds))
+	for _, id := range followedIds {
+		followed[id] = true
+	}
+
+	for _, c := range restricted {
+		if mentioned[c.ID] || (c.Visibility == visibilityFollowers && followed[c.UserID]) {
+			continue
+		}
+		hidden[c.ID] = true
+	}
+
+	return hidden, nil
+}
+
+// canRead tells if viewerId can read chirp, the handlers acting on a single
+// chirp answer as if it did not exist when it can not
+func canRead(ctx context.Context, q *database.Queries, viewerId uuid.UUID, chirp *database.Chirp) (bool, *customErrors.CodedError) {
+	hidden, errHidden := hiddenAmong(ctx, q, viewerId, []database.Chirp{*chirp})
+	if errHidden != nil {
+		return false, errHidden
+	}
+
+	return !hidden[chirp.ID], nil
+}
+
+func withoutChirps(chirps []database.Chirp, hidden map[uuid.UUID]bool) []database.Chirp {
+	if len(hidden) == 0 {
+		return chirps
+	}
+
+	kept := make([]database.Chirp, 0, len(chirps))
+	for _, c := range chirps {
+		if !hidden[c.ID] {
+			kept = append(kept, c)
+		}
+	}
+
+	return kept
+}
+
+// setChirpVisibility changes the visibility of chirp. Restricting it is
+// refused once somebody replied, the replies were written for the wider
+// audience, and drops the rechirps since only public chirps are rechirped
+func setChirpVisibility(ctx context.Context, q *database.Queries, chirp *database.Chirp, visibility string) *customErrors.CodedError {
+	restricts := visibilityRank[visibility] > visibilityRank[chirp.Visibility]
+	visibilityPars := database.SetChirpVisibilityParams{
+		Visibility: visibility,
+		ID: chirp.ID,
+		Restricts: restricts,
+	}
+
+	updated, errVisibility := q.SetChirpVisibility(ctx, visibilityPars)
+	if errVisibility != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to set chirp visibility: %w, function: %s",
+				errVisibility,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	if updated == 0 {
+		e := customErrors.CodedError{
+			Message: "visibility can not be restricted once the chirp has replies",
+			StatusCode: http.StatusConflict,
+		}
+		return &e
+	}
+
+	if !restricts || chirp.Visibility != visibilityPublic {
+		return nil
+	}
+
+	errRechirps := q.DeleteRechirpsOf(ctx, uuid.NullUUID{UUID: chirp.ID, Valid: true})
+	if errRechirps != nil {
+		e := customErrors.CodedError{
+			Message: fmt.Errorf("failed to delete rechirps: %w, function: %s",
+				errRechirps,
+				customErrors.GetFunctionName()).Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+		return &e
+	}
+
+	return nil
+}